@@ -0,0 +1,106 @@
+package e2eframe
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CaptureNameRegex validates the names of captured runtime variables.
+// Captured names follow the same rules as fixture names so that later tests
+// can reference them with `{{ name }}`.
+var CaptureNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// Captures maps runtime variable names to the location of their value in a
+// test response. The meaning of the location depends on the test kind
+// (e.g. a gjson path for HTTP bodies, a column name for postgres rows).
+type Captures map[string]string
+
+// UnmarshalYAML decodes a `capture:` block and validates the variable names.
+func (c *Captures) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("capture must be a mapping of variable names to sources, got %v", node.Kind)
+	}
+
+	captures := make(Captures, len(node.Content)/2)
+
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		value := node.Content[i+1]
+
+		if !CaptureNameRegex.MatchString(key.Value) {
+			return fmt.Errorf(
+				"invalid capture name %q at line %d: only letters, digits and underscores are allowed",
+				key.Value,
+				key.Line,
+			)
+		}
+
+		if value.Kind != yaml.ScalarNode || value.Value == "" {
+			return fmt.Errorf("capture %q at line %d must have a non-empty source", key.Value, key.Line)
+		}
+
+		captures[key.Value] = value.Value
+	}
+
+	*c = captures
+
+	return nil
+}
+
+// CaptureMissingError is returned when a capture source cannot be found in a test response.
+type CaptureMissingError struct {
+	Name   string
+	Source string
+	Reason string
+}
+
+func (e *CaptureMissingError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("capture %s: %s (%s)", e.Name, e.Reason, e.Source)
+	}
+
+	return fmt.Sprintf("capture %s: no value found at %s", e.Name, e.Source)
+}
+
+// CapturedFixture is a fixture whose value was captured from the response
+// of a previous test at runtime.
+type CapturedFixture struct {
+	CaptureName  string
+	CaptureValue string
+}
+
+func (f *CapturedFixture) Name() string {
+	return f.CaptureName
+}
+
+func (f *CapturedFixture) Value() []byte {
+	return []byte(f.CaptureValue)
+}
+
+// FormatCapturedValue converts a value read from a test response into the
+// string form used for interpolation. Structured values are encoded as JSON.
+func FormatCapturedValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case map[string]any, []any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,119 @@
+package e2eframe
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCapturesUnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    Captures
+		wantErr string
+	}{
+		{
+			name: "valid captures",
+			yaml: "user_id: body.id\ntoken: header.X-Token\n",
+			want: Captures{"user_id": "body.id", "token": "header.X-Token"},
+		},
+		{
+			name:    "not a mapping",
+			yaml:    "- body.id\n",
+			wantErr: "capture must be a mapping",
+		},
+		{
+			name:    "invalid name",
+			yaml:    "user.id: body.id\n",
+			wantErr: `invalid capture name "user.id"`,
+		},
+		{
+			name:    "empty source",
+			yaml:    "user_id: \"\"\n",
+			wantErr: `capture "user_id" at line 1 must have a non-empty source`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Captures
+
+			err := yaml.Unmarshal([]byte(tt.yaml), &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+
+			for name, source := range tt.want {
+				if got[name] != source {
+					t.Errorf("capture %s = %q, want %q", name, got[name], source)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatCapturedValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"nil", nil, ""},
+		{"string", "abc", "abc"},
+		{"bytes", []byte("abc"), "abc"},
+		{"int", int64(42), "42"},
+		{"float", 1.5, "1.5"},
+		{"bool", true, "true"},
+		{"time", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "2024-01-02T03:04:05Z"},
+		{"map", map[string]any{"a": 1}, `{"a":1}`},
+		{"slice", []any{"a", 1}, `["a",1]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatCapturedValue(tt.value); got != tt.want {
+				t.Errorf("FormatCapturedValue(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuntimeFixturesPreferCapturedValues(t *testing.T) {
+	suite := &TestSuiteV1{
+		Fixtures: []Fixture{
+			&FixtureV1{FixtureName: "user_id", FixtureValue: "seeded"},
+			&FixtureV1{FixtureName: "api_key", FixtureValue: "secret"},
+		},
+	}
+
+	if got := suite.runtimeFixtures(); len(got) != 2 {
+		t.Fatalf("expected suite fixtures only, got %d fixtures", len(got))
+	}
+
+	suite.storeCaptured(map[string]string{"user_id": "1"})
+	suite.storeCaptured(map[string]string{"user_id": "2"})
+
+	if len(suite.captured) != 1 {
+		t.Fatalf("expected recapturing a name to replace its value, got %d captured values", len(suite.captured))
+	}
+
+	got := InterpolateString(FixtureInterpolationRegex, "/users/{{ user_id }}?key={{ api_key }}", suite.runtimeFixtures())
+	if want := "/users/2?key=secret"; got != want {
+		t.Errorf("interpolated %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,303 @@
+package e2eframe
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// testCase is one row of a data-driven test (`cases:` or `matrix:`).
+type testCase struct {
+	// label identifies the case in the name of the expanded test
+	label string
+	// fixtures are the values of the row, in column order
+	fixtures []Fixture
+}
+
+// expandedTest is a test definition produced from a test and one of its cases.
+type expandedTest struct {
+	node     *yaml.Node
+	fixtures []Fixture
+}
+
+// expandTestCases returns the test definitions of a test node: the node itself,
+// or one definition per case when the test sets `cases:` or `matrix:`.
+// Expanded tests are named `<name> [<case>]`, where the case is its `name`
+// column if any, or its values.
+func (t *TestSuiteConfigV1) expandTestCases(node *yaml.Node) ([]expandedTest, error) {
+	casesKey, casesValue := mappingField(node, "cases")
+	matrixKey, matrixValue := mappingField(node, "matrix")
+
+	if casesValue == nil && matrixValue == nil {
+		return []expandedTest{{node: node}}, nil
+	}
+
+	if casesValue != nil && matrixValue != nil {
+		return nil, fmt.Errorf("test at line %d cannot set both cases and matrix", node.Line)
+	}
+
+	var (
+		cases []testCase
+		err   error
+	)
+
+	if casesValue != nil {
+		cases, err = t.decodeCases(casesKey, casesValue)
+	} else {
+		cases, err = decodeMatrix(matrixKey, matrixValue)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, nameValue := mappingField(node, "name")
+	if nameValue == nil {
+		return nil, fmt.Errorf("test at line %d must have a name to expand its cases", node.Line)
+	}
+
+	expanded := make([]expandedTest, 0, len(cases))
+	names := make(map[string]struct{}, len(cases))
+
+	for _, c := range cases {
+		name := fmt.Sprintf("%s [%s]", nameValue.Value, c.label)
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf(
+				"cases of test %s at line %d produce the same test name %q, add a distinct name column",
+				nameValue.Value,
+				node.Line,
+				name,
+			)
+		}
+
+		names[name] = struct{}{}
+
+		testNode := *node
+		testNode.Content = make([]*yaml.Node, 0, len(node.Content))
+
+		for i := 0; i < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			switch key.Value {
+			case "cases", "matrix":
+				continue
+			case "name":
+				value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name, Line: value.Line, Column: value.Column}
+			default:
+				value = cloneNode(value)
+			}
+
+			testNode.Content = append(testNode.Content, cloneNode(key), value)
+		}
+
+		expanded = append(expanded, expandedTest{node: &testNode, fixtures: c.fixtures})
+	}
+
+	return expanded, nil
+}
+
+// decodeCases reads the rows of `cases:`, either inline or from a CSV or JSON file.
+func (t *TestSuiteConfigV1) decodeCases(key, value *yaml.Node) ([]testCase, error) {
+	switch value.Kind {
+	case yaml.SequenceNode:
+		return casesFromRows(key, value.Content)
+	case yaml.MappingNode:
+		_, fileValue := mappingField(value, "file")
+		if fileValue == nil || len(value.Content) != 2 {
+			return nil, fmt.Errorf("cases at line %d must be a list of rows or a mapping with a single file field", key.Line)
+		}
+
+		return t.casesFromFile(key, fileValue.Value)
+	default:
+		return nil, fmt.Errorf("cases at line %d must be a list of rows or a file reference", key.Line)
+	}
+}
+
+// casesFromRows builds the cases of a list of YAML or JSON rows.
+func casesFromRows(key *yaml.Node, rows []*yaml.Node) ([]testCase, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("cases at line %d must have at least one row", key.Line)
+	}
+
+	cases := make([]testCase, 0, len(rows))
+
+	for _, row := range rows {
+		if row.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("case at line %d must be a mapping of values", row.Line)
+		}
+
+		var columns, values []string
+
+		for i := 0; i < len(row.Content); i += 2 {
+			value, err := caseValue(row.Content[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("case at line %d: %w", row.Line, err)
+			}
+
+			columns = append(columns, row.Content[i].Value)
+			values = append(values, value)
+		}
+
+		cases = append(cases, newTestCase(columns, values))
+	}
+
+	return cases, nil
+}
+
+// casesFromFile reads the rows of a CSV file with a header row, or of a JSON array of objects.
+// The path is relative to the file that defines the test.
+func (t *TestSuiteConfigV1) casesFromFile(key *yaml.Node, path string) ([]testCase, error) {
+	if !filepath.IsAbs(path) && t.SourceFile != "" {
+		path = filepath.Join(filepath.Dir(t.SourceFile), path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read cases file at line %d: %w", key.Line, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("could not parse cases file %s: %w", path, err)
+		}
+
+		if len(records) < 2 {
+			return nil, fmt.Errorf("cases file %s must have a header row and at least one row", path)
+		}
+
+		cases := make([]testCase, 0, len(records)-1)
+		for _, record := range records[1:] {
+			cases = append(cases, newTestCase(records[0], record))
+		}
+
+		return cases, nil
+	case ".json":
+		// JSON is valid YAML, decoding it as YAML keeps the order of the columns
+		var document yaml.Node
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("could not parse cases file %s: %w", path, err)
+		}
+
+		if len(document.Content) == 0 || document.Content[0].Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("cases file %s must contain an array of objects", path)
+		}
+
+		return casesFromRows(key, document.Content[0].Content)
+	default:
+		return nil, fmt.Errorf("cases file %s must be a .csv or .json file", path)
+	}
+}
+
+// decodeMatrix builds one case per combination of the values of `matrix:`.
+// Combinations are ordered by the columns as written, the last column varying fastest.
+func decodeMatrix(key, value *yaml.Node) ([]testCase, error) {
+	if value.Kind != yaml.MappingNode || len(value.Content) == 0 {
+		return nil, fmt.Errorf("matrix at line %d must map names to lists of values", key.Line)
+	}
+
+	var columns []string
+
+	var columnValues [][]string
+
+	for i := 0; i < len(value.Content); i += 2 {
+		column, list := value.Content[i], value.Content[i+1]
+		if list.Kind != yaml.SequenceNode || len(list.Content) == 0 {
+			return nil, fmt.Errorf("matrix %s at line %d must be a non-empty list of values", column.Value, column.Line)
+		}
+
+		values := make([]string, 0, len(list.Content))
+
+		for _, item := range list.Content {
+			v, err := caseValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("matrix %s at line %d: %w", column.Value, item.Line, err)
+			}
+
+			values = append(values, v)
+		}
+
+		columns = append(columns, column.Value)
+		columnValues = append(columnValues, values)
+	}
+
+	combinations := [][]string{{}}
+
+	for _, values := range columnValues {
+		next := make([][]string, 0, len(combinations)*len(values))
+
+		for _, combination := range combinations {
+			for _, v := range values {
+				next = append(next, append(combination[:len(combination):len(combination)], v))
+			}
+		}
+
+		combinations = next
+	}
+
+	cases := make([]testCase, 0, len(combinations))
+	for _, combination := range combinations {
+		cases = append(cases, newTestCase(columns, combination))
+	}
+
+	return cases, nil
+}
+
+// newTestCase creates a case from its columns and values.
+func newTestCase(columns, values []string) testCase {
+	c := testCase{fixtures: make([]Fixture, 0, len(columns))}
+
+	labels := make([]string, 0, len(columns))
+
+	for i, column := range columns {
+		c.fixtures = append(c.fixtures, &FixtureV1{FixtureName: column, FixtureValue: values[i]})
+
+		if column == "name" {
+			c.label = values[i]
+		}
+
+		labels = append(labels, column+"="+values[i])
+	}
+
+	if c.label == "" {
+		c.label = strings.Join(labels, ", ")
+	}
+
+	return c
+}
+
+// caseValue returns the string value of a case cell. Lists and mappings are encoded as JSON.
+func caseValue(node *yaml.Node) (string, error) {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value, nil
+	}
+
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return "", err
+	}
+
+	return FormatCapturedValue(value), nil
+}
+
+// cloneNode returns a deep copy of a YAML node.
+func cloneNode(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+
+	clone := *node
+	if node.Content != nil {
+		clone.Content = make([]*yaml.Node, len(node.Content))
+		for i, child := range node.Content {
+			clone.Content[i] = cloneNode(child)
+		}
+	}
+
+	return &clone
+}
@@ -0,0 +1,198 @@
+package e2eframe
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func caseValues(fixtures []Fixture) map[string]string {
+	values := make(map[string]string, len(fixtures))
+	for _, fixture := range fixtures {
+		values[fixture.Name()] = string(fixture.Value())
+	}
+
+	return values
+}
+
+func TestCasesExpandTests(t *testing.T) {
+	cfg, _, err := loadSuiteConfig(t, map[string]string{
+		"suite.yml": `
+kind: e2e_test:v1
+name: users
+target: app
+units:
+  - name: app
+    kind: stub
+tests:
+  - name: rejects invalid input
+    kind: stub
+    tags: [validation]
+    cases:
+      - name: bad email
+        field: email
+        value: not-an-email
+      - field: phone
+        value: "123"
+  - name: from csv
+    kind: stub
+    cases:
+      file: cases.csv
+  - name: from json
+    kind: stub
+    cases:
+      file: data/cases.json
+`,
+		"cases.csv": "status,body\n200,ok\n404,missing\n",
+		"data/cases.json": `[
+  {"name": "object", "payload": {"a": 1}},
+  {"name": "list", "payload": [1, 2]}
+]`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"rejects invalid input [bad email]",
+		"rejects invalid input [field=phone, value=123]",
+		"from csv [status=200, body=ok]",
+		"from csv [status=404, body=missing]",
+		"from json [object]",
+		"from json [list]",
+	}
+
+	if got := testNames(cfg.Tests); !reflect.DeepEqual(got, want) {
+		t.Fatalf("tests = %v, want %v", got, want)
+	}
+
+	meta := cfg.TestMetas["rejects invalid input [bad email]"]
+	if !meta.HasTag("validation") {
+		t.Error("expanded tests must keep the fields of the test")
+	}
+
+	wantValues := map[string]string{"name": "bad email", "field": "email", "value": "not-an-email"}
+	if got := caseValues(meta.Fixtures); !reflect.DeepEqual(got, wantValues) {
+		t.Errorf("case values = %v, want %v", got, wantValues)
+	}
+
+	if got := caseValues(cfg.TestMetas["from json [object]"].Fixtures)["payload"]; got != `{"a":1}` {
+		t.Errorf("structured case values must be encoded as JSON, got %s", got)
+	}
+}
+
+func TestMatrixExpandsCombinations(t *testing.T) {
+	cfg, _, err := loadSuiteConfig(t, map[string]string{
+		"suite.yml": `
+kind: e2e_test:v1
+name: users
+target: app
+units:
+  - name: app
+    kind: stub
+tests:
+  - name: list
+    kind: stub
+    matrix:
+      role: [admin, guest]
+      page: [1, 2]
+`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"list [role=admin, page=1]",
+		"list [role=admin, page=2]",
+		"list [role=guest, page=1]",
+		"list [role=guest, page=2]",
+	}
+
+	if got := testNames(cfg.Tests); !reflect.DeepEqual(got, want) {
+		t.Errorf("tests = %v, want %v", got, want)
+	}
+}
+
+func TestCasesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		test    string
+		wantErr string
+	}{
+		{
+			name: "duplicate case names",
+			test: `
+    cases:
+      - name: same
+      - name: same
+`,
+			wantErr: `produce the same test name "case test [same]"`,
+		},
+		{
+			name: "cases and matrix",
+			test: `
+    cases:
+      - a: 1
+    matrix:
+      b: [1]
+`,
+			wantErr: "cannot set both cases and matrix",
+		},
+		{
+			name: "empty matrix column",
+			test: `
+    matrix:
+      b: []
+`,
+			wantErr: "matrix b at line",
+		},
+		{
+			name: "unsupported file",
+			test: `
+    cases:
+      file: cases.txt
+`,
+			wantErr: "must be a .csv or .json file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := loadSuiteConfig(t, map[string]string{
+				"suite.yml": `
+kind: e2e_test:v1
+name: users
+target: app
+units:
+  - name: app
+    kind: stub
+tests:
+  - name: case test
+    kind: stub` + tt.test,
+				"cases.txt": "a\n1\n",
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTestFixturesPreferCaseValues(t *testing.T) {
+	test := &namedTest{name: "case"}
+	suite := &TestSuiteV1{
+		Fixtures: []Fixture{
+			&FixtureV1{FixtureName: "email", FixtureValue: "suite@example.com"},
+			&FixtureV1{FixtureName: "token", FixtureValue: "abc"},
+		},
+		TestMetas: map[string]TestMeta{
+			"case": {Fixtures: []Fixture{&FixtureV1{FixtureName: "email", FixtureValue: "case@example.com"}}},
+		},
+	}
+
+	got := InterpolateString(FixtureInterpolationRegex, "{{ email }} {{ token }}", suite.testFixtures(test))
+	if want := "case@example.com abc"; got != want {
+		t.Errorf("interpolated %q, want %q", got, want)
+	}
+}
@@ -3,6 +3,9 @@ package e2eframe
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -25,8 +28,16 @@ type ConfigKind string
 const (
 	// ConfigKindE2ETest is the version 1 of the test suite configuration.
 	ConfigKindE2ETest ConfigKind = "e2e_test:v1"
+	// ConfigKindE2ETestV2 is the version 2 of the test suite configuration. It decodes
+	// into the same suites as version 1 and rejects the fields deprecated in version 1.
+	ConfigKindE2ETestV2 ConfigKind = "e2e_test:v2"
+	// LatestConfigKind is the kind `ene migrate-config` rewrites suites to.
+	LatestConfigKind = ConfigKindE2ETestV2
 )
 
+// ConfigKinds are the supported kinds of test suite configuration, oldest first.
+var ConfigKinds = []ConfigKind{ConfigKindE2ETest, ConfigKindE2ETestV2}
+
 type Config interface {
 	// Kind returns the kind of the configuration.
 	Kind() ConfigKind
@@ -56,6 +67,45 @@ type TestSuiteConfigV1 struct {
 	TestTargetName string          `yaml:"target"`
 	Debug          bool            `yaml:"debug,omitempty"`
 	RelativePath   string
+	// UnitDependsOn maps unit names to the units listed in their `depends_on:`
+	UnitDependsOn map[string][]string
+	// TestMetas maps test names to their tags, skip/only markers and retry policy
+	TestMetas map[string]TestMeta
+	// Retry is the suite retry policy (`retries`, `retry_delay`, `retry_backoff`, `retry_on`)
+	Retry RetryPolicy
+	// Timeout is the deadline of the whole suite, from unit startup to the last test
+	Timeout time.Duration
+	// Parallel runs the tests of the suite concurrently (`parallel: true`)
+	Parallel bool
+	// SourceFile is the path of the suite file, used to resolve `include:` and `extends:`
+	SourceFile string
+	// Strict fails validation on references to unknown or empty fixtures, nil means true (`strict: false`)
+	Strict *bool
+	// Vars are the suite variables computed once every unit is ready (`vars:`)
+	Vars []SuiteVar
+	// Before are the steps run before every attempt of every test (`before:`), whose values are scoped to the test
+	Before []SuiteVar
+	// Warnings report the deprecated fields of the suite, with how to replace them
+	Warnings []string
+
+	// includeStack holds the files being included or extended, to detect cycles
+	includeStack []string
+	// definitions records where every unit, fixture and test was defined
+	definitions map[string]definition
+	// references are the `{{ }}` placeholders of the units and tests, checked by validateReferences
+	references []fixtureReference
+	// captureNames are the variables captured by the tests, which later tests may reference
+	captureNames map[string]struct{}
+	// env substitutes the `${VAR}` references of the suite and of the files it includes
+	env *suiteEnv
+	// project is the ene.yml of the suite, nil when there is none
+	project *ProjectConfig
+	// profile is the profile selected with --profile, empty for none
+	profile string
+	// profiles are the names of the profiles of the suite
+	profiles []string
+	// deprecations are the deprecated fields of the suite and of its base suite
+	deprecations []deprecation
 }
 
 func (t *TestSuiteConfigV1) Name() string {
@@ -67,16 +117,73 @@ func (t *TestSuiteConfigV1) Kind() ConfigKind {
 }
 
 func (t *TestSuiteConfigV1) UnmarshalYAML(node *yaml.Node) error {
+	// The selected profile overrides the suite, its other profiles are left out
+	node, profile, err := t.takeProfile(node)
+	if err != nil {
+		return err
+	}
+
+	t.project.overrideImages(node)
+
+	if err := t.decode(node); err != nil {
+		return err
+	}
+
+	if err := t.checkDeprecations(); err != nil {
+		return err
+	}
+
+	if err := t.applyProfile(profile); err != nil {
+		return err
+	}
+
+	// Project defaults fill in what the suite, its base and included files leave unset
+	if err := t.project.applyDefaults(t); err != nil {
+		return err
+	}
+
+	return t.validate()
+}
+
+// decode reads the fields of a suite or of a file it includes or extends, without
+// checking that the suite is complete. Definitions of an extended base suite are
+// read first and included files next, so that the suite can override the base.
+func (t *TestSuiteConfigV1) decode(node *yaml.Node) error {
 	if node.Kind != yaml.MappingNode {
 		return fmt.Errorf("expected mapping node to yaml mapping, got: %v", node.Kind)
 	}
 
+	if key, value := mappingField(node, "extends"); value != nil {
+		if err := t.extend(key, value); err != nil {
+			return err
+		}
+	}
+
+	if key, value := mappingField(node, "include"); value != nil {
+		if err := t.include(key, value); err != nil {
+			return err
+		}
+	}
+
 	// Walk through the YAML node and unmarshal each field
 	for i := 0; i < len(node.Content); i += 2 {
 		key := node.Content[i]
 		value := node.Content[i+1]
 
+		// Deprecated fields are decoded as the fields replacing them
+		if replacement, ok := deprecatedSuiteFields[key.Value]; ok {
+			if err := t.deprecate(node, key, replacement); err != nil {
+				return err
+			}
+
+			key = &yaml.Node{Kind: yaml.ScalarNode, Value: replacement, Line: key.Line, Column: key.Column}
+		}
+
 		switch key.Value {
+		case "extends", "include":
+			// Already resolved
+		case "profiles":
+			return fmt.Errorf("profiles at %s can only be defined in the suite file and in %s", t.location(key), ProjectConfigFile)
 		case "name":
 			if err := value.Decode(&t.TestName); err != nil {
 				return err
@@ -89,49 +196,35 @@ func (t *TestSuiteConfigV1) UnmarshalYAML(node *yaml.Node) error {
 			if err := value.Decode(&t.Debug); err != nil {
 				return err
 			}
-		case "fixtures":
-			// Support both array and map formats for fixtures
-			if value.Kind == yaml.SequenceNode {
-				// Array format: - key: value
-				for i := 0; i < len(value.Content); i++ {
-					fixture := &FixtureV1{RelativePath: t.RelativePath}
-					fixtureValue := value.Content[i]
-
-					if err := fixtureValue.Decode(fixture); err != nil {
-						return err
-					}
-
-					t.Fixtures = append(t.Fixtures, fixture)
-				}
-			} else if value.Kind == yaml.MappingNode {
-				// Map format: key: value (direct mapping)
-				// Convert the mapping node into individual fixtures
-				for i := 0; i < len(value.Content); i += 2 {
-					keyNode := value.Content[i]
-					valueNode := value.Content[i+1]
-
-					fixture := &FixtureV1{
-						RelativePath: t.RelativePath,
-						FixtureName:  keyNode.Value,
-					}
+		case "timeout":
+			timeout, err := decodeTimeout(key, value)
+			if err != nil {
+				return err
+			}
 
-					// Handle both scalar values and file references
-					if valueNode.Kind == yaml.ScalarNode {
-						fixture.FixtureValue = valueNode.Value
-					} else if valueNode.Kind == yaml.MappingNode {
-						// Look for "file" key
-						for j := 0; j < len(valueNode.Content); j += 2 {
-							if valueNode.Content[j].Value == "file" {
-								fixture.FixtureFile = valueNode.Content[j+1].Value
-								break
-							}
-						}
-					}
+			t.Timeout = timeout
+		case "parallel":
+			if err := value.Decode(&t.Parallel); err != nil {
+				return fmt.Errorf("could not decode parallel at line %d: %w", key.Line, err)
+			}
+		case "strict":
+			var strict bool
+			if err := value.Decode(&strict); err != nil {
+				return fmt.Errorf("could not decode strict at line %d: %w", key.Line, err)
+			}
 
-					t.Fixtures = append(t.Fixtures, fixture)
-				}
-			} else {
-				return fmt.Errorf("fixtures must be either a sequence (array) or mapping (object), got: %v", value.Kind)
+			t.Strict = &strict
+		case "fixtures":
+			if err := t.decodeFixtures(value, t.addFixture); err != nil {
+				return err
+			}
+		case "vars":
+			if err := t.decodeVars(value); err != nil {
+				return err
+			}
+		case "before":
+			if err := t.decodeBeforeSteps(value); err != nil {
+				return err
 			}
 		case "units":
 			if value.Kind != yaml.SequenceNode {
@@ -139,7 +232,9 @@ func (t *TestSuiteConfigV1) UnmarshalYAML(node *yaml.Node) error {
 			}
 
 			type unitTmp struct {
-				Kind UnitKind `yaml:"kind"`
+				Name      string   `yaml:"name"`
+				Kind      UnitKind `yaml:"kind"`
+				DependsOn []string `yaml:"depends_on"`
 			}
 
 			for i := 0; i < len(value.Content); i++ {
@@ -155,7 +250,11 @@ func (t *TestSuiteConfigV1) UnmarshalYAML(node *yaml.Node) error {
 					return err
 				}
 
-				t.Units = append(t.Units, unitImpl)
+				if err := t.addUnit(unitImpl, unit.DependsOn, t.location(unitValue)); err != nil {
+					return err
+				}
+
+				t.collectReferences(unitValue, referenceOwner{unit: unitImpl.Name()})
 			}
 		case "tests":
 			if value.Kind != yaml.SequenceNode {
@@ -167,19 +266,42 @@ func (t *TestSuiteConfigV1) UnmarshalYAML(node *yaml.Node) error {
 			}
 
 			for i := 0; i < len(value.Content); i++ {
-				test := &testTmp{}
 				testValue := value.Content[i]
 
-				if err := testValue.Decode(test); err != nil {
-					return err
-				}
-
-				testImpl, err := UnmarshallTestSuiteTest(test.Kind, testValue)
+				// A test with `cases:` or `matrix:` expands into one test per case
+				expanded, err := t.expandTestCases(testValue)
 				if err != nil {
 					return err
 				}
 
-				t.Tests = append(t.Tests, testImpl)
+				for _, expandedTest := range expanded {
+					test := &testTmp{}
+					if err := expandedTest.node.Decode(test); err != nil {
+						return err
+					}
+
+					testImpl, err := UnmarshallTestSuiteTest(test.Kind, expandedTest.node)
+					if err != nil {
+						return err
+					}
+
+					meta := TestMeta{}
+					if err := expandedTest.node.Decode(&meta); err != nil {
+						return fmt.Errorf("test %s: %w", testImpl.Name(), err)
+					}
+
+					meta.Fixtures = expandedTest.fixtures
+
+					if err := t.decodeTestScope(testImpl.Name(), expandedTest.node, &meta); err != nil {
+						return err
+					}
+
+					if err := t.addTest(testImpl, meta, t.location(testValue)); err != nil {
+						return err
+					}
+
+					t.collectReferences(expandedTest.node, referenceOwner{test: testImpl.Name()})
+				}
 			}
 		case "target":
 			if err := value.Decode(&t.TestTargetName); err != nil {
@@ -202,10 +324,22 @@ func (t *TestSuiteConfigV1) UnmarshalYAML(node *yaml.Node) error {
 				return fmt.Errorf("could not decode after_each: %w", err)
 			}
 		default:
-			return fmt.Errorf("unknown field: %s", key.Value)
+			isRetryField, err := t.Retry.decodeField(key, value)
+			if err != nil {
+				return err
+			}
+
+			if !isRetryField {
+				return fmt.Errorf("unknown field: %s", key.Value)
+			}
 		}
 	}
 
+	return nil
+}
+
+// validate checks that the suite defines everything it needs to run.
+func (t *TestSuiteConfigV1) validate() error {
 	if t.TestName == "" {
 		return errors.New("test name is required")
 	}
@@ -245,6 +379,14 @@ func (t *TestSuiteConfigV1) UnmarshalYAML(node *yaml.Node) error {
 		return err
 	}
 
+	if err := t.validateUnitDependencies(); err != nil {
+		return err
+	}
+
+	if err := t.validateReferences(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -277,40 +419,85 @@ func (t *TestSuiteConfigV1) validateDuplicateTestNames() error {
 	return nil
 }
 
+// validateUnitDependencies checks that depends_on only references defined units
+// and does not form a cycle. Dependencies through env interpolation are checked
+// when the suite runs, once the env of every unit can be resolved.
+func (t *TestSuiteConfigV1) validateUnitDependencies() error {
+	if len(t.UnitDependsOn) == 0 {
+		return nil
+	}
+
+	graph, err := newUnitGraph(t.Units, nil, t.UnitDependsOn)
+	if err != nil {
+		return err
+	}
+
+	if _, err := graph.levels(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 type CreateSuiteParams struct {
 	RelativePath string
 	WorkingDir   string
+	LogDir       string
 }
 
 func (t *TestSuiteConfigV1) CreateTestSuite(params CreateSuiteParams) (TestSuite, error) {
-	if t.TestKind != ConfigKindE2ETest {
+	if !slices.Contains(ConfigKinds, t.TestKind) {
 		return nil, fmt.Errorf("unsupported test suite kind: %s", t.TestKind)
 	}
 
 	target := t.Target()
 
-	// add relative path to fixtures
+	// add relative path to fixtures, fixtures of included files are relative to their file
 	for i, fixture := range t.Fixtures {
 		if fixture, ok := fixture.(*FixtureV1); ok {
-			fixture.RelativePath = params.RelativePath
+			if fixture.RelativePath == "" {
+				fixture.RelativePath = params.RelativePath
+			}
+
 			t.Fixtures[i] = fixture
 		} else {
 			return nil, fmt.Errorf("fixture %d is not a FixtureV1", i)
 		}
 	}
 
+	// units of included and extended files resolve their paths from their file
+	unitDirs := make(map[string]string, len(t.Units))
+	for _, unit := range t.Units {
+		if definition, ok := t.definitions["unit:"+unit.Name()]; ok && definition.location.File != "" {
+			unitDirs[unit.Name()] = filepath.Dir(definition.location.File)
+		}
+	}
+
 	testSuite := &TestSuiteV1{
 		WorkingDir:     params.WorkingDir,
 		RelativePath:   params.RelativePath,
+		UnitDirs:       unitDirs,
+		LogDir:         params.LogDir,
 		TestKind:       t.TestKind,
 		TestName:       t.TestName,
 		Fixtures:       t.Fixtures,
+		Vars:           t.Vars,
+		Before:         t.Before,
+		Profiles:       t.profileNames(),
+		SourceFile:     t.SourceFile,
 		TestBeforeAll:  t.BeforeAll,
 		TestAfterAll:   t.AfterAll,
 		TestBeforeEach: t.BeforeEach,
 		TestAfterEach:  t.AfterEach,
 		TestUnits:      t.Units,
+		UnitDependsOn:  t.referencedUnits(),
 		TestSuiteTests: t.Tests,
+		TestMetas:      t.TestMetas,
+		testReferences: t.testReferences(),
+		Warnings:       t.Warnings,
+		Retry:          t.Retry,
+		Timeout:        t.Timeout,
+		Parallel:       t.Parallel,
 		TestTarget:     target,
 		Debug:          t.Debug,
 	}
@@ -3,6 +3,7 @@ package e2eframe
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -236,3 +237,125 @@ func TestLoadTestSuites_Integration(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscoverTestSuites_Patterns(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		"ene.yml":                          "suite_files: [suite.yml, \"*.ene.yml\"]\n",
+		"tests/users/suite.yml":            "kind: e2e_test:v1\n",
+		"services/orders/orders.ene.yml":   "kind: e2e_test:v1\n",
+		"services/orders/handler.go":       "package orders\n",
+		"services/billing/billing.e2e.yml": "kind: e2e_test:v1\n",
+	})
+
+	suites, err := discoverTestSuites(dir, LoadOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Custom patterns search the whole project, not only the tests directory
+	if len(suites) != 2 || filepath.Base(suites[0]) != "orders.ene.yml" || filepath.Base(suites[1]) != "suite.yml" {
+		t.Errorf("suites = %v, want the suites of the services and tests directories", suites)
+	}
+
+	suites, err = discoverTestSuites(filepath.Join(dir, "services"), LoadOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(suites) != 1 || filepath.Base(suites[0]) != "orders.ene.yml" {
+		t.Errorf("suites = %v, want the suite_files of ene.yml", suites)
+	}
+
+	// --suite-files overrides ene.yml
+	suites, err = discoverTestSuites(filepath.Join(dir, "services"), LoadOptions{SuiteFiles: []string{"*.e2e.yml"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(suites) != 1 || filepath.Base(suites[0]) != "billing.e2e.yml" {
+		t.Errorf("suites = %v, want the files of --suite-files", suites)
+	}
+
+	// ene.yml is never a suite file
+	if _, err := DiscoverTestSuites(filepath.Join(dir, "ene.yml"), "*.yml"); err == nil || !strings.Contains(err.Error(), "is not a *.yml file") {
+		t.Errorf("expected ene.yml to be rejected, got %v", err)
+	}
+
+	if _, err := DiscoverTestSuites(dir, "[suite"); err == nil || !strings.Contains(err.Error(), `invalid suite file pattern "[suite"`) {
+		t.Errorf("expected an invalid pattern error, got %v", err)
+	}
+}
+
+func TestDiscoverTestSuites_CustomPatternsRecurse(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		"app.ene.yml":                           "kind: e2e_test:v1\n",
+		"tests/users.ene.yml":                   "kind: e2e_test:v1\n",
+		"services/orders/orders.ene.yml":        "kind: e2e_test:v1\n",
+		"vendor/example.com/lib/lib.ene.yml":    "kind: e2e_test:v1\n",
+		"node_modules/lib/lib.ene.yml":          "kind: e2e_test:v1\n",
+		".git/refs/stash.ene.yml":               "kind: e2e_test:v1\n",
+		"web/node_modules/widget/suite.ene.yml": "kind: e2e_test:v1\n",
+	})
+
+	suites, err := DiscoverTestSuites(dir, "*.ene.yml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, suite := range suites {
+		rel, err := filepath.Rel(dir, suite)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got = append(got, filepath.ToSlash(rel))
+	}
+
+	// Suites next to the code are found even when the directory has its own suite
+	// files or a tests directory, dependencies are never searched
+	want := []string{"app.ene.yml", "services/orders/orders.ene.yml", "tests/users.ene.yml"}
+	if !slices.Equal(got, want) {
+		t.Errorf("suites = %v, want %v", got, want)
+	}
+
+	// The default suite.yml keeps searching the tests directory only
+	dir = writeProjectFiles(t, map[string]string{
+		"tests/users/suite.yml":        "kind: e2e_test:v1\n",
+		"examples/demo/suite.yml":      "kind: e2e_test:v1\n",
+		"vendor/example.com/suite.yml": "kind: e2e_test:v1\n",
+	})
+
+	suites, err = DiscoverTestSuites(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(suites) != 1 || !strings.HasSuffix(filepath.ToSlash(suites[0]), "tests/users/suite.yml") {
+		t.Errorf("suites = %v, want the suite of the tests directory", suites)
+	}
+}
+
+func TestLoadTestSuiteFile_Documents(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		"empty.ene.yml": "---\n---\n",
+		"mixed.ene.yml": "kind: other\nname: a\n---\nkind: other\n",
+	})
+
+	tests := []struct {
+		file    string
+		wantErr string
+	}{
+		{file: "empty.ene.yml", wantErr: "defines no test suite"},
+		{file: "mixed.ene.yml", wantErr: "unsupported test suite kind: other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			_, err := LoadTestSuiteFile(filepath.Join(dir, tt.file), LoadOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -1,14 +1,18 @@
 package e2eframe
 
 import (
+	"bytes"
 	"context"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -36,20 +40,42 @@ const (
 	SuiteYamlFile = "suite.yml"
 )
 
+// fixtureReferencePattern matches `{{ name }}`, references to paths of structured
+// fixtures such as `{{ name.key[0].field }}` and expressions piping values into
+// template functions such as `{{ payload | hmac secret }}`.
+const fixtureReferencePattern = `{{\s*([a-zA-Z0-9_"'][^{}]*?)\s*}}`
+
 var (
-	FixtureInterpolationRegex         = regexp.MustCompile(`{{\s*([a-zA-Z0-9_]+)\s*}}`)
+	FixtureInterpolationRegex         = regexp.MustCompile(fixtureReferencePattern)
 	ServiceVariableInterpolationRegex = regexp.MustCompile(
 		`{{\s*([a-zA-Z0-9_]+)\.([a-zA-Z0-9_]+)\s*}}`,
 	)
 )
 
 // InterpolateString replaces all occurrences of fixtures in the string
-// The regex is of the form {{ (fixture_name) }}
+// The regex is of the form {{ (fixture_name) }}, the name can be followed by a path
+// into a structured fixture, e.g. {{ user.items[0].sku }}, and piped into template
+// functions, e.g. {{ payload | hmac secret }}
 //
 // Example:
 // "Hello {{ name }}!" with fixture { Name: "name", Value: "World" }
 // will return "Hello World!".
+//
+// References that cannot be resolved are left as is, see InterpolateStringErr to
+// report the expressions that fail.
 func InterpolateString(regx *regexp.Regexp, str string, fixtures []Fixture) string {
+	str, _ = InterpolateStringErr(regx, str, fixtures)
+
+	return str
+}
+
+// InterpolateStringErr is InterpolateString, also returning the errors of the
+// expressions that cannot be evaluated, such as a function failing on its input or
+// a path missing from its fixture. References to fixtures that do not exist are
+// left as is without error, they are reported when the suite loads.
+func InterpolateStringErr(regx *regexp.Regexp, str string, fixtures []Fixture) (string, error) {
+	var errs []error
+
 	// Replace all occurrences of the regex in the string with the corresponding fixture value
 	// The regex is of the form {{ (fixture_name) }}
 	// The whole string should be replaced with the fixture value
@@ -64,11 +90,30 @@ func InterpolateString(regx *regexp.Regexp, str string, fixtures []Fixture) stri
 		// Find the fixture by name
 		var fixtureValue []byte
 
-		for _, fixture := range fixtures {
-			if fixture.Name() == fixtureName {
-				fixtureValue = fixture.Value()
+		if !fixtureReferenceRegex.MatchString(fixtureName) || strings.ContainsAny(fixtureName, ".[") {
+			// Path into a structured fixture or expression, structured values are inserted as JSON
+			value, _, ok, err := evaluateReference(fixtures, fixtureName)
+			if err != nil {
+				errs = append(errs, err)
 
-				break
+				continue
+			}
+
+			if ok {
+				fixtureValue = []byte(formatFixtureValue(value))
+			}
+		} else {
+			for _, fixture := range fixtures {
+				// Units are only referenced through their variables
+				if _, isVariables := fixture.(VariableFixture); isVariables {
+					continue
+				}
+
+				if fixture.Name() == fixtureName {
+					fixtureValue = fixture.Value()
+
+					break
+				}
 			}
 		}
 
@@ -81,39 +126,149 @@ func InterpolateString(regx *regexp.Regexp, str string, fixtures []Fixture) stri
 		str = strings.ReplaceAll(str, match[0], string(fixtureValue))
 	}
 
-	return str
+	if len(errs) > 0 {
+		return str, &InterpolationError{err: errors.Join(errs...)}
+	}
+
+	return str, nil
+}
+
+// LoadTestSuite loads a suite file defining a single suite, see LoadTestSuiteFile.
+func LoadTestSuite(path string, opts LoadOptions) (TestSuite, error) {
+	testSuites, err := LoadTestSuiteFile(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(testSuites) != 1 {
+		return nil, fmt.Errorf("%s defines %d test suites, expected one", path, len(testSuites))
+	}
+
+	return testSuites[0], nil
 }
 
-func LoadTestSuite(path string) (TestSuite, error) {
-	file, err := os.Open(path)
+// LoadTestSuiteFile loads the suites of a suite file, one per YAML document
+// separated by `---`, with the overrides of the profile selected with --profile,
+// defined in the suite or in ene.yml, and the project root of --root, detected
+// from the suite file if not given.
+func LoadTestSuiteFile(path string, opts LoadOptions) ([]TestSuite, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open test suite file %s: %w", path, err)
 	}
-	defer file.Close()
 
-	type TestSuiteConfig struct {
-		Kind string `yaml:"kind"`
+	suitePath := filepath.Dir(path)
+
+	project, err := LoadProjectConfig(suitePath, opts.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := ProjectRoot(suitePath, opts.Root, project)
+	if err != nil {
+		return nil, err
 	}
 
-	var config TestSuiteConfig
-	if err := yaml.NewDecoder(file).Decode(&config); err != nil {
-		return nil, fmt.Errorf("failed to read test suite kind from %s: %w", path, err)
+	var (
+		testSuites []TestSuite
+		// lines of the suites of the file by name
+		defined = make(map[string]int)
+	)
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		var document yaml.Node
+
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, NewYAMLError(fmt.Sprintf("invalid YAML syntax: %s", err.Error()), path)
+		}
+
+		// Empty documents, e.g. after a trailing `---`, define no suite
+		if len(document.Content) == 0 || document.Content[0].Tag == "!!null" {
+			continue
+		}
+
+		testSuite, err := loadTestSuiteDocument(path, &document, project, root, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		line := document.Content[0].Line
+		if previous, ok := defined[testSuite.Name()]; ok {
+			return nil, NewValidationError(
+				fmt.Sprintf("test suite %s at line %d is already defined at line %d", testSuite.Name(), line, previous),
+				path,
+				line,
+			)
+		}
+
+		defined[testSuite.Name()] = line
+		testSuites = append(testSuites, testSuite)
+	}
+
+	if len(testSuites) == 0 {
+		return nil, fmt.Errorf("%s defines no test suite", path)
+	}
+
+	return testSuites, nil
+}
+
+// loadTestSuiteDocument loads the suite of one YAML document of a suite file.
+func loadTestSuiteDocument(
+	path string,
+	document *yaml.Node,
+	project *ProjectConfig,
+	root string,
+	opts LoadOptions,
+) (TestSuite, error) {
+	var kind string
+	if _, kindNode := mappingField(document.Content[0], "kind"); kindNode != nil {
+		kind = kindNode.Value
 	}
 
-	switch config.Kind {
-	case string(ConfigKindE2ETest):
-		file.Seek(0, 0) // Reset file pointer to the beginning
+	switch ConfigKind(kind) {
+	case ConfigKindE2ETest, ConfigKindE2ETestV2:
+		suitePath := filepath.Dir(path)
+
+		overrides, err := profileEnv(document, opts.Profile)
+		if err != nil {
+			return nil, NewValidationError(err.Error(), path, 0)
+		}
+
+		// Substitute ${VAR} references before validating the suite, the env of
+		// the profile of the suite overrides the env of the profile of ene.yml
+		env, err := newSuiteEnv(root, suitePath)
+		if err != nil {
+			return nil, err
+		}
+
+		env.override(project.Env())
+		env.override(overrides)
+		env.expand(document, filepath.Clean(path))
+
+		if err := env.missingError(); err != nil {
+			return nil, err
+		}
 
 		// Pre-validate using JSON schema
-		if err := validateTestSuiteSchema(file, path); err != nil {
+		if err := validateTestSuiteSchema(document, path); err != nil {
 			return nil, err
 		}
 
-		file.Seek(0, 0) // Reset file pointer again for YAML parsing
+		testSuiteConfig := TestSuiteConfigV1{SourceFile: filepath.Clean(path), env: env, project: project, profile: opts.Profile}
+		if err := document.Decode(&testSuiteConfig); err != nil {
+			// Errors of included files already point to the file and line at fault
+			var detailedErr *DetailedError
+			if errors.As(err, &detailedErr) {
+				return nil, detailedErr
+			}
 
-		var testSuiteConfig TestSuiteConfigV1
-		decoder := yaml.NewDecoder(file)
-		if err := decoder.Decode(&testSuiteConfig); err != nil {
 			// Try to provide more context about the YAML error
 			if yamlErr, ok := err.(*yaml.TypeError); ok {
 				return nil, NewYAMLError(yamlErr.Error(), path)
@@ -121,12 +276,15 @@ func LoadTestSuite(path string) (TestSuite, error) {
 			return nil, NewYAMLError(err.Error(), path)
 		}
 
-		suitePath := filepath.Dir(path)
-		workingDir := filepath.Join(suitePath, "../../")
+		// Included and extended files may reference missing variables too
+		if err := env.missingError(); err != nil {
+			return nil, err
+		}
 
 		params := CreateSuiteParams{
 			RelativePath: suitePath,
-			WorkingDir:   workingDir,
+			WorkingDir:   root,
+			LogDir:       project.LogPath(root),
 		}
 
 		testSuite, err := testSuiteConfig.CreateTestSuite(params)
@@ -137,20 +295,15 @@ func LoadTestSuite(path string) (TestSuite, error) {
 		return testSuite, nil
 
 	default:
-		return nil, fmt.Errorf("unsupported test suite kind: %s", config.Kind)
+		return nil, fmt.Errorf("unsupported test suite kind: %s, expected one of %s", kind, describeKinds())
 	}
 }
 
 // validateTestSuiteSchema validates the test suite YAML against the JSON schema
-func validateTestSuiteSchema(file *os.File, path string) error {
+func validateTestSuiteSchema(document *yaml.Node, path string) error {
 	// Convert YAML to JSON for schema validation
-	yamlBytes, err := io.ReadAll(file)
-	if err != nil {
-		return NewValidationError("failed to read test suite file", path, 0)
-	}
-
 	var yamlData interface{}
-	if err := yaml.Unmarshal(yamlBytes, &yamlData); err != nil {
+	if err := document.Decode(&yamlData); err != nil {
 		return NewYAMLError(fmt.Sprintf("invalid YAML syntax: %s", err.Error()), path)
 	}
 
@@ -191,13 +344,41 @@ func validateTestSuiteSchema(file *os.File, path string) error {
 	return nil
 }
 
-// DiscoverTestSuites finds all suite.yml files starting from the given path.
+// DiscoverTestSuites finds all suite files starting from the given path, the files
+// whose name matches one of patterns, e.g. `*.ene.yml`, or suite.yml if none are given.
 // It handles multiple cases:
-// 1. If path points to a suite.yml file directly -> return that file
-// 2. If path is a directory containing suite.yml -> return that file
-// 3. If path is a directory without suite.yml -> recursively search for suite.yml files
-// 4. For backwards compatibility: if path/tests/ exists, search from there instead
-func DiscoverTestSuites(path string) ([]string, error) {
+// 1. If path points to a suite file directly -> return that file
+// 2. If path is a directory containing suite.yml files -> return those files
+// 3. If path is a directory without suite.yml files -> recursively search for suite files
+// 4. For backwards compatibility: if path/tests/ exists, search suite.yml files from there instead
+// Custom patterns always search path recursively, since suites live next to the code.
+// Hidden, vendor and node_modules directories are never searched.
+func DiscoverTestSuites(path string, patterns ...string) ([]string, error) {
+	if len(patterns) == 0 {
+		patterns = []string{SuiteYamlFile}
+	}
+
+	// Cases 2 and 4 only apply to the default suite.yml files
+	isDefault := slices.Equal(patterns, []string{SuiteYamlFile})
+
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid suite file pattern %q: %w", pattern, err)
+		}
+	}
+
+	// isSuiteFile reports whether a file name matches the patterns, ene.yml never does
+	isSuiteFile := func(name string) bool {
+		if name == ProjectConfigFile {
+			return false
+		}
+
+		return slices.ContainsFunc(patterns, func(pattern string) bool {
+			matched, _ := filepath.Match(pattern, name)
+			return matched
+		})
+	}
+
 	if path == "" {
 		cwd, err := os.Getwd()
 		if err != nil {
@@ -212,47 +393,62 @@ func DiscoverTestSuites(path string) ([]string, error) {
 		return nil, fmt.Errorf("failed to resolve absolute path: %w", err)
 	}
 
-	// Case 1: Path points to a suite.yml file directly
+	// Case 1: Path points to a suite file directly
 	info, err := os.Stat(absPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to stat path %s: %w", absPath, err)
 	}
 
 	if !info.IsDir() {
-		// It's a file - check if it's a suite.yml
-		if filepath.Base(absPath) == SuiteYamlFile {
+		// It's a file - check if it's a suite file
+		if isSuiteFile(filepath.Base(absPath)) {
 			return []string{absPath}, nil
 		}
-		return nil, fmt.Errorf("path %s is not a %s file", absPath, SuiteYamlFile)
-	}
-
-	// Case 2: Directory containing suite.yml directly
-	directSuitePath := filepath.Join(absPath, SuiteYamlFile)
-	if _, err := os.Stat(directSuitePath); err == nil {
-		return []string{directSuitePath}, nil
+		return nil, fmt.Errorf("path %s is not a %s file", absPath, strings.Join(patterns, " or "))
 	}
 
-	// Case 3 & 4: Directory - search recursively
-	// For backwards compatibility, if /tests subdirectory exists, search from there
 	searchPath := absPath
-	testsSubdir := filepath.Join(absPath, "tests")
-	if info, err := os.Stat(testsSubdir); err == nil && info.IsDir() {
-		searchPath = testsSubdir
+
+	if isDefault {
+		// Case 2: Directory containing suite files directly
+		entries, err := os.ReadDir(absPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory %s: %w", absPath, err)
+		}
+
+		var directSuites []string
+		for _, entry := range entries {
+			if !entry.IsDir() && isSuiteFile(entry.Name()) {
+				directSuites = append(directSuites, filepath.Join(absPath, entry.Name()))
+			}
+		}
+
+		if len(directSuites) > 0 {
+			return directSuites, nil
+		}
+
+		// Case 4: For backwards compatibility, if /tests subdirectory exists, search from there
+		testsSubdir := filepath.Join(absPath, "tests")
+		if info, err := os.Stat(testsSubdir); err == nil && info.IsDir() {
+			searchPath = testsSubdir
+		}
 	}
 
-	// Recursively find all suite.yml files
+	// Case 3: Directory - search recursively
+
+	// Recursively find all suite files
 	var suiteFiles []string
 	err = filepath.WalkDir(searchPath, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Skip hidden directories
-		if d.IsDir() && strings.HasPrefix(d.Name(), ".") && d.Name() != "." {
+		// Skip hidden directories, e.g. .git, and dependencies
+		if d.IsDir() && path != searchPath && isSkippedDir(d.Name()) {
 			return filepath.SkipDir
 		}
 
-		if !d.IsDir() && d.Name() == SuiteYamlFile {
+		if !d.IsDir() && isSuiteFile(d.Name()) {
 			suiteFiles = append(suiteFiles, path)
 		}
 
@@ -270,31 +466,67 @@ func DiscoverTestSuites(path string) ([]string, error) {
 	return suiteFiles, nil
 }
 
-func LoadTestSuites(baseDir string) ([]TestSuite, error) {
-	suiteFiles, err := DiscoverTestSuites(baseDir)
+// isSkippedDir reports whether suites are never searched in a directory: hidden
+// directories, e.g. .git, and directories of dependencies.
+func isSkippedDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules"
+}
+
+// discoverTestSuites finds the suite files of baseDir, named after --suite-files,
+// else `suite_files` of ene.yml, else suite.yml.
+func discoverTestSuites(baseDir string, opts LoadOptions) ([]string, error) {
+	patterns := opts.SuiteFiles
+
+	if len(patterns) == 0 {
+		project, err := LoadProjectConfig(baseDir, opts.Profile)
+		if err != nil {
+			return nil, err
+		}
+
+		if project != nil {
+			patterns = project.SuiteFiles
+		}
+	}
+
+	return DiscoverTestSuites(baseDir, patterns...)
+}
+
+func LoadTestSuites(baseDir string, opts LoadOptions) ([]TestSuite, error) {
+	suiteFiles, err := discoverTestSuites(baseDir, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	var testSuites []TestSuite
 	for _, suiteFile := range suiteFiles {
-		testSuite, err := LoadTestSuite(suiteFile)
+		fileSuites, err := LoadTestSuiteFile(suiteFile, opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load test suite from %s: %w", suiteFile, err)
 		}
-		testSuites = append(testSuites, testSuite)
+		testSuites = append(testSuites, fileSuites...)
+	}
+
+	if err := CheckProfile(opts.Profile, testSuites); err != nil {
+		return nil, err
 	}
 
 	return testSuites, nil
 }
 
-// CountFilteredTestSuites returns the count of test suites that would be run with the given filter
-func CountFilteredTestSuites(baseDir string, filterFunc func(suiteName, testName string) bool) (int, error) {
-	testSuites, err := LoadTestSuites(baseDir)
+// CountFilteredTestSuites returns the count of test suites of the shard that would be run with the given filter
+func CountFilteredTestSuites(
+	baseDir string,
+	opts LoadOptions,
+	filterFunc func(suiteName, testName string) bool,
+	shard Shard,
+) (int, error) {
+	testSuites, err := LoadTestSuites(baseDir, opts)
 	if err != nil {
 		return 0, fmt.Errorf("load test suites: %w", err)
 	}
 
+	testSuites = shard.Select(testSuites)
+
 	if filterFunc == nil {
 		return len(testSuites), nil
 	}
@@ -318,22 +550,24 @@ func CountFilteredTestSuites(baseDir string, filterFunc func(suiteName, testName
 }
 
 // ListTestSuiteNames returns a list of test suite names from the test directory
-func ListTestSuiteNames(baseDir string) ([]string, error) {
-	suiteFiles, err := DiscoverTestSuites(baseDir)
+func ListTestSuiteNames(baseDir string, opts LoadOptions) ([]string, error) {
+	suiteFiles, err := discoverTestSuites(baseDir, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	var suiteNames []string
 	for _, suiteFile := range suiteFiles {
-		// Load the suite to get its name from the YAML
-		testSuite, err := LoadTestSuite(suiteFile)
+		// Load the suites to get their names from the YAML
+		testSuites, err := LoadTestSuiteFile(suiteFile, opts)
 		if err != nil {
-			// If we can't load the suite, skip it but don't fail entirely
+			// If we can't load the suites, skip them but don't fail entirely
 			continue
 		}
 
-		suiteNames = append(suiteNames, testSuite.Name())
+		for _, testSuite := range testSuites {
+			suiteNames = append(suiteNames, testSuite.Name())
+		}
 	}
 
 	return suiteNames, nil
@@ -343,13 +577,57 @@ type RunOpts struct {
 	FilterFunc      func(test, testName string) bool
 	Verbose         bool
 	Parallel        bool
+	Jobs            int // Maximum number of suites run at once in parallel, and separately of parallel tests of each suite, zero for the number of CPUs
 	Events          EventSink
 	FlushableEvents EventSinkWithFlush // Optional: if provided, will be used to ensure event ordering
 	MaxRetries      int                // Number of retries for failed tests
 	RetryDelay      string             // Delay between retries (e.g. "2s")
+	RetryBackoff    RetryBackoff       // How the delay grows between retries
+	RetryOn         []RetryCondition   // Which failures are retried, all of them if empty
 	Debug           bool               // Enable debug mode
 	BaseDir         string             // Base directory for test suites
 	CleanupCache    bool               // Cleanup old cached Docker images to prevent bloat
+	Tags            TagFilter          // Only run tests matching these tags
+	TestPattern     *regexp.Regexp     // Only run tests whose name matches this pattern
+	Timeout         time.Duration      // Deadline of the whole run, zero for none
+	Shard           Shard              // Only run the suites of this shard
+	Profile         string             // Profile of ene.yml and the suites to run with, empty for none
+	Root            string             // Project root, detected from the suites if empty
+	SuiteFiles      []string           // Name patterns of the suite files, `suite_files` of ene.yml or suite.yml if empty
+}
+
+// loadOptions returns the options suites are loaded with.
+func (o *RunOpts) loadOptions() LoadOptions {
+	return LoadOptions{Profile: o.Profile, Root: o.Root, SuiteFiles: o.SuiteFiles}
+}
+
+// runTestOptions returns the options every suite of the run is run with.
+func (o *RunOpts) runTestOptions(events EventSink) *RunTestOptions {
+	return &RunTestOptions{
+		FilterFunc:      o.FilterFunc,
+		Verbose:         o.Verbose,
+		CleanupCache:    o.CleanupCache,
+		EventSink:       events,
+		FlushableEvents: o.FlushableEvents,
+		MaxRetries:      o.MaxRetries,
+		RetryDelay:      o.RetryDelay,
+		RetryBackoff:    o.RetryBackoff,
+		RetryOn:         o.RetryOn,
+		Debug:           o.Debug,
+		BaseDir:         o.BaseDir,
+		Tags:            o.Tags,
+		TestPattern:     o.TestPattern,
+		Jobs:            o.jobs(),
+	}
+}
+
+// jobs returns the maximum number of suites or parallel tests run at once.
+func (o *RunOpts) jobs() int {
+	if o.Jobs > 0 {
+		return o.Jobs
+	}
+
+	return runtime.NumCPU()
 }
 
 type DryRunOpts struct {
@@ -357,21 +635,32 @@ type DryRunOpts struct {
 	Verbose  bool   // Enable verbose output
 	Debug    bool   // Enable debug mode
 	BaseDir  string // Base directory for test suites
+	Profile  string // Profile of ene.yml and the suites to validate, empty for none
+	Root     string // Project root, detected from the suites if empty
+	// SuiteFiles are the name patterns of the suite files, `suite_files` of ene.yml or suite.yml if empty
+	SuiteFiles []string
+}
+
+// loadOptions returns the options suites are loaded with.
+func (o *DryRunOpts) loadOptions() LoadOptions {
+	return LoadOptions{Profile: o.Profile, Root: o.Root, SuiteFiles: o.SuiteFiles}
 }
 
 func Run(ctx context.Context, opts *RunOpts) error {
 	var err error
 
-	testSuites, err := LoadTestSuites(opts.BaseDir)
+	testSuites, err := LoadTestSuites(opts.BaseDir, opts.loadOptions())
 	if err != nil {
 		return fmt.Errorf("load test suites: %w", err)
 	}
 
+	// Suites of other shards are left to other runners and not reported
+	testSuites = opts.Shard.Select(testSuites)
+
 	filteredSuites := make([]TestSuite, 0, len(testSuites))
 
 	for _, testSuite := range testSuites {
-		isFilteredIn := opts.FilterFunc(testSuite.Name(), "")
-		if opts.FilterFunc == nil || isFilteredIn {
+		if opts.FilterFunc == nil || opts.FilterFunc(testSuite.Name(), "") {
 			filteredSuites = append(filteredSuites, testSuite)
 		} else {
 			opts.Events <- &SuiteSkippedEvent{
@@ -388,39 +677,48 @@ func Run(ctx context.Context, opts *RunOpts) error {
 		}
 	}
 
-	testResults := make(chan TestResult)
-	go func() {
-		defer close(testResults)
+	// Deprecated fields of the suites are reported before they run
+	for _, testSuite := range filteredSuites {
+		suite, ok := testSuite.(*TestSuiteV1)
+		if !ok {
+			continue
+		}
 
-		// runCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
-		// defer cancel()
-		runCtx := ctx
+		for _, warning := range suite.Warnings {
+			opts.Events <- &BaseEvent{
+				EventType:    EventWarning,
+				EventTime:    time.Now(),
+				Suite:        suite.TestName,
+				EventMessage: warning,
+			}
+		}
+	}
+
+	go func() {
+		// Close the events channel when done, so that the main goroutine can exit cleanly
+		defer close(opts.Events)
 
-		done := make(chan struct{})
+		runCtx, cancel := withTimeout(ctx, TimeoutScopeRun, "", opts.Timeout)
+		defer cancel()
 
-		go func() {
-			// Wait for all tests to complete or for the context to be done
-			defer close(done)
-			// Close the events channel when done, so that the main goroutine can exit cleanly
-			defer close(opts.Events)
+		if opts.Parallel {
+			runTestsInParallel(runCtx, filteredSuites, opts, opts.Events)
+		} else {
+			runTestsSequentially(runCtx, filteredSuites, opts, opts.Events)
+		}
 
-			if opts.Parallel {
-				runTestsInParallel(runCtx, filteredSuites, opts, opts.Events)
-			} else {
-				runTestsSequentially(runCtx, filteredSuites, opts, opts.Events)
+		// Report why the run stopped early, once the suites have cleaned up
+		if cause := timeoutCause(runCtx); cause != nil {
+			opts.Events <- &BaseEvent{
+				EventType:    EventWarning,
+				EventTime:    time.Now(),
+				EventMessage: cause.Error(),
 			}
-		}()
-
-		// Wait for either completion or timeout
-		select {
-		case <-done:
-			// All tests completed normally
-		case <-runCtx.Done():
-			// Timeout or cancellation occurred
+		} else if runCtx.Err() != nil {
 			opts.Events <- &BaseEvent{
 				EventType:    EventWarning,
 				EventTime:    time.Now(),
-				EventMessage: "Test run was cancelled or timed out",
+				EventMessage: "Test run was cancelled",
 			}
 		}
 	}()
@@ -436,31 +734,64 @@ func DryRun(ctx context.Context, opts *DryRunOpts) error {
 	}
 
 	// Validate all test suites in the directory structure
-	testSuites, err := LoadTestSuites(opts.BaseDir)
+	suiteFiles, err := discoverTestSuites(opts.BaseDir, opts.loadOptions())
 	if err != nil {
 		return fmt.Errorf("load test suites: %w", err)
 	}
 
 	if opts.Verbose {
-		fmt.Printf("Found %d test suite(s) to validate\n", len(testSuites))
+		fmt.Printf("Found %d test suite file(s) to validate\n", len(suiteFiles))
 	}
 
-	for _, testSuite := range testSuites {
-		if opts.Verbose {
-			fmt.Printf("Validating test suite: %s\n", testSuite.Name())
+	// Missing environment variables of every suite are listed together
+	var (
+		missingEnv []MissingEnvVar
+		loaded     []TestSuite
+	)
+
+	for _, suiteFile := range suiteFiles {
+		testSuites, err := LoadTestSuiteFile(suiteFile, opts.loadOptions())
+
+		var missingErr *MissingEnvError
+		if errors.As(err, &missingErr) {
+			missingEnv = append(missingEnv, missingErr.Missing...)
+
+			continue
 		}
 
-		// Validate units in the test suite
-		if err := validateTestSuiteUnits(testSuite, opts); err != nil {
-			return fmt.Errorf("validation failed for suite %s: %w", testSuite.Name(), err)
+		if err != nil {
+			return fmt.Errorf("load test suites: failed to load test suite from %s: %w", suiteFile, err)
 		}
 
-		if opts.Verbose {
-			fmt.Printf("✓ Test suite %s is valid\n", testSuite.Name())
+		for _, testSuite := range testSuites {
+			if opts.Verbose {
+				fmt.Printf("Validating test suite: %s\n", testSuite.Name())
+			}
+
+			printSuiteWarnings(testSuite)
+
+			// Validate units in the test suite
+			if err := validateTestSuiteUnits(testSuite, opts); err != nil {
+				return fmt.Errorf("validation failed for suite %s: %w", testSuite.Name(), err)
+			}
+
+			if opts.Verbose {
+				fmt.Printf("✓ Test suite %s is valid\n", testSuite.Name())
+			}
 		}
+
+		if err := validateProfiles(suiteFile, testSuites, opts, &missingEnv); err != nil {
+			return fmt.Errorf("validation failed for %s: %w", suiteFile, err)
+		}
+
+		loaded = append(loaded, testSuites...)
 	}
 
-	return nil
+	if len(missingEnv) > 0 {
+		return &MissingEnvError{Missing: missingEnv}
+	}
+
+	return CheckProfile(opts.Profile, loaded)
 }
 
 // validateSingleTestFile validates a single test file
@@ -474,17 +805,34 @@ func validateSingleTestFile(testFile string, opts *DryRunOpts) error {
 		return fmt.Errorf("test file not found: %s", testFile)
 	}
 
-	// Try to load the test suite
-	testSuite, err := LoadTestSuite(testFile)
+	// Try to load the test suites of the file
+	testSuites, err := LoadTestSuiteFile(testFile, opts.loadOptions())
 	if err != nil {
 		return fmt.Errorf("failed to load test file %s: %w", testFile, err)
 	}
 
-	// Validate units in the test suite
-	if err := validateTestSuiteUnits(testSuite, opts); err != nil {
+	// Validate units in the test suites
+	for _, testSuite := range testSuites {
+		printSuiteWarnings(testSuite)
+
+		if err := validateTestSuiteUnits(testSuite, opts); err != nil {
+			return fmt.Errorf("validation failed for %s: %w", testFile, err)
+		}
+	}
+
+	var missingEnv []MissingEnvVar
+	if err := validateProfiles(testFile, testSuites, opts, &missingEnv); err != nil {
 		return fmt.Errorf("validation failed for %s: %w", testFile, err)
 	}
 
+	if len(missingEnv) > 0 {
+		return &MissingEnvError{Missing: missingEnv}
+	}
+
+	if err := CheckProfile(opts.Profile, testSuites); err != nil {
+		return err
+	}
+
 	if opts.Verbose {
 		fmt.Printf("✓ Test file %s is valid\n", testFile)
 	}
@@ -492,10 +840,82 @@ func validateSingleTestFile(testFile string, opts *DryRunOpts) error {
 	return nil
 }
 
+// validateProfiles validates the suites of a suite file, already validated with
+// the profile selected with --profile, with every other profile of the suites and
+// of their ene.yml, so that a broken profile is reported before it is selected.
+// Environment variables missing with a profile are added to missingEnv.
+func validateProfiles(path string, testSuites []TestSuite, opts *DryRunOpts, missingEnv *[]MissingEnvVar) error {
+	var profiles []string
+
+	for _, testSuite := range testSuites {
+		if suite, ok := testSuite.(*TestSuiteV1); ok {
+			profiles = append(profiles, suite.Profiles...)
+		}
+	}
+
+	slices.Sort(profiles)
+
+	for _, profile := range slices.Compact(profiles) {
+		if profile == opts.Profile {
+			continue
+		}
+
+		if opts.Verbose {
+			fmt.Printf("  Validating profile %s\n", profile)
+		}
+
+		loadOpts := opts.loadOptions()
+		loadOpts.Profile = profile
+
+		profileSuites, err := LoadTestSuiteFile(path, loadOpts)
+
+		var missingErr *MissingEnvError
+		if errors.As(err, &missingErr) {
+			for _, missing := range missingErr.Missing {
+				if !slices.Contains(*missingEnv, missing) {
+					*missingEnv = append(*missingEnv, missing)
+				}
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("profile %s: %w", profile, err)
+		}
+
+		for _, testSuite := range profileSuites {
+			if err := validateTestSuiteUnits(testSuite, &DryRunOpts{BaseDir: opts.BaseDir}); err != nil {
+				return fmt.Errorf("profile %s: suite %s: %w", profile, testSuite.Name(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// printSuiteWarnings prints the deprecated fields of a suite validated by dry-run.
+func printSuiteWarnings(testSuite TestSuite) {
+	suite, ok := testSuite.(*TestSuiteV1)
+	if !ok {
+		return
+	}
+
+	for _, warning := range suite.Warnings {
+		fmt.Printf("⚠ %s\n", warning)
+	}
+}
+
 // validateTestSuiteUnits validates all units in a test suite
 func validateTestSuiteUnits(testSuite TestSuite, opts *DryRunOpts) error {
 	units := testSuite.Units()
 
+	// Paths of units are relative to the file defining them
+	unitDir := func(Unit) string { return opts.BaseDir }
+	if suite, ok := testSuite.(*TestSuiteV1); ok {
+		unitDir = suite.UnitDir
+	}
+
 	if opts.Verbose {
 		fmt.Printf("  Validating %d unit(s)\n", len(units))
 	}
@@ -512,7 +932,7 @@ func validateTestSuiteUnits(testSuite TestSuite, opts *DryRunOpts) error {
 
 		// Test that environment variables can be retrieved
 		envVars := unit.GetEnvRaw(&GetEnvRawOptions{
-			WorkingDir: opts.BaseDir,
+			WorkingDir: unitDir(unit),
 		})
 
 		if opts.Debug {
@@ -555,27 +975,39 @@ func runTestsInParallel(
 ) {
 	wg := sync.WaitGroup{}
 
+	// Every suite starts its own network and containers,
+	// so only a limited number of suites run at once
+	semaphore := make(chan struct{}, opts.jobs())
+
 	for _, testSuite := range testSuites {
-		wg.Add(1)
+		// Suites that cannot start before the run deadline time out instead of
+		// failing to create their network
+		if cause := timeoutCause(ctx); cause != nil {
+			reportSuiteTimedOut(testSuite, cause, opts.runTestOptions(events))
+
+			continue
+		}
 
 		if ctx.Err() != nil {
 			break
 		}
 
+		wg.Add(1)
+
 		go func(testSuite TestSuite) {
 			defer wg.Done()
 
-			err := testSuite.Run(ctx, &RunTestOptions{
-				FilterFunc:      opts.FilterFunc,
-				Verbose:         opts.Verbose,
-				CleanupCache:    opts.CleanupCache,
-				EventSink:       events,
-				FlushableEvents: opts.FlushableEvents,
-				MaxRetries:      opts.MaxRetries,
-				RetryDelay:      opts.RetryDelay,
-				Debug:           opts.Debug,
-				BaseDir:         opts.BaseDir,
-			})
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			// The deadline may pass while the suite waits for its turn
+			if cause := timeoutCause(ctx); cause != nil {
+				reportSuiteTimedOut(testSuite, cause, opts.runTestOptions(events))
+
+				return
+			}
+
+			err := testSuite.Run(ctx, opts.runTestOptions(events))
 			if err != nil {
 				events <- &SuiteErrorEvent{
 					BaseEvent: BaseEvent{
@@ -607,6 +1039,10 @@ func runTestsSequentially(
 	events EventSink,
 ) {
 	for _, testSuite := range testSuites {
+		if ctx.Err() != nil && timeoutCause(ctx) == nil {
+			break // Run cancelled
+		}
+
 		events <- &BaseEvent{
 			EventType:    EventSuiteStarted,
 			EventTime:    time.Now(),
@@ -614,18 +1050,15 @@ func runTestsSequentially(
 			EventMessage: fmt.Sprintf("Starting test suite: %s", testSuite.Name()),
 		}
 
-		// Create test options with filter
-		err := testSuite.Run(ctx, &RunTestOptions{
-			FilterFunc:      opts.FilterFunc,
-			Verbose:         opts.Verbose,
-			CleanupCache:    opts.CleanupCache,
-			EventSink:       events,
-			FlushableEvents: opts.FlushableEvents,
-			MaxRetries:      opts.MaxRetries,
-			RetryDelay:      opts.RetryDelay,
-			Debug:           opts.Debug,
-			BaseDir:         opts.BaseDir,
-		})
+		// Suites that cannot start before the run deadline time out instead of
+		// failing to create their network
+		if cause := timeoutCause(ctx); cause != nil {
+			reportSuiteTimedOut(testSuite, cause, opts.runTestOptions(events))
+
+			continue
+		}
+
+		err := testSuite.Run(ctx, opts.runTestOptions(events))
 		if err != nil {
 			events <- &SuiteErrorEvent{
 				BaseEvent: BaseEvent{
@@ -646,3 +1079,59 @@ func runTestsSequentially(
 		}
 	}
 }
+
+// reportSuiteTimedOut reports the tests of a suite that did not start before the
+// run deadline as timed out, and the tests it would skip anyway as skipped.
+func reportSuiteTimedOut(testSuite TestSuite, cause *TimeoutError, opts *RunTestOptions) {
+	var plan []plannedTest
+	if suite, ok := testSuite.(*TestSuiteV1); ok {
+		plan = suite.planTests(opts)
+	} else {
+		for _, test := range testSuite.Tests() {
+			plan = append(plan, plannedTest{test: test})
+		}
+	}
+
+	var timedOut, skipped int
+
+	for _, planned := range plan {
+		if planned.skipReason != "" {
+			skipped++
+			opts.EventSink <- &TestEvent{
+				BaseEvent: BaseEvent{
+					EventType:    EventTestSkipped,
+					EventTime:    time.Now(),
+					Suite:        testSuite.Name(),
+					EventMessage: planned.skipReason,
+				},
+				TestName: planned.test.Name(),
+			}
+
+			continue
+		}
+
+		timedOut++
+		opts.EventSink <- &TestEvent{
+			BaseEvent: BaseEvent{
+				EventType:    EventTestCompleted,
+				EventTime:    time.Now(),
+				Suite:        testSuite.Name(),
+				EventMessage: cause.Error(),
+			},
+			TestName: planned.test.Name(),
+			Error:    cause,
+			TimedOut: true,
+		}
+	}
+
+	opts.EventSink <- &SuiteFinishedEvent{
+		BaseEvent: BaseEvent{
+			EventType:    EventSuiteFinished,
+			EventTime:    time.Now(),
+			Suite:        testSuite.Name(),
+			EventMessage: cause.Error(),
+		},
+		FailedCount:  timedOut,
+		SkippedCount: skipped,
+	}
+}
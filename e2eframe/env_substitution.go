@@ -0,0 +1,162 @@
+package e2eframe
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvFileName is the name of the .env files read at the project and suite level.
+const EnvFileName = ".env"
+
+// envReferenceRegex matches `${VAR}` and `${VAR:-default}` in suite files. Only
+// upper-case names are matched, so that the template literals of scripts embedded
+// in suites, such as `${filename}`, are left as is. `$${VAR}` escapes the
+// reference and is replaced by the literal `${VAR}`.
+var envReferenceRegex = regexp.MustCompile(`\$(\$?)\{([A-Z_][A-Z0-9_]*)(:-([^}]*))?\}`)
+
+// MissingEnvVar is a `${VAR}` reference without a value nor a default.
+type MissingEnvVar struct {
+	Name string
+	File string
+	Line int
+}
+
+func (v MissingEnvVar) String() string {
+	return fmt.Sprintf("%s (%s)", v.Name, sourceLocation{File: v.File, Line: v.Line})
+}
+
+// MissingEnvError is returned when suite files reference environment variables that are not set.
+type MissingEnvError struct {
+	Missing []MissingEnvVar
+}
+
+func (e *MissingEnvError) Error() string {
+	var b strings.Builder
+
+	b.WriteString("environment variables not set:\n")
+
+	for _, missing := range e.Missing {
+		fmt.Fprintf(&b, "  • %s\n", missing)
+	}
+
+	fmt.Fprintf(&b, "Set them, add them to a %s file or --env-file, or give them a default: ${VAR:-default}", EnvFileName)
+
+	return b.String()
+}
+
+// LoadEnvFile adds the variables of an env file, such as the one given with
+// --env-file, to the process environment. Variables already set are kept, so
+// that they take precedence over the file.
+func LoadEnvFile(path string) error {
+	if err := godotenv.Load(path); err != nil {
+		return fmt.Errorf("load env file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// suiteEnv resolves the `${VAR}` references of a suite and of the files it includes.
+// Variables of the process environment, including those of --env-file, take
+// precedence over the .env file of the suite directory, which takes precedence
+// over the .env file of the project.
+type suiteEnv struct {
+	dotenv  map[string]string
+	missing []MissingEnvVar
+}
+
+// newSuiteEnv reads the .env files of the project and suite directories, if any.
+func newSuiteEnv(projectDir, suiteDir string) (*suiteEnv, error) {
+	env := &suiteEnv{dotenv: make(map[string]string)}
+
+	for _, dir := range []string{projectDir, suiteDir} {
+		path := filepath.Join(dir, EnvFileName)
+
+		values, err := godotenv.Read(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+
+		maps.Copy(env.dotenv, values)
+	}
+
+	return env, nil
+}
+
+// override sets variables taking precedence over the .env files, but not over
+// the process environment, such as the `env:` of a profile.
+func (e *suiteEnv) override(values map[string]string) {
+	maps.Copy(e.dotenv, values)
+}
+
+func (e *suiteEnv) lookup(name string) (string, bool) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true
+	}
+
+	value, ok := e.dotenv[name]
+
+	return value, ok
+}
+
+// expand substitutes the `${VAR}` references of the scalars of a parsed YAML
+// document, recording the variables that are missing. Comments are left as is.
+// Plain scalars are resolved again, so that `port: ${PORT}` decodes as a number.
+func (e *suiteEnv) expand(node *yaml.Node, file string) {
+	if e == nil {
+		return
+	}
+
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "${") {
+		node.Value = envReferenceRegex.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			match := envReferenceRegex.FindStringSubmatch(ref)
+			if match[1] != "" {
+				return ref[1:]
+			}
+
+			name, hasDefault, defaultValue := match[2], match[3] != "", match[4]
+
+			// Like in shells, `${VAR:-default}` also uses the default when VAR is empty
+			if value, ok := e.lookup(name); ok && (value != "" || !hasDefault) {
+				return value
+			}
+
+			if hasDefault {
+				return defaultValue
+			}
+
+			e.missing = append(e.missing, MissingEnvVar{Name: name, File: file, Line: node.Line})
+
+			return ""
+		})
+
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	}
+
+	for _, child := range node.Content {
+		e.expand(child, file)
+	}
+}
+
+// missingError returns a MissingEnvError listing the missing variables, nil if there are none.
+func (e *suiteEnv) missingError() error {
+	if e == nil || len(e.missing) == 0 {
+		return nil
+	}
+
+	return &MissingEnvError{Missing: e.missing}
+}
@@ -0,0 +1,162 @@
+package e2eframe
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSuiteEnvExpandsReferences(t *testing.T) {
+	project := t.TempDir()
+	suiteDir := filepath.Join(project, "tests", "users")
+
+	if err := os.MkdirAll(suiteDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		filepath.Join(project, EnvFileName):  "IMAGE_TAG=from-project\nREGISTRY=registry.local\nFEATURE=on\n",
+		filepath.Join(suiteDir, EnvFileName): "IMAGE_TAG=from-suite\n",
+	}
+
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("FEATURE", "from-process")
+	t.Setenv("EMPTY", "")
+
+	env, err := newSuiteEnv(project, suiteDir)
+	if err != nil {
+		t.Fatalf("new suite env: %v", err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(`
+image: ${REGISTRY}/app:${IMAGE_TAG}
+feature: ${FEATURE}
+port: ${PORT:-8080}
+level: ${EMPTY:-info}
+empty: "${EMPTY}"
+literal: $${IMAGE_TAG}
+script: console.log(${filename})
+# ${COMMENTED}
+`), &document); err != nil {
+		t.Fatal(err)
+	}
+
+	env.expand(&document, "suite.yml")
+
+	if err := env.missingError(); err != nil {
+		t.Fatalf("unexpected missing variables: %v", err)
+	}
+
+	var got struct {
+		Image   string `yaml:"image"`
+		Feature string `yaml:"feature"`
+		Port    int    `yaml:"port"`
+		Level   string `yaml:"level"`
+		Empty   string `yaml:"empty"`
+		Literal string `yaml:"literal"`
+		Script  string `yaml:"script"`
+	}
+
+	if err := document.Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	want := map[string]string{
+		"image":   "registry.local/app:from-suite",
+		"feature": "from-process",
+		"level":   "info",
+		"empty":   "",
+		"literal": "${IMAGE_TAG}",
+		"script":  "console.log(${filename})",
+	}
+
+	for field, value := range map[string]string{
+		"image":   got.Image,
+		"feature": got.Feature,
+		"level":   got.Level,
+		"empty":   got.Empty,
+		"literal": got.Literal,
+		"script":  got.Script,
+	} {
+		if value != want[field] {
+			t.Errorf("%s = %q, want %q", field, value, want[field])
+		}
+	}
+
+	if got.Port != 8080 {
+		t.Errorf("port = %d, want the default decoded as a number", got.Port)
+	}
+}
+
+func TestSuiteEnvReportsMissingVariables(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "units.yml"), []byte(`
+units:
+  - name: app
+    kind: stub
+    image: app:${APP_TAG}
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := newSuiteEnv(dir, dir)
+	if err != nil {
+		t.Fatalf("new suite env: %v", err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(`kind: e2e_test:v1
+name: users
+include: units.yml
+target: app
+tests:
+  - name: login
+    kind: stub
+    path: /login?token=${API_TOKEN}
+`), &document); err != nil {
+		t.Fatal(err)
+	}
+
+	suitePath := filepath.Join(dir, "suite.yml")
+	env.expand(&document, suitePath)
+
+	cfg := &TestSuiteConfigV1{SourceFile: suitePath, env: env}
+	if err := document.Decode(cfg); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	var missingErr *MissingEnvError
+	if err := env.missingError(); !errors.As(err, &missingErr) {
+		t.Fatalf("expected a missing env error, got %v", err)
+	}
+
+	want := []string{
+		"API_TOKEN (" + suitePath + ":8)",
+		"APP_TAG (" + filepath.Join(dir, "units.yml") + ":5)",
+	}
+
+	if len(missingErr.Missing) != len(want) {
+		t.Fatalf("missing = %v, want %v", missingErr.Missing, want)
+	}
+
+	for i, missing := range missingErr.Missing {
+		if missing.String() != want[i] {
+			t.Errorf("missing[%d] = %s, want %s", i, missing, want[i])
+		}
+
+		if !strings.Contains(missingErr.Error(), want[i]) {
+			t.Errorf("error does not list %s:\n%s", want[i], missingErr.Error())
+		}
+	}
+}
@@ -13,6 +13,9 @@ const (
 	EventSuiteError     EventType = "test_suite_error"
 	EventSuiteSkipped   EventType = "test_suite_skipped"
 
+	// Fixture events.
+	EventFixturesGenerated EventType = "fixtures_generated"
+
 	// Test lifecycle events.
 	EventTestStarted   EventType = "test_started"
 	EventTestCompleted EventType = "test_completed"
@@ -80,6 +83,7 @@ type TestEvent struct {
 	Error    error
 	Duration time.Duration
 	LogPaths []string // Paths to saved log files (for failed tests)
+	TimedOut bool     // Whether the test failed because a timeout fired
 }
 
 func (te *TestEvent) Unwrap() error {
@@ -125,6 +129,12 @@ type SuiteFinishedEvent struct {
 	SkippedCount int           // Number of skipped tests
 }
 
+// FixturesGeneratedEvent reports the fixture values generated for a suite run.
+type FixturesGeneratedEvent struct {
+	BaseEvent
+	Fixtures []GeneratedFixture
+}
+
 // SuiteErrorEvent represents suite error events with preserved original error
 type SuiteErrorEvent struct {
 	BaseEvent
@@ -0,0 +1,652 @@
+package e2eframe
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fixtureReferenceRegex matches a fixture name optionally followed by a path, e.g. `user.items[0].sku`.
+var fixtureReferenceRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+(?:\.[a-zA-Z0-9_]+|\[[0-9]+\])*$`)
+
+// numberLiteralRegex matches the number arguments of template functions.
+var numberLiteralRegex = regexp.MustCompile(`^-?[0-9]+(?:\.[0-9]+)?$`)
+
+// templateFunc transforms the value piped into it. in is nil when the function
+// starts the expression (e.g. `{{ now }}`) or the referenced fixture does not exist.
+type templateFunc func(in *exprValue, args []exprValue) (exprValue, error)
+
+// exprValue is the value of an expression or of one of its arguments.
+type exprValue struct {
+	value any
+	// structured values come from a JSON or YAML document and keep their type
+	structured bool
+}
+
+// text returns the value as inserted in a string, structured values are encoded as JSON.
+func (v exprValue) text() string {
+	return formatFixtureValue(v.value)
+}
+
+// templateFuncs are the functions available in interpolation expressions,
+// e.g. `{{ payload | hmac secret }}` or `{{ page | add 1 }}`.
+var templateFuncs = map[string]templateFunc{
+	"base64": textFunc(func(s string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(s)), nil
+	}),
+	"base64decode": textFunc(func(s string) (string, error) {
+		data, err := base64.StdEncoding.DecodeString(s)
+
+		return string(data), err
+	}),
+	"urlencode": textFunc(func(s string) (string, error) { return url.QueryEscape(s), nil }),
+	"upper":     textFunc(func(s string) (string, error) { return strings.ToUpper(s), nil }),
+	"lower":     textFunc(func(s string) (string, error) { return strings.ToLower(s), nil }),
+	"trim":      textFunc(func(s string) (string, error) { return strings.TrimSpace(s), nil }),
+	"sha256": textFunc(func(s string) (string, error) {
+		sum := sha256.Sum256([]byte(s))
+
+		return hex.EncodeToString(sum[:]), nil
+	}),
+	"hmac":    hmacFunc,
+	"json":    jsonFunc,
+	"default": defaultFunc,
+	"now":     nowFunc,
+	"date":    dateFunc,
+	"add": arithmeticFunc(
+		func(a, b int64) (int64, bool, error) {
+			sum := a + b
+			if (sum > a) != (b > 0) {
+				return 0, false, errIntegerOverflow
+			}
+
+			return sum, true, nil
+		},
+		func(a, b float64) (float64, error) { return a + b, nil },
+	),
+	"sub": arithmeticFunc(
+		func(a, b int64) (int64, bool, error) {
+			diff := a - b
+			if (diff < a) != (b > 0) {
+				return 0, false, errIntegerOverflow
+			}
+
+			return diff, true, nil
+		},
+		func(a, b float64) (float64, error) { return a - b, nil },
+	),
+	"mul": arithmeticFunc(
+		func(a, b int64) (int64, bool, error) {
+			product := a * b
+			if a != 0 && (product/a != b || (a == -1 && b == math.MinInt64)) {
+				return 0, false, errIntegerOverflow
+			}
+
+			return product, true, nil
+		},
+		func(a, b float64) (float64, error) { return a * b, nil },
+	),
+	"div": arithmeticFunc(
+		func(a, b int64) (int64, bool, error) {
+			switch {
+			case b == 0:
+				return 0, false, errDivisionByZero
+			case a == math.MinInt64 && b == -1:
+				return 0, false, errIntegerOverflow
+			case a%b != 0:
+				return 0, false, nil // Not a whole number, divide as decimals
+			}
+
+			return a / b, true, nil
+		},
+		func(a, b float64) (float64, error) {
+			if b == 0 {
+				return 0, errDivisionByZero
+			}
+
+			return a / b, nil
+		},
+	),
+	"mod": arithmeticFunc(
+		func(a, b int64) (int64, bool, error) {
+			if b == 0 {
+				return 0, false, errDivisionByZero
+			}
+
+			return a % b, true, nil
+		},
+		nil, // The remainder is only defined for integers
+	),
+}
+
+var (
+	errDivisionByZero  = errors.New("division by zero")
+	errIntegerOverflow = errors.New("integer overflow")
+)
+
+// fixtureExpression is a parsed interpolation expression: an operand followed by
+// the functions its value is piped into, e.g. `user.name | default "anonymous" | upper`.
+type fixtureExpression struct {
+	operand exprOperand
+	pipes   []exprCall
+}
+
+// exprOperand is a fixture reference, a literal or a function call starting an expression.
+type exprOperand struct {
+	ref     string
+	literal *exprValue
+	call    *exprCall
+}
+
+type exprCall struct {
+	name string
+	args []exprOperand
+}
+
+// parseFixtureExpression parses the content of a `{{ }}` placeholder.
+func parseFixtureExpression(src string) (*fixtureExpression, error) {
+	var stages [][]string
+
+	tokens, err := tokenizeExpression(src)
+	if err != nil {
+		return nil, err
+	}
+
+	stage := []string{}
+
+	for _, token := range tokens {
+		if token == "|" {
+			stages = append(stages, stage)
+			stage = []string{}
+
+			continue
+		}
+
+		stage = append(stage, token)
+	}
+
+	stages = append(stages, stage)
+
+	expr := &fixtureExpression{}
+
+	for i, stage := range stages {
+		if len(stage) == 0 {
+			return nil, fmt.Errorf("empty expression in %q", src)
+		}
+
+		if i == 0 && len(stage) == 1 {
+			operand, err := parseOperand(stage[0], true)
+			if err != nil {
+				return nil, err
+			}
+
+			expr.operand = operand
+
+			continue
+		}
+
+		call, err := parseCall(stage)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			expr.operand = exprOperand{call: call}
+		} else {
+			expr.pipes = append(expr.pipes, *call)
+		}
+	}
+
+	return expr, nil
+}
+
+// tokenizeExpression splits an expression into names, quoted strings and pipes.
+func tokenizeExpression(src string) ([]string, error) {
+	var tokens []string
+
+	for i := 0; i < len(src); {
+		switch c := src[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '|':
+			tokens = append(tokens, "|")
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(src) && src[end] != c {
+				if src[end] == '\\' && c == '"' {
+					end++
+				}
+
+				end++
+			}
+
+			if end >= len(src) {
+				return nil, fmt.Errorf("unterminated string in %q", src)
+			}
+
+			tokens = append(tokens, src[i:end+1])
+			i = end + 1
+		default:
+			end := i
+			for end < len(src) && !strings.ContainsRune(" \t|\"'", rune(src[end])) {
+				end++
+			}
+
+			tokens = append(tokens, src[i:end])
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+// parseOperand parses a fixture reference or a literal. Numbers are literals
+// only as function arguments, so that fixtures can keep numeric names.
+func parseOperand(token string, first bool) (exprOperand, error) {
+	switch {
+	case token[0] == '"':
+		s, err := strconv.Unquote(token)
+		if err != nil {
+			return exprOperand{}, fmt.Errorf("invalid string %s", token)
+		}
+
+		return exprOperand{literal: &exprValue{value: s}}, nil
+	case token[0] == '\'':
+		return exprOperand{literal: &exprValue{value: token[1 : len(token)-1]}}, nil
+	case !first && numberLiteralRegex.MatchString(token):
+		return exprOperand{literal: &exprValue{value: token}}, nil
+	case fixtureReferenceRegex.MatchString(token):
+		if _, isFunc := templateFuncs[token]; isFunc && first {
+			// A function starting the expression, unless a fixture has the same name
+			return exprOperand{ref: token, call: &exprCall{name: token}}, nil
+		}
+
+		return exprOperand{ref: token}, nil
+	default:
+		return exprOperand{}, fmt.Errorf("invalid fixture reference %q", token)
+	}
+}
+
+func parseCall(stage []string) (*exprCall, error) {
+	if _, ok := templateFuncs[stage[0]]; !ok {
+		return nil, fmt.Errorf("unknown function %q", stage[0])
+	}
+
+	call := &exprCall{name: stage[0]}
+
+	for _, token := range stage[1:] {
+		arg, err := parseOperand(token, false)
+		if err != nil {
+			return nil, err
+		}
+
+		call.args = append(call.args, arg)
+	}
+
+	return call, nil
+}
+
+// root returns the name of the fixture the expression starts from, empty for literals and functions.
+func (e *fixtureExpression) root() string {
+	if e.operand.ref == "" || e.operand.call != nil {
+		return ""
+	}
+
+	return refRoot(e.operand.ref)
+}
+
+// argumentRoots returns the names of the fixtures passed as function arguments, e.g. `secret` in `hmac secret`.
+func (e *fixtureExpression) argumentRoots() []string {
+	refs := e.argumentRefs()
+	for i, ref := range refs {
+		refs[i] = refRoot(ref)
+	}
+
+	return refs
+}
+
+// argumentRefs returns the fixture references passed as function arguments, with their paths.
+func (e *fixtureExpression) argumentRefs() []string {
+	var refs []string
+
+	calls := e.pipes
+	if e.operand.call != nil {
+		calls = append([]exprCall{*e.operand.call}, calls...)
+	}
+
+	for _, call := range calls {
+		for _, arg := range call.args {
+			if arg.ref != "" {
+				refs = append(refs, arg.ref)
+			}
+		}
+	}
+
+	return refs
+}
+
+// refRoot returns the fixture name of a reference, without its path.
+func refRoot(ref string) string {
+	return ref[:strings.IndexAny(ref+".", ".[")]
+}
+
+// hasDefault reports whether the expression provides a value for a missing fixture.
+func (e *fixtureExpression) hasDefault() bool {
+	for _, call := range e.pipes {
+		if call.name == "default" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// eval evaluates the expression. ok is false when it references a fixture that
+// does not exist and no `default` provides a value.
+func (e *fixtureExpression) eval(fixtures []Fixture) (exprValue, bool, error) {
+	in, err := e.operand.eval(fixtures)
+	if err != nil {
+		return exprValue{}, false, err
+	}
+
+	for _, call := range e.pipes {
+		if in == nil && call.name != "default" {
+			return exprValue{}, false, nil
+		}
+
+		out, err := call.eval(in, fixtures)
+		if err != nil {
+			return exprValue{}, false, err
+		}
+
+		in = &out
+	}
+
+	if in == nil {
+		return exprValue{}, false, nil
+	}
+
+	return *in, true, nil
+}
+
+// eval returns the value of an operand, nil if it references a missing fixture.
+func (o exprOperand) eval(fixtures []Fixture) (*exprValue, error) {
+	if o.literal != nil {
+		return o.literal, nil
+	}
+
+	if o.ref != "" {
+		if value, structured, ok := LookupFixture(fixtures, o.ref); ok {
+			return &exprValue{value: value, structured: structured}, nil
+		}
+	}
+
+	if o.call == nil {
+		return nil, nil
+	}
+
+	value, err := o.call.eval(nil, fixtures)
+	if err != nil {
+		return nil, err
+	}
+
+	return &value, nil
+}
+
+func (c exprCall) eval(in *exprValue, fixtures []Fixture) (exprValue, error) {
+	args := make([]exprValue, 0, len(c.args))
+
+	for _, arg := range c.args {
+		value, err := arg.eval(fixtures)
+		if err != nil {
+			return exprValue{}, err
+		}
+
+		if value == nil {
+			return exprValue{}, fmt.Errorf("%s: fixture %s not found", c.name, arg.ref)
+		}
+
+		args = append(args, *value)
+	}
+
+	out, err := templateFuncs[c.name](in, args)
+	if err != nil {
+		return exprValue{}, fmt.Errorf("%s: %w", c.name, err)
+	}
+
+	return out, nil
+}
+
+// EvaluateFixtureExpression evaluates the content of a `{{ }}` placeholder: a fixture
+// reference or a literal, optionally piped into functions, e.g. `payload | hmac secret`.
+// ok is false when the expression references a missing fixture without a default.
+func EvaluateFixtureExpression(fixtures []Fixture, src string) (value any, structured bool, ok bool, err error) {
+	expr, err := parseFixtureExpression(src)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	result, ok, err := expr.eval(fixtures)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("{{ %s }}: %w", src, err)
+	}
+
+	return result.value, result.structured, ok, nil
+}
+
+// evaluateReference evaluates the content of a `{{ }}` placeholder like
+// EvaluateFixtureExpression, and also fails on a path missing from the fixture it
+// starts from, e.g. a typo in `{{ user.adress.city }}`. A reference to a fixture
+// that does not exist is not an error, it is reported when the suite loads.
+func evaluateReference(fixtures []Fixture, src string) (value any, structured bool, ok bool, err error) {
+	value, structured, ok, err = EvaluateFixtureExpression(fixtures, src)
+	if err != nil || ok {
+		return value, structured, ok, err
+	}
+
+	expr, err := parseFixtureExpression(src)
+	if err != nil || expr.hasDefault() || expr.root() == expr.operand.ref {
+		return nil, false, false, nil
+	}
+
+	root := expr.root()
+	if root != "" && slices.ContainsFunc(fixtures, func(f Fixture) bool { return f.Name() == root }) {
+		return nil, false, false, fmt.Errorf("{{ %s }}: %s not found in %s", src, expr.operand.ref, root)
+	}
+
+	return nil, false, false, nil
+}
+
+// textFunc adapts a string transformation to a template function without arguments.
+func textFunc(fn func(string) (string, error)) templateFunc {
+	return func(in *exprValue, args []exprValue) (exprValue, error) {
+		if in == nil || len(args) != 0 {
+			return exprValue{}, fmt.Errorf("expects a piped value and no arguments")
+		}
+
+		out, err := fn(in.text())
+
+		return exprValue{value: out}, err
+	}
+}
+
+// hmacFunc signs the piped value: `hmac KEY [sha1|sha256|sha512] [hex|base64]`.
+func hmacFunc(in *exprValue, args []exprValue) (exprValue, error) {
+	if in == nil || len(args) == 0 || len(args) > 3 {
+		return exprValue{}, fmt.Errorf("expects a piped value and a key, e.g. {{ body | hmac secret }}")
+	}
+
+	algorithm, encoding := "sha256", "hex"
+	if len(args) > 1 {
+		algorithm = args[1].text()
+	}
+
+	if len(args) > 2 {
+		encoding = args[2].text()
+	}
+
+	var newHash func() hash.Hash
+
+	switch algorithm {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return exprValue{}, fmt.Errorf("unknown algorithm %q, expected sha1, sha256 or sha512", algorithm)
+	}
+
+	mac := hmac.New(newHash, []byte(args[0].text()))
+	mac.Write([]byte(in.text()))
+	sum := mac.Sum(nil)
+
+	switch encoding {
+	case "hex":
+		return exprValue{value: hex.EncodeToString(sum)}, nil
+	case "base64":
+		return exprValue{value: base64.StdEncoding.EncodeToString(sum)}, nil
+	default:
+		return exprValue{}, fmt.Errorf("unknown encoding %q, expected hex or base64", encoding)
+	}
+}
+
+// jsonFunc encodes the piped value as JSON, e.g. a string becomes a quoted JSON string.
+func jsonFunc(in *exprValue, args []exprValue) (exprValue, error) {
+	if in == nil || len(args) != 0 {
+		return exprValue{}, fmt.Errorf("expects a piped value and no arguments")
+	}
+
+	data, err := json.Marshal(stringKeys(in.value))
+	if err != nil {
+		return exprValue{}, err
+	}
+
+	return exprValue{value: string(data)}, nil
+}
+
+// defaultFunc replaces a missing or empty value.
+func defaultFunc(in *exprValue, args []exprValue) (exprValue, error) {
+	if len(args) != 1 {
+		return exprValue{}, fmt.Errorf("expects one value, e.g. {{ name | default \"anonymous\" }}")
+	}
+
+	if in == nil || in.value == nil || in.text() == "" {
+		return args[0], nil
+	}
+
+	return *in, nil
+}
+
+// nowFunc returns the current time: `{{ now }}` or `{{ now | date "unix" }}`.
+func nowFunc(in *exprValue, args []exprValue) (exprValue, error) {
+	if in != nil || len(args) != 0 {
+		return exprValue{}, fmt.Errorf("takes no piped value and no arguments")
+	}
+
+	return exprValue{value: time.Now().UTC()}, nil
+}
+
+// dateFunc formats the piped time with a layout of `now` fixtures: `date FORMAT`.
+// Piped strings are parsed as RFC 3339 times, dates or unix seconds.
+func dateFunc(in *exprValue, args []exprValue) (exprValue, error) {
+	if in == nil || len(args) != 1 {
+		return exprValue{}, fmt.Errorf("expects a piped time and a format, e.g. {{ now | date \"date\" }}")
+	}
+
+	t, ok := in.value.(time.Time)
+	if !ok {
+		var err error
+		if t, err = parseTime(in.text()); err != nil {
+			return exprValue{}, err
+		}
+	}
+
+	return exprValue{value: formatNow(t, args[0].text())}, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, time.DateTime, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+
+	return time.Time{}, fmt.Errorf("cannot parse %q as a time", s)
+}
+
+// arithmeticFunc adapts a binary operation to a template function, e.g. `{{ page | add 1 }}`.
+// Integers are computed exactly with intOp, so that large ids keep every digit,
+// and decimals with floatOp. intOp reports false when its result is not an
+// integer, to compute it with floatOp instead. A nil floatOp only takes integers.
+func arithmeticFunc(
+	intOp func(a, b int64) (int64, bool, error),
+	floatOp func(a, b float64) (float64, error),
+) templateFunc {
+	return func(in *exprValue, args []exprValue) (exprValue, error) {
+		if in == nil || len(args) != 1 {
+			return exprValue{}, fmt.Errorf("expects a piped number and one number")
+		}
+
+		aText, bText := in.text(), args[0].text()
+
+		aInt, aErr := strconv.ParseInt(aText, 10, 64)
+		bInt, bErr := strconv.ParseInt(bText, 10, 64)
+
+		if aErr == nil && bErr == nil {
+			result, ok, err := intOp(aInt, bInt)
+			if err != nil {
+				return exprValue{}, err
+			}
+
+			if ok {
+				return exprValue{value: strconv.FormatInt(result, 10)}, nil
+			}
+		}
+
+		if floatOp == nil {
+			if aErr != nil {
+				return exprValue{}, fmt.Errorf("%q is not an integer", aText)
+			}
+
+			return exprValue{}, fmt.Errorf("%q is not an integer", bText)
+		}
+
+		a, err := strconv.ParseFloat(aText, 64)
+		if err != nil {
+			return exprValue{}, fmt.Errorf("%q is not a number", aText)
+		}
+
+		b, err := strconv.ParseFloat(bText, 64)
+		if err != nil {
+			return exprValue{}, fmt.Errorf("%q is not a number", bText)
+		}
+
+		result, err := floatOp(a, b)
+		if err != nil {
+			return exprValue{}, err
+		}
+
+		return exprValue{value: strconv.FormatFloat(result, 'f', -1, 64)}, nil
+	}
+}
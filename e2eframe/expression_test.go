@@ -0,0 +1,174 @@
+package e2eframe
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInterpolateExpressions(t *testing.T) {
+	payload := `{"event":"paid"}`
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(payload))
+	sum := mac.Sum(nil)
+
+	fixtures := []Fixture{
+		&FixtureV1{FixtureName: "payload", FixtureValue: payload},
+		&FixtureV1{FixtureName: "secret", FixtureValue: "s3cret"},
+		&FixtureV1{FixtureName: "name", FixtureValue: "Jane Doe"},
+		&FixtureV1{FixtureName: "page", FixtureValue: "2"},
+		&FixtureV1{FixtureName: "created_at", FixtureValue: "2025-03-01T10:20:30Z"},
+		&CapturedFixture{CaptureName: "user", CaptureValue: `{"id": 7, "tags": ["a"]}`},
+		&FixtureV1{FixtureName: "snowflake", FixtureValue: "1234567890123456789"},
+	}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{expr: "{{ payload | hmac secret }}", want: hex.EncodeToString(sum)},
+		{expr: `{{ payload | hmac secret "sha256" "base64" }}`, want: base64.StdEncoding.EncodeToString(sum)},
+		{expr: "{{ name | base64 }}", want: base64.StdEncoding.EncodeToString([]byte("Jane Doe"))},
+		{expr: "{{ name | base64 | base64decode }}", want: "Jane Doe"},
+		{expr: "{{ name | urlencode }}", want: "Jane+Doe"},
+		{expr: "{{ name | upper }}/{{ name | lower }}", want: "JANE DOE/jane doe"},
+		{expr: "{{ name | sha256 }}", want: func() string { s := sha256.Sum256([]byte("Jane Doe")); return hex.EncodeToString(s[:]) }()},
+		{expr: "{{ name | json }}", want: `"Jane Doe"`},
+		{expr: "{{ user.tags | json }}", want: `["a"]`},
+		{expr: `{{ nickname | default "anonymous" | upper }}`, want: "ANONYMOUS"},
+		{expr: `{{ name | default 'anonymous' }}`, want: "Jane Doe"},
+		{expr: "{{ page | add 1 }} {{ page | sub 3 }} {{ page | mul 2.5 }} {{ page | div 4 }} {{ user.id | mod 4 }}", want: "3 -1 5 0.5 3"},
+		// Integers keep every digit, decimals use floating point
+		{expr: "{{ snowflake | add 1 }} {{ snowflake | sub 10 }} {{ snowflake | mul -1 }}", want: "1234567890123456790 1234567890123456779 -1234567890123456789"},
+		{expr: "{{ snowflake | div 3 }} {{ snowflake | mod 1000 }} {{ page | div 2 }}", want: "411522630041152263 789 1"},
+		{expr: `{{ created_at | date "date" }} {{ created_at | date "unix" }}`, want: "2025-03-01 1740824430"},
+		{expr: `{{ "literal" | upper }}`, want: "LITERAL"},
+		// Missing fixtures and invalid expressions are left as is
+		{expr: "{{ nickname | upper }}", want: "{{ nickname | upper }}"},
+		{expr: "{{ name | shout }}", want: "{{ name | shout }}"},
+		{expr: "{{ page | div 0 }}", want: "{{ page | div 0 }}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := InterpolateString(FixtureInterpolationRegex, tt.expr, fixtures); got != tt.want {
+				t.Errorf("InterpolateString(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+
+	now := InterpolateString(FixtureInterpolationRegex, `{{ now | date "date" }}`, fixtures)
+	if want := time.Now().UTC().Format(time.DateOnly); now != want {
+		t.Errorf("now = %q, want %q", now, want)
+	}
+}
+
+func TestEvaluateFixtureExpressionErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{expr: "name | shout", wantErr: `unknown function "shout"`},
+		{expr: "name |", wantErr: "empty expression"},
+		{expr: `name | default "x`, wantErr: "unterminated string"},
+		{expr: "name | hmac", wantErr: "hmac: expects a piped value and a key"},
+		{expr: `name | hmac secret "md5"`, wantErr: `unknown algorithm "md5"`},
+		{expr: "name | add x", wantErr: "add: fixture x not found"},
+		{expr: "name | add 1", wantErr: `add: "jane" is not a number`},
+		{expr: "max | add 1", wantErr: "add: integer overflow"},
+		{expr: "max | mul 2", wantErr: "mul: integer overflow"},
+		{expr: "min | sub 1", wantErr: "sub: integer overflow"},
+		{expr: "min | div -1", wantErr: "div: integer overflow"},
+		{expr: "max | mod 0", wantErr: "mod: division by zero"},
+		{expr: "max | mod 2.5", wantErr: `mod: "2.5" is not an integer`},
+	}
+
+	fixtures := []Fixture{
+		&FixtureV1{FixtureName: "name", FixtureValue: "jane"},
+		&FixtureV1{FixtureName: "secret", FixtureValue: "s3cret"},
+		&FixtureV1{FixtureName: "max", FixtureValue: "9223372036854775807"},
+		&FixtureV1{FixtureName: "min", FixtureValue: "-9223372036854775808"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, _, _, err := EvaluateFixtureExpression(fixtures, tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestInterpolateStringErrReportsFailingExpressions(t *testing.T) {
+	fixtures := []Fixture{
+		&FixtureV1{FixtureName: "page", FixtureValue: "2"},
+		&CapturedFixture{CaptureName: "user", CaptureValue: `{"address": {"city": "Athens"}}`},
+	}
+
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{expr: "/users?page={{ page | div 0 }}", wantErr: "{{ page | div 0 }}: div: division by zero"},
+		{expr: "{{ user.adress.city }}", wantErr: "{{ user.adress.city }}: user.adress.city not found in user"},
+		{expr: "{{ page.count }}", wantErr: "{{ page.count }}: page.count not found in page"},
+		// Missing fixtures are reported when the suite loads, defaults apply to missing paths
+		{expr: "{{ nickname.first }}"},
+		{expr: `{{ user.nickname | default "anonymous" }}`},
+		{expr: "{{ user.address.city }}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := InterpolateStringErr(FixtureInterpolationRegex, tt.expr, fixtures)
+
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestInterpolateVarsAndStartUnitsEvaluatesFixtureExpressions(t *testing.T) {
+	app := &graphUnit{name: "app", env: map[string]string{
+		"AUTH":      "Basic {{ credentials | base64 }}",
+		"LOG_LEVEL": `{{ log_level | default "info" }}`,
+	}}
+
+	suite := &TestSuiteV1{
+		TestUnits: []Unit{app},
+		Fixtures:  []Fixture{&FixtureV1{FixtureName: "credentials", FixtureValue: "user:pass"}},
+	}
+
+	deps, err := suite.calculateEnvDependencies()
+	if err != nil {
+		t.Fatalf("calculate env dependencies: %v", err)
+	}
+
+	levels, err := suite.unitStartupLevels(deps)
+	if err != nil {
+		t.Fatalf("unit startup levels: %v", err)
+	}
+
+	if err := suite.interpolateVarsAndStartUnits(context.Background(), &RunTestOptions{}, levels, deps, nil); err != nil {
+		t.Fatalf("start units: %v", err)
+	}
+
+	if got, want := app.received["AUTH"], "Basic "+base64.StdEncoding.EncodeToString([]byte("user:pass")); got != want {
+		t.Errorf("AUTH = %q, want %q", got, want)
+	}
+
+	if got := app.received["LOG_LEVEL"]; got != "info" {
+		t.Errorf("LOG_LEVEL = %q, want info", got)
+	}
+}
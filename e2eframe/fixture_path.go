@@ -0,0 +1,261 @@
+package e2eframe
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fixturePathSegmentRegex splits the path of a fixture reference into keys and indexes.
+var fixturePathSegmentRegex = regexp.MustCompile(`\.([a-zA-Z0-9_]+)|\[([0-9]+)\]`)
+
+// quotedFixtureRegex matches a JSON string made of a single fixture reference, e.g. "{{ user.id }}".
+var quotedFixtureRegex = regexp.MustCompile(`"\s*` + fixtureReferencePattern + `\s*"`)
+
+// StructuredFixture is implemented by fixtures whose value is a JSON or YAML document.
+type StructuredFixture interface {
+	Fixture
+	// Data returns the decoded document, false if the value is not a document.
+	Data() (any, bool)
+}
+
+// Data decodes the value of .json, .yaml and .yml file fixtures.
+func (f *FixtureV1) Data() (any, bool) {
+	switch strings.ToLower(filepath.Ext(f.FixtureFile)) {
+	case ".json", ".yaml", ".yml":
+	default:
+		return nil, false
+	}
+
+	return decodeFixtureDocument(f.Value())
+}
+
+// decodeFixtureDocument decodes a JSON or YAML document. JSON is valid YAML.
+func decodeFixtureDocument(value []byte) (any, bool) {
+	var data any
+	if len(value) == 0 || yaml.Unmarshal(value, &data) != nil {
+		return nil, false
+	}
+
+	return data, true
+}
+
+// LookupFixture resolves a fixture reference: a fixture name optionally followed
+// by a path, such as `user`, `user.address.city` or `order.items[0].sku`.
+// References to the variables of a VariableFixture, such as `db.dsn`, resolve to
+// a string.
+//
+// Without a path, structured fixtures return their decoded document and other
+// fixtures their value as a string. Paths are resolved in the document of
+// structured fixtures, or in the value of other fixtures decoded as JSON or YAML
+// (e.g. a captured object). structured reports whether the value comes from a
+// document and so keeps its JSON type.
+func LookupFixture(fixtures []Fixture, ref string) (value any, structured bool, ok bool) {
+	name, path, _ := strings.Cut(ref, ".")
+	if i := strings.Index(name, "["); i >= 0 {
+		name, path = ref[:i], ref[i:]
+	} else if path != "" {
+		path = "." + path
+	}
+
+	var fixture Fixture
+
+	for _, f := range fixtures {
+		if f.Name() != name {
+			continue
+		}
+
+		// Unknown variables fall through to the fixtures with the same name
+		if variables, ok := f.(VariableFixture); ok {
+			if value, ok := lookupVariable(variables, path); ok {
+				return value, false, true
+			}
+
+			continue
+		}
+
+		fixture = f
+
+		break
+	}
+
+	if fixture == nil {
+		return nil, false, false
+	}
+
+	data, isDocument := any(nil), false
+	if structuredFixture, ok := fixture.(StructuredFixture); ok {
+		data, isDocument = structuredFixture.Data()
+	}
+
+	if path == "" {
+		if isDocument {
+			return data, true, true
+		}
+
+		raw := fixture.Value()
+
+		return string(raw), false, len(raw) > 0
+	}
+
+	if !isDocument {
+		if data, isDocument = decodeFixtureDocument(fixture.Value()); !isDocument {
+			return nil, false, false
+		}
+	}
+
+	for _, segment := range fixturePathSegmentRegex.FindAllStringSubmatch(path, -1) {
+		if segment[1] != "" {
+			switch node := data.(type) {
+			case map[string]any:
+				data, ok = node[segment[1]]
+			case map[any]any:
+				data, ok = node[segment[1]]
+			default:
+				ok = false
+			}
+		} else {
+			index, _ := strconv.Atoi(segment[2])
+
+			list, isList := data.([]any)
+			if ok = isList && index < len(list); ok {
+				data = list[index]
+			}
+		}
+
+		if !ok {
+			return nil, false, false
+		}
+	}
+
+	return data, true, true
+}
+
+// lookupVariable resolves a path made of a single key, e.g. `.dsn`, to a variable.
+func lookupVariable(fixture VariableFixture, path string) (string, bool) {
+	segments := fixturePathSegmentRegex.FindAllStringSubmatch(path, -1)
+	if len(segments) != 1 || segments[0][1] == "" || segments[0][0] != path {
+		return "", false
+	}
+
+	return fixture.Variable(segments[0][1])
+}
+
+// formatFixtureValue returns the text of a fixture value, structured values are encoded as JSON.
+func formatFixtureValue(value any) string {
+	return FormatCapturedValue(stringKeys(value))
+}
+
+// InterpolateValue interpolates the fixtures referenced by a value decoded from YAML or JSON.
+// A string made of a single reference to a structured value is replaced by that value,
+// keeping its JSON type; other strings are interpolated with InterpolateString.
+// Maps and lists are interpolated recursively.
+func InterpolateValue(regx *regexp.Regexp, value any, fixtures []Fixture) any {
+	switch v := value.(type) {
+	case string:
+		if match := regx.FindStringSubmatch(strings.TrimSpace(v)); match != nil && match[0] == strings.TrimSpace(v) {
+			if resolved, structured, ok, err := EvaluateFixtureExpression(fixtures, match[1]); err == nil && ok && structured {
+				return resolved
+			}
+		}
+
+		return InterpolateString(regx, v, fixtures)
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = InterpolateValue(regx, item, fixtures)
+		}
+
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = InterpolateValue(regx, item, fixtures)
+		}
+
+		return result
+	default:
+		return value
+	}
+}
+
+// InterpolateJSON interpolates the fixtures referenced by a JSON document.
+// A JSON string value made of a single reference to a structured value, such as
+// "{{ user.id }}", is replaced by the JSON encoding of that value, so numbers,
+// booleans, objects and lists keep their type. Object keys always stay strings.
+// Text that is not valid JSON is interpolated with InterpolateString.
+func InterpolateJSON(regx *regexp.Regexp, str string, fixtures []Fixture) string {
+	if json.Valid([]byte(str)) {
+		var sb strings.Builder
+
+		last := 0
+
+		for _, match := range quotedFixtureRegex.FindAllStringSubmatchIndex(str, -1) {
+			start, end := match[0], match[1]
+			if isJSONKey(str, end) {
+				continue
+			}
+
+			value, structured, ok, err := EvaluateFixtureExpression(fixtures, str[match[2]:match[3]])
+			if err != nil || !ok || !structured {
+				continue
+			}
+
+			data, err := json.Marshal(stringKeys(value))
+			if err != nil {
+				continue
+			}
+
+			sb.WriteString(str[last:start])
+			sb.Write(data)
+
+			last = end
+		}
+
+		sb.WriteString(str[last:])
+		str = sb.String()
+	}
+
+	return InterpolateString(regx, str, fixtures)
+}
+
+// isJSONKey reports whether the JSON string ending at end is an object key,
+// that is followed by a colon.
+func isJSONKey(str string, end int) bool {
+	rest := strings.TrimLeft(str[end:], " \t\r\n")
+
+	return strings.HasPrefix(rest, ":")
+}
+
+// stringKeys converts the YAML mappings with non-string keys of a document to JSON objects.
+func stringKeys(value any) any {
+	switch v := value.(type) {
+	case map[any]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[FormatCapturedValue(key)] = stringKeys(item)
+		}
+
+		return result
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = stringKeys(item)
+		}
+
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = stringKeys(item)
+		}
+
+		return result
+	default:
+		return value
+	}
+}
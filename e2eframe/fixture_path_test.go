@@ -0,0 +1,150 @@
+package e2eframe
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func structuredFixtures(t *testing.T) []Fixture {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"user.json": `{"user": {"id": 42, "name": "alice", "admin": true}, "items": [{"sku": "A-1"}, {"sku": "B-2"}]}`,
+		"order.yml": "order:\n  total: 9.5\n  tags: [new, paid]\n",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return []Fixture{
+		&FixtureV1{FixtureName: "test_data", FixtureFile: "user.json", RelativePath: dir},
+		&FixtureV1{FixtureName: "order", FixtureFile: "order.yml", RelativePath: dir},
+		&FixtureV1{FixtureName: "greeting", FixtureValue: "hello"},
+		&CapturedFixture{CaptureName: "created", CaptureValue: `{"id": "u-1", "roles": ["admin"]}`},
+	}
+}
+
+func TestLookupFixture(t *testing.T) {
+	fixtures := structuredFixtures(t)
+
+	tests := []struct {
+		ref            string
+		want           any
+		wantStructured bool
+		wantOK         bool
+	}{
+		{ref: "test_data.user.id", want: 42, wantStructured: true, wantOK: true},
+		{ref: "test_data.user.admin", want: true, wantStructured: true, wantOK: true},
+		{ref: "test_data.items[1].sku", want: "B-2", wantStructured: true, wantOK: true},
+		{ref: "test_data.items", want: []any{map[string]any{"sku": "A-1"}, map[string]any{"sku": "B-2"}}, wantStructured: true, wantOK: true},
+		{ref: "order.order.total", want: 9.5, wantStructured: true, wantOK: true},
+		{ref: "created.roles[0]", want: "admin", wantStructured: true, wantOK: true},
+		{ref: "greeting", want: "hello", wantOK: true},
+		{ref: "test_data.items[5].sku"},
+		{ref: "test_data.user.missing"},
+		{ref: "greeting.length"},
+		{ref: "missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, structured, ok := LookupFixture(fixtures, tt.ref)
+			if ok != tt.wantOK || structured != tt.wantStructured || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LookupFixture(%q) = %#v, %v, %v, want %#v, %v, %v",
+					tt.ref, got, structured, ok, tt.want, tt.wantStructured, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestInterpolateFixturePaths(t *testing.T) {
+	fixtures := structuredFixtures(t)
+
+	got := InterpolateString(
+		FixtureInterpolationRegex,
+		"/users/{{ test_data.user.id }}/items/{{ test_data.items[0].sku }}?tags={{ order.order.tags }}&x={{ test_data.nope }}",
+		fixtures,
+	)
+	if want := `/users/42/items/A-1?tags=["new","paid"]&x={{ test_data.nope }}`; got != want {
+		t.Errorf("InterpolateString = %q, want %q", got, want)
+	}
+
+	value := InterpolateValue(FixtureInterpolationRegex, map[string]any{
+		"id":    "{{ test_data.user.id }}",
+		"label": "user {{ test_data.user.name }}",
+		"roles": []any{"{{ created.roles }}"},
+		"plain": "{{ greeting }}",
+	}, fixtures)
+
+	want := map[string]any{
+		"id":    42,
+		"label": "user alice",
+		"roles": []any{[]any{"admin"}},
+		"plain": "hello",
+	}
+	if !reflect.DeepEqual(value, want) {
+		t.Errorf("InterpolateValue = %#v, want %#v", value, want)
+	}
+
+	body := InterpolateJSON(
+		FixtureInterpolationRegex,
+		`{"id": "{{ test_data.user.id }}", "name": "{{ test_data.user.name }}", "greeting": "{{ greeting }} world", "total": "{{ order.order.total }}"}`,
+		fixtures,
+	)
+	if want := `{"id": 42, "name": "alice", "greeting": "hello world", "total": 9.5}`; body != want {
+		t.Errorf("InterpolateJSON = %s, want %s", body, want)
+	}
+
+	// Object keys stay strings, even when they refer to a number
+	body = InterpolateJSON(
+		FixtureInterpolationRegex,
+		`{"{{ test_data.user.id }}" : "{{ test_data.user.id }}", "{{ test_data.user.name }}": {"{{ order.order.total }}":"{{ order.order.total }}"}}`,
+		fixtures,
+	)
+	if want := `{"42" : 42, "alice": {"9.5":9.5}}`; body != want {
+		t.Errorf("InterpolateJSON = %s, want %s", body, want)
+	}
+
+	if !json.Valid([]byte(body)) {
+		t.Errorf("InterpolateJSON produced invalid JSON: %s", body)
+	}
+}
+
+func TestEnvDependenciesDistinguishFixturePathsFromUnitVariables(t *testing.T) {
+	suite := &TestSuiteV1{
+		Fixtures: []Fixture{&FixtureV1{FixtureName: "config", FixtureValue: `{"db": "users"}`}},
+		TestUnits: []Unit{
+			&graphUnit{name: "db"},
+			&graphUnit{name: "app", env: map[string]string{
+				"DSN":     "{{ db.dsn }}",
+				"DB_NAME": "{{ config.db }}",
+			}},
+		},
+	}
+
+	deps, err := suite.calculateEnvDependencies()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := map[string]EnvDependency{}
+	for _, dep := range deps {
+		got[dep.AssignedEnvName] = dep
+	}
+
+	if dep := got["DSN"]; dep.IsFixture || dep.DependencyUnitName != "db" || dep.VarName != "dsn" {
+		t.Errorf("DSN dependency = %+v, want the dsn variable of unit db", dep)
+	}
+
+	if dep := got["DB_NAME"]; !dep.IsFixture || dep.VarName != "config" {
+		t.Errorf("DB_NAME dependency = %+v, want the config fixture", dep)
+	}
+}
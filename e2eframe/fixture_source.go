@@ -0,0 +1,363 @@
+package e2eframe
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// FixtureGenerator is the kind of value a generated fixture produces.
+type FixtureGenerator string
+
+const (
+	FixtureGeneratorUUID     FixtureGenerator = "uuid"
+	FixtureGeneratorString   FixtureGenerator = "string"
+	FixtureGeneratorInt      FixtureGenerator = "int"
+	FixtureGeneratorNow      FixtureGenerator = "now"
+	FixtureGeneratorSequence FixtureGenerator = "sequence"
+)
+
+const (
+	defaultGeneratedStringLength = 16
+	defaultGeneratedIntMax       = 1_000_000
+	generatedStringCharset       = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// fixtureSourceFields lists the fields allowed next to `env:` or each `generate:` kind.
+var fixtureSourceFields = map[FixtureGenerator][]string{
+	"":                       {"env", "default"},
+	FixtureGeneratorUUID:     {"generate"},
+	FixtureGeneratorString:   {"generate", "length", "seed"},
+	FixtureGeneratorInt:      {"generate", "min", "max", "seed"},
+	FixtureGeneratorNow:      {"generate", "format", "offset"},
+	FixtureGeneratorSequence: {"generate", "start", "step"},
+}
+
+// nowFormats are the named layouts accepted by the format of `now` fixtures.
+// Any other format is used as a Go time layout.
+var nowFormats = map[string]string{
+	"rfc3339":      time.RFC3339,
+	"rfc3339_nano": time.RFC3339Nano,
+	"date":         time.DateOnly,
+	"datetime":     time.DateTime,
+}
+
+// fixtureSequences holds the next value of every sequence fixture of the run, by fixture name.
+// Counters belong to the run, not to a suite: every suite defining a sequence with the
+// same name shares its counter, so that their values do not collide. Suites needing
+// their own counter give their fixture a distinct name.
+var fixtureSequences = struct {
+	sync.Mutex
+	next map[string]int64
+}{next: make(map[string]int64)}
+
+// FixtureSource produces the value of a fixture that is not written in the suite:
+// an environment variable or a generated value.
+type FixtureSource struct {
+	// Env is the environment variable the value is read from
+	Env string
+	// Default is the value used when Env is not set, Env is required otherwise
+	Default *string
+	// Generate is the kind of generated value
+	Generate FixtureGenerator
+	// Length is the length of generated strings
+	Length int
+	// Min and Max bound generated ints, both inclusive
+	Min, Max int64
+	// Seed makes generated strings and ints reproducible, a random seed is used otherwise
+	Seed *int64
+	// Format is the layout of `now` values: a named format, unix, unix_ms or a Go layout
+	Format string
+	// Offset is added to the current time of `now` values
+	Offset time.Duration
+	// Start is the first value of a sequence and Step the increment between suites
+	Start, Step int64
+}
+
+// GeneratedFixture is the value a generator produced for a suite run, reported
+// so that a failing run can be reproduced.
+type GeneratedFixture struct {
+	Name      string
+	Value     string
+	Generator FixtureGenerator
+	// Seed is the seed of generated strings and ints
+	Seed *int64
+}
+
+// decodeFixtureSource decodes the `env:` or `generate:` mapping of a fixture.
+func decodeFixtureSource(node *yaml.Node) (*FixtureSource, error) {
+	var raw struct {
+		Env      *string `yaml:"env"`
+		Default  *string `yaml:"default"`
+		Generate string  `yaml:"generate"`
+		Length   *int    `yaml:"length"`
+		Min      *int64  `yaml:"min"`
+		Max      *int64  `yaml:"max"`
+		Seed     *int64  `yaml:"seed"`
+		Format   string  `yaml:"format"`
+		Offset   string  `yaml:"offset"`
+		Start    *int64  `yaml:"start"`
+		Step     *int64  `yaml:"step"`
+	}
+
+	if err := node.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	source := &FixtureSource{
+		Generate: FixtureGenerator(raw.Generate),
+		Default:  raw.Default,
+		Seed:     raw.Seed,
+		Format:   raw.Format,
+		Length:   defaultGeneratedStringLength,
+		Max:      defaultGeneratedIntMax,
+		Start:    1,
+		Step:     1,
+	}
+
+	switch {
+	case raw.Env != nil && raw.Generate != "":
+		return nil, fmt.Errorf("fixture cannot set both env and generate")
+	case raw.Env != nil:
+		if *raw.Env == "" {
+			return nil, fmt.Errorf("env of fixture must name an environment variable")
+		}
+
+		source.Env = *raw.Env
+	case raw.Generate == "":
+		return nil, fmt.Errorf("fixture mapping must have a file, env or generate field")
+	}
+
+	allowed, ok := fixtureSourceFields[source.Generate]
+	if !ok {
+		return nil, fmt.Errorf(
+			"unknown fixture generator %q, expected uuid, string, int, now or sequence",
+			raw.Generate,
+		)
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		if key := node.Content[i].Value; !slices.Contains(allowed, key) {
+			if source.Env != "" {
+				return nil, fmt.Errorf("unknown field %s in env fixture at line %d", key, node.Content[i].Line)
+			}
+
+			return nil, fmt.Errorf(
+				"unknown field %s for %s fixtures at line %d, expected one of: %s",
+				key,
+				source.Generate,
+				node.Content[i].Line,
+				strings.Join(allowed, ", "),
+			)
+		}
+	}
+
+	if raw.Length != nil {
+		if *raw.Length <= 0 {
+			return nil, fmt.Errorf("length of generated strings must be positive, got %d", *raw.Length)
+		}
+
+		source.Length = *raw.Length
+	}
+
+	if raw.Min != nil {
+		source.Min = *raw.Min
+	}
+
+	if raw.Max != nil {
+		source.Max = *raw.Max
+	}
+
+	if source.Min > source.Max {
+		return nil, fmt.Errorf("min %d of generated ints is greater than max %d", source.Min, source.Max)
+	}
+
+	if raw.Offset != "" {
+		offset, err := time.ParseDuration(raw.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset %q, expected a duration like -1h or 30m", raw.Offset)
+		}
+
+		source.Offset = offset
+	}
+
+	if raw.Start != nil {
+		source.Start = *raw.Start
+	}
+
+	if raw.Step != nil {
+		source.Step = *raw.Step
+	}
+
+	return source, nil
+}
+
+// resolve produces the value of the fixture named name. Generated values are
+// also returned as a GeneratedFixture, environment values are not.
+func (s *FixtureSource) resolve(name string) (string, *GeneratedFixture, error) {
+	if s.Env != "" {
+		if value, ok := os.LookupEnv(s.Env); ok {
+			return value, nil, nil
+		}
+
+		if s.Default != nil {
+			return *s.Default, nil, nil
+		}
+
+		return "", nil, fmt.Errorf("fixture %s: environment variable %s is not set and has no default", name, s.Env)
+	}
+
+	generated := &GeneratedFixture{Name: name, Generator: s.Generate}
+
+	switch s.Generate {
+	case FixtureGeneratorUUID:
+		generated.Value = uuid.NewString()
+	case FixtureGeneratorString, FixtureGeneratorInt:
+		seed := rand.Int64()
+		if s.Seed != nil {
+			seed = *s.Seed
+		}
+
+		generated.Seed = &seed
+		r := rand.New(rand.NewPCG(uint64(seed), 0))
+
+		if s.Generate == FixtureGeneratorInt {
+			generated.Value = strconv.FormatInt(randomInt64(r, s.Min, s.Max), 10)
+
+			break
+		}
+
+		value := make([]byte, s.Length)
+		for i := range value {
+			value[i] = generatedStringCharset[r.IntN(len(generatedStringCharset))]
+		}
+
+		generated.Value = string(value)
+	case FixtureGeneratorNow:
+		generated.Value = formatNow(time.Now().Add(s.Offset), s.Format)
+	case FixtureGeneratorSequence:
+		fixtureSequences.Lock()
+
+		value, ok := fixtureSequences.next[name]
+		if !ok {
+			value = s.Start
+		}
+
+		fixtureSequences.next[name] = value + s.Step
+		fixtureSequences.Unlock()
+
+		generated.Value = strconv.FormatInt(value, 10)
+	default:
+		return "", nil, fmt.Errorf("fixture %s: unknown generator %q", name, s.Generate)
+	}
+
+	return generated.Value, generated, nil
+}
+
+// randomInt64 returns a random integer between low and high, inclusive. The width
+// of the range is computed unsigned, as it overflows int64 for wide ranges.
+func randomInt64(r *rand.Rand, low, high int64) int64 {
+	width := uint64(high) - uint64(low)
+	if width == math.MaxUint64 {
+		return int64(r.Uint64())
+	}
+
+	return low + int64(r.Uint64N(width+1))
+}
+
+// formatNow formats a time for a `now` fixture, in UTC unless the layout has a zone.
+func formatNow(now time.Time, format string) string {
+	switch format {
+	case "":
+		return now.UTC().Format(time.RFC3339)
+	case "unix":
+		return strconv.FormatInt(now.Unix(), 10)
+	case "unix_ms":
+		return strconv.FormatInt(now.UnixMilli(), 10)
+	}
+
+	if layout, ok := nowFormats[format]; ok {
+		format = layout
+	}
+
+	return now.UTC().Format(format)
+}
+
+// resolveFixtures reads the fixture files and the environment and generates the values
+// of the fixtures that need it, including the fixtures scoped to a test. Values are
+// produced once and kept for the whole suite run.
+func (t *TestSuiteV1) resolveFixtures() ([]GeneratedFixture, error) {
+	var generated []GeneratedFixture
+
+	for _, fixture := range t.Fixtures {
+		g, err := resolveFixture(fixture)
+		if err != nil {
+			return nil, err
+		}
+
+		if g != nil {
+			generated = append(generated, *g)
+		}
+	}
+
+	for _, test := range t.TestSuiteTests {
+		for _, fixture := range t.TestMetas[test.Name()].Fixtures {
+			g, err := resolveFixture(fixture)
+			if err != nil {
+				return nil, fmt.Errorf("test %s: %w", test.Name(), err)
+			}
+
+			// Generated values of a test are reported under the name of the test
+			if g != nil {
+				g.Name = test.Name() + " > " + g.Name
+				generated = append(generated, *g)
+			}
+		}
+	}
+
+	return generated, nil
+}
+
+// resolveFixture produces the value of a fixture, once. The value of a secret
+// fixture is masked in every output from then on.
+func resolveFixture(fixture Fixture) (*GeneratedFixture, error) {
+	f, ok := fixture.(*FixtureV1)
+	if !ok {
+		return nil, nil
+	}
+
+	var generated *GeneratedFixture
+
+	switch {
+	case f.resolved:
+	case f.Source == nil:
+		// File fixtures are read now so that a missing file fails the suite
+		if _, err := f.load(); err != nil {
+			return nil, err
+		}
+	default:
+		value, g, err := f.Source.resolve(f.FixtureName)
+		if err != nil {
+			return nil, err
+		}
+
+		f.FixtureValue = value
+		f.resolved = true
+		generated = g
+	}
+
+	if f.IsSecret() {
+		RegisterSecret(string(f.Value()))
+	}
+
+	return generated, nil
+}
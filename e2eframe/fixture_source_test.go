@@ -0,0 +1,276 @@
+package e2eframe
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func decodeFixtures(t *testing.T, src string) []Fixture {
+	t.Helper()
+
+	cfg := &TestSuiteConfigV1{}
+	if err := yaml.Unmarshal([]byte(src), cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return cfg.Fixtures
+}
+
+func TestFixtureSourceResolve(t *testing.T) {
+	t.Setenv("ENE_TEST_API_KEY", "secret")
+
+	fixtures := decodeFixtures(t, `
+kind: e2e_test:v1
+name: users
+target: app
+units:
+  - name: app
+    kind: stub
+tests:
+  - name: a
+    kind: stub
+fixtures:
+  - api_key:
+      env: ENE_TEST_API_KEY
+  - region:
+      env: ENE_TEST_UNSET_REGION
+      default: eu-west-1
+  - user_id:
+      generate: uuid
+  - suffix:
+      generate: string
+      length: 8
+      seed: 42
+  - amount:
+      generate: int
+      min: 5
+      max: 5
+  - yesterday:
+      generate: now
+      format: unix
+      offset: -24h
+`)
+
+	suite := &TestSuiteV1{TestName: "users", Fixtures: fixtures}
+
+	generated, err := suite.resolveFixtures()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	values := map[string]string{}
+	for _, fixture := range suite.Fixtures {
+		values[fixture.Name()] = string(fixture.Value())
+	}
+
+	if values["api_key"] != "secret" || values["region"] != "eu-west-1" {
+		t.Errorf("env fixtures = %q, %q", values["api_key"], values["region"])
+	}
+
+	if len(values["user_id"]) != 36 {
+		t.Errorf("user_id = %q, want a uuid", values["user_id"])
+	}
+
+	if len(values["suffix"]) != 8 || values["amount"] != "5" {
+		t.Errorf("suffix = %q, amount = %q", values["suffix"], values["amount"])
+	}
+
+	yesterday, err := strconv.ParseInt(values["yesterday"], 10, 64)
+	if err != nil || time.Since(time.Unix(yesterday, 0)).Round(time.Hour) != 24*time.Hour {
+		t.Errorf("yesterday = %q, want a unix time one day ago", values["yesterday"])
+	}
+
+	// Environment values are not reported, generated ones are
+	if len(generated) != 4 || generated[0].Name != "user_id" {
+		t.Fatalf("generated = %+v, want the 4 generated fixtures", generated)
+	}
+
+	if seed := generated[1].Seed; seed == nil || *seed != 42 {
+		t.Errorf("seed of suffix = %v, want 42", seed)
+	}
+
+	if seed := generated[2].Seed; seed == nil {
+		t.Error("ints generated without a seed must report the seed they used")
+	}
+
+	// Values are stable for the whole run
+	again, err := suite.resolveFixtures()
+	if err != nil || len(again) != 0 || string(suite.Fixtures[2].Value()) != values["user_id"] {
+		t.Errorf("resolving again must keep the values, got %+v, %v", again, err)
+	}
+
+	// The same seed reproduces the same value
+	other := &TestSuiteV1{Fixtures: decodeFixtures(t, `
+kind: e2e_test:v1
+name: users
+target: app
+units:
+  - name: app
+    kind: stub
+tests:
+  - name: a
+    kind: stub
+fixtures:
+  suffix:
+    generate: string
+    length: 8
+    seed: 42
+`)}
+
+	if _, err := other.resolveFixtures(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := string(other.Fixtures[0].Value()); got != values["suffix"] {
+		t.Errorf("seeded string = %q, want %q", got, values["suffix"])
+	}
+}
+
+func TestFixtureSourceSequence(t *testing.T) {
+	var got []string
+
+	for range 3 {
+		suite := &TestSuiteV1{Fixtures: []Fixture{
+			&FixtureV1{
+				FixtureName: "ene_test_order_no",
+				Source:      &FixtureSource{Generate: FixtureGeneratorSequence, Start: 100, Step: 10},
+			},
+		}}
+
+		if _, err := suite.resolveFixtures(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got = append(got, string(suite.Fixtures[0].Value()))
+	}
+
+	if want := "100,110,120"; strings.Join(got, ",") != want {
+		t.Errorf("sequence values = %v, want %s", got, want)
+	}
+}
+
+func TestFixtureSourceWideIntRanges(t *testing.T) {
+	ranges := [][2]int64{
+		{math.MinInt64, math.MaxInt64},
+		{math.MinInt64, 0},
+		{-1, math.MaxInt64},
+		{math.MaxInt64, math.MaxInt64},
+	}
+
+	for _, bounds := range ranges {
+		for seed := range int64(20) {
+			source := &FixtureSource{Generate: FixtureGeneratorInt, Min: bounds[0], Max: bounds[1], Seed: &seed}
+
+			value, _, err := source.resolve("n")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < bounds[0] || n > bounds[1] {
+				t.Fatalf("int between %d and %d = %q", bounds[0], bounds[1], value)
+			}
+		}
+	}
+}
+
+func TestFixtureSourceErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		wantErr string
+	}{
+		{name: "env and generate", fixture: "{env: A, generate: uuid}", wantErr: "cannot set both env and generate"},
+		{name: "no source", fixture: "{length: 3}", wantErr: "must have a file, env or generate field"},
+		{name: "unknown generator", fixture: "{generate: color}", wantErr: `unknown fixture generator "color"`},
+		{name: "field of another generator", fixture: "{generate: uuid, seed: 1}", wantErr: "unknown field seed for uuid fixtures"},
+		{name: "min greater than max", fixture: "{generate: int, min: 3, max: 1}", wantErr: "min 3 of generated ints is greater than max 1"},
+		{name: "invalid offset", fixture: "{generate: now, offset: yesterday}", wantErr: `invalid offset "yesterday"`},
+		{name: "file with other fields", fixture: "{file: a.json, env: A}", wantErr: "a file fixture cannot have other fields"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fixture FixtureV1
+
+			err := yaml.Unmarshal([]byte("value: "+tt.fixture), &fixture)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	suite := &TestSuiteV1{Fixtures: []Fixture{
+		&FixtureV1{FixtureName: "token", Source: &FixtureSource{Env: "ENE_TEST_UNSET_TOKEN"}},
+	}}
+
+	if _, err := suite.resolveFixtures(); err == nil || !strings.Contains(err.Error(), "ENE_TEST_UNSET_TOKEN is not set") {
+		t.Errorf("expected a missing environment variable error, got %v", err)
+	}
+}
+
+func TestJSONReportIncludesGeneratedFixtures(t *testing.T) {
+	secretary := NewTestsSecretary(nil)
+	seed := int64(7)
+
+	events := []Event{
+		&FixturesGeneratedEvent{
+			BaseEvent: BaseEvent{EventType: EventFixturesGenerated, Suite: "users"},
+			Fixtures:  []GeneratedFixture{{Name: "suffix", Value: "abc", Generator: FixtureGeneratorString, Seed: &seed}},
+		},
+		&TestEvent{
+			BaseEvent: BaseEvent{EventType: EventTestCompleted, Suite: "users"},
+			TestName:  "a",
+			Passed:    true,
+		},
+	}
+
+	for _, event := range events {
+		if err := secretary.ConsumeEvent(event); err != nil {
+			t.Fatalf("consume event: %v", err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+
+	processor, err := NewJSONReportProcessor(JSONReportProcessorParams{OutputFile: path, TestsSecretary: secretary})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := processor.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report struct {
+		Suites []struct {
+			GeneratedFixtures []map[string]any `json:"generatedFixtures"`
+		} `json:"suites"`
+	}
+
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Suites) != 1 || len(report.Suites[0].GeneratedFixtures) != 1 {
+		t.Fatalf("unexpected report: %s", data)
+	}
+
+	fixture := report.Suites[0].GeneratedFixtures[0]
+	if fixture["name"] != "suffix" || fixture["value"] != "abc" || fixture["generator"] != "string" || fixture["seed"] != float64(7) {
+		t.Errorf("generated fixture = %v", fixture)
+	}
+}
@@ -0,0 +1,356 @@
+package e2eframe
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includableFields are the fields a file merged with `include:` may define.
+var includableFields = map[string]struct{}{
+	"units":    {},
+	"fixtures": {},
+	"tests":    {},
+	"include":  {},
+}
+
+// sourceLocation is the position of a definition in a suite file or in a file it includes.
+type sourceLocation struct {
+	File string
+	Line int
+}
+
+func (l sourceLocation) String() string {
+	if l.File == "" {
+		return fmt.Sprintf("line %d", l.Line)
+	}
+
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// definition records where a unit, fixture or test was defined.
+type definition struct {
+	location sourceLocation
+	// inherited definitions come from an extended base suite and can be overridden
+	inherited bool
+}
+
+// location returns the position of a node of the file being decoded.
+func (t *TestSuiteConfigV1) location(node *yaml.Node) sourceLocation {
+	return sourceLocation{File: t.SourceFile, Line: node.Line}
+}
+
+// define records the definition of a named unit, fixture or test.
+// Only definitions inherited from an extended base suite may be redefined.
+func (t *TestSuiteConfigV1) define(kind, name string, location sourceLocation) error {
+	if t.definitions == nil {
+		t.definitions = make(map[string]definition)
+	}
+
+	key := kind + ":" + name
+
+	if previous, ok := t.definitions[key]; ok && !previous.inherited {
+		return &DetailedError{
+			Message: fmt.Sprintf(
+				"duplicate %s name: %s is defined at %s and %s",
+				kind,
+				name,
+				previous.location,
+				location,
+			),
+			File: location.File,
+			Line: location.Line,
+			Suggestions: []string{
+				fmt.Sprintf("Rename one of the %ss", kind),
+				"To override a definition, put it in a base suite and use 'extends:' instead of 'include:'",
+			},
+		}
+	}
+
+	t.definitions[key] = definition{location: location}
+
+	return nil
+}
+
+// addUnit adds a unit to the suite, replacing an inherited unit with the same name.
+func (t *TestSuiteConfigV1) addUnit(unit Unit, dependsOn []string, location sourceLocation) error {
+	if err := t.define("unit", unit.Name(), location); err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(t.Units, func(u Unit) bool { return u.Name() == unit.Name() })
+	if index >= 0 {
+		t.Units[index] = unit
+		t.dropReferences(referenceOwner{unit: unit.Name()})
+	} else {
+		t.Units = append(t.Units, unit)
+	}
+
+	// An overriding unit also replaces the dependencies of the inherited one
+	delete(t.UnitDependsOn, unit.Name())
+
+	if len(dependsOn) > 0 {
+		if t.UnitDependsOn == nil {
+			t.UnitDependsOn = make(map[string][]string)
+		}
+
+		t.UnitDependsOn[unit.Name()] = dependsOn
+	}
+
+	return nil
+}
+
+// addFixture adds a fixture to the suite, replacing an inherited fixture with the same name.
+func (t *TestSuiteConfigV1) addFixture(fixture Fixture, location sourceLocation) error {
+	if err := t.define("fixture", fixture.Name(), location); err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(t.Fixtures, func(f Fixture) bool { return f.Name() == fixture.Name() })
+	if index >= 0 {
+		t.Fixtures[index] = fixture
+	} else {
+		t.Fixtures = append(t.Fixtures, fixture)
+	}
+
+	return nil
+}
+
+// addTest adds a test to the suite, replacing an inherited test with the same name.
+func (t *TestSuiteConfigV1) addTest(test TestSuiteTest, meta TestMeta, location sourceLocation) error {
+	if err := t.define("test", test.Name(), location); err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(t.Tests, func(other TestSuiteTest) bool { return other.Name() == test.Name() })
+	if index >= 0 {
+		t.Tests[index] = test
+		t.dropReferences(referenceOwner{test: test.Name()})
+	} else {
+		t.Tests = append(t.Tests, test)
+	}
+
+	if t.TestMetas == nil {
+		t.TestMetas = make(map[string]TestMeta)
+	}
+
+	t.TestMetas[test.Name()] = meta
+
+	return nil
+}
+
+// extend inherits every field and definition of the base suite named by `extends:`.
+// The fields and definitions of the suite itself are decoded afterwards and override them.
+func (t *TestSuiteConfigV1) extend(key, value *yaml.Node) error {
+	var path string
+	if err := value.Decode(&path); err != nil || path == "" {
+		return &DetailedError{
+			Message:  fmt.Sprintf("extends at %s must be the path of a base suite file", t.location(key)),
+			File:     t.SourceFile,
+			Line:     key.Line,
+			Examples: []string{"extends: ../base.yml"},
+		}
+	}
+
+	base, err := t.loadFragment(value, path, nil)
+	if err != nil {
+		return err
+	}
+
+	sourceFile, relativePath, includeStack, env, project, profile := t.SourceFile, t.RelativePath, t.includeStack, t.env, t.project, t.profile
+
+	*t = *base
+	t.SourceFile, t.RelativePath, t.includeStack, t.env, t.project, t.profile = sourceFile, relativePath, includeStack, env, project, profile
+
+	for name, def := range t.definitions {
+		def.inherited = true
+		t.definitions[name] = def
+	}
+
+	return nil
+}
+
+// include merges the units, fixtures and tests of the files listed in `include:`.
+func (t *TestSuiteConfigV1) include(key, value *yaml.Node) error {
+	var pathNodes []*yaml.Node
+
+	switch value.Kind {
+	case yaml.ScalarNode:
+		pathNodes = []*yaml.Node{value}
+	case yaml.SequenceNode:
+		pathNodes = value.Content
+	default:
+		return &DetailedError{
+			Message:  fmt.Sprintf("include at %s must be a file path or a list of file paths", t.location(key)),
+			File:     t.SourceFile,
+			Line:     key.Line,
+			Examples: []string{"include:", "  - ../common/units.yml", "  - ../common/fixtures.yml"},
+		}
+	}
+
+	for _, pathNode := range pathNodes {
+		if pathNode.Kind != yaml.ScalarNode || pathNode.Value == "" {
+			return &DetailedError{
+				Message: fmt.Sprintf("include entry at %s must be a file path", t.location(pathNode)),
+				File:    t.SourceFile,
+				Line:    pathNode.Line,
+			}
+		}
+
+		fragment, err := t.loadFragment(pathNode, pathNode.Value, includableFields)
+		if err != nil {
+			return err
+		}
+
+		for _, unit := range fragment.Units {
+			location := fragment.definitions["unit:"+unit.Name()].location
+			if err := t.addUnit(unit, fragment.UnitDependsOn[unit.Name()], location); err != nil {
+				return err
+			}
+		}
+
+		for _, fixture := range fragment.Fixtures {
+			if err := t.addFixture(fixture, fragment.definitions["fixture:"+fixture.Name()].location); err != nil {
+				return err
+			}
+		}
+
+		for _, test := range fragment.Tests {
+			location := fragment.definitions["test:"+test.Name()].location
+			if err := t.addTest(test, fragment.TestMetas[test.Name()], location); err != nil {
+				return err
+			}
+		}
+
+		t.references = append(t.references, fragment.references...)
+
+		for name := range fragment.captureNames {
+			if t.captureNames == nil {
+				t.captureNames = make(map[string]struct{})
+			}
+
+			t.captureNames[name] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// loadFragment decodes a file included or extended by the suite. Relative paths are
+// resolved from the directory of the including file. When allowed is set, the file
+// may only define these fields.
+func (t *TestSuiteConfigV1) loadFragment(
+	pathNode *yaml.Node,
+	path string,
+	allowed map[string]struct{},
+) (*TestSuiteConfigV1, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(t.SourceFile), path)
+	}
+
+	path = filepath.Clean(path)
+
+	chain := append(slices.Clone(t.includeStack), t.SourceFile)
+	if slices.Contains(chain, path) {
+		return nil, &DetailedError{
+			Message: fmt.Sprintf(
+				"include cycle at %s: %s",
+				t.location(pathNode),
+				strings.Join(append(chain[slices.Index(chain, path):], path), " -> "),
+			),
+			File:        t.SourceFile,
+			Line:        pathNode.Line,
+			Suggestions: []string{"Move the definitions shared by these files to a separate file"},
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &DetailedError{
+			Message: fmt.Sprintf("could not read %s included at %s: %v", path, t.location(pathNode), err),
+			File:    t.SourceFile,
+			Line:    pathNode.Line,
+			Suggestions: []string{
+				"Paths are relative to the file that includes them",
+			},
+		}
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, NewYAMLError(fmt.Sprintf("%s: %v", path, err), path)
+	}
+
+	t.env.expand(&document, path)
+
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, NewValidationError(fmt.Sprintf("%s must contain a YAML mapping", path), path, 0)
+	}
+
+	root := document.Content[0]
+	t.project.overrideImages(root)
+
+	for i := 0; i < len(root.Content); i += 2 {
+		key := root.Content[i]
+		if _, ok := allowed[key.Value]; allowed != nil && !ok {
+			return nil, NewValidationError(
+				fmt.Sprintf(
+					"%s at %s cannot be included, included files may only define units, fixtures and tests",
+					key.Value,
+					sourceLocation{File: path, Line: key.Line},
+				),
+				path,
+				key.Line,
+			)
+		}
+	}
+
+	fragment := &TestSuiteConfigV1{
+		SourceFile:   path,
+		RelativePath: filepath.Dir(path),
+		includeStack: chain,
+		env:          t.env,
+		project:      t.project,
+	}
+
+	if err := fragment.decode(root); err != nil {
+		var detailedErr *DetailedError
+		if errors.As(err, &detailedErr) {
+			return nil, err
+		}
+
+		return nil, NewValidationError(fmt.Sprintf("%s: %v", path, err), path, 0)
+	}
+
+	return fragment, nil
+}
+
+// mappingField returns the key and value of a field of a mapping node, or nils if it is not set.
+func mappingField(node *yaml.Node, name string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == name {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+
+	return nil, nil
+}
+
+// withoutField returns a copy of a mapping node without one of its fields.
+func withoutField(node *yaml.Node, name string) *yaml.Node {
+	result := *node
+	result.Content = nil
+
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value != name {
+			result.Content = append(result.Content, node.Content[i], node.Content[i+1])
+		}
+	}
+
+	return &result
+}
@@ -0,0 +1,311 @@
+package e2eframe
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	stubUnitKind UnitKind          = "stub"
+	stubTestKind TestSuiteTestKind = "stub"
+)
+
+func init() {
+	RegisterUnitMarshaller(stubUnitKind, func(node *yaml.Node) (Unit, error) {
+		var config struct {
+			Name  string `yaml:"name"`
+			Image string `yaml:"image"`
+		}
+
+		if err := node.Decode(&config); err != nil {
+			return nil, err
+		}
+
+		return &graphUnit{name: config.Name, vars: map[string]string{"image": config.Image}}, nil
+	})
+
+	RegisterTestSuiteTestUnmarshaler(stubTestKind, func(node *yaml.Node) (TestSuiteTest, error) {
+		var config struct {
+			Name string `yaml:"name"`
+		}
+
+		if err := node.Decode(&config); err != nil {
+			return nil, err
+		}
+
+		return &namedTest{name: config.Name}, nil
+	})
+}
+
+// loadSuiteConfig writes the files to a temporary directory and decodes suite.yml.
+func loadSuiteConfig(t *testing.T, files map[string]string) (*TestSuiteConfigV1, string, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	suitePath := filepath.Join(dir, "suite.yml")
+
+	data, err := os.ReadFile(suitePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &TestSuiteConfigV1{SourceFile: suitePath}
+
+	return cfg, dir, yaml.Unmarshal(data, cfg)
+}
+
+func unitImages(units []Unit) map[string]string {
+	images := make(map[string]string, len(units))
+	for _, unit := range units {
+		image, _ := unit.Get("image")
+		images[unit.Name()] = image
+	}
+
+	return images
+}
+
+func testNames(tests []TestSuiteTest) []string {
+	names := make([]string, 0, len(tests))
+	for _, test := range tests {
+		names = append(names, test.Name())
+	}
+
+	return names
+}
+
+func TestIncludeMergesUnitsFixturesAndTests(t *testing.T) {
+	cfg, dir, err := loadSuiteConfig(t, map[string]string{
+		"suite.yml": `
+kind: e2e_test:v1
+name: users
+include:
+  - ../common/units.yml
+  - ../common/tests.yml
+target: app
+tests:
+  - name: local test
+    kind: stub
+`,
+		"../common/units.yml": `
+include: fixtures.yml
+units:
+  - name: db
+    kind: stub
+    image: postgres:16
+  - name: app
+    kind: stub
+    image: app:latest
+    depends_on: [db]
+`,
+		"../common/fixtures.yml": `
+fixtures:
+  - token: abc
+  - payload:
+      file: data/payload.json
+`,
+		"../common/tests.yml": `
+tests:
+  - name: shared test
+    kind: stub
+    tags: [smoke]
+`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := map[string]string{"db": "postgres:16", "app": "app:latest"}; !reflect.DeepEqual(unitImages(cfg.Units), want) {
+		t.Errorf("units = %v, want %v", unitImages(cfg.Units), want)
+	}
+
+	if want := []string{"db"}; !reflect.DeepEqual(cfg.UnitDependsOn["app"], want) {
+		t.Errorf("depends_on of app = %v, want %v", cfg.UnitDependsOn["app"], want)
+	}
+
+	if want := []string{"shared test", "local test"}; !reflect.DeepEqual(testNames(cfg.Tests), want) {
+		t.Errorf("tests = %v, want %v", testNames(cfg.Tests), want)
+	}
+
+	if !cfg.TestMetas["shared test"].HasTag("smoke") {
+		t.Error("included test lost its tags")
+	}
+
+	// Fixture files are relative to the file that defines them
+	payload := cfg.Fixtures[1].(*FixtureV1)
+	if want := filepath.Join(filepath.Dir(dir), "common"); payload.RelativePath != want {
+		t.Errorf("fixture relative path = %q, want %q", payload.RelativePath, want)
+	}
+}
+
+func TestExtendsOverridesNamedDefinitions(t *testing.T) {
+	cfg, _, err := loadSuiteConfig(t, map[string]string{
+		"base.yml": `
+kind: e2e_test:v1
+name: base
+target: app
+retries: 1
+fixtures:
+  - token: base-token
+  - user: alice
+units:
+  - name: db
+    kind: stub
+    image: postgres:15
+  - name: app
+    kind: stub
+    image: app:latest
+    depends_on: [db]
+tests:
+  - name: health
+    kind: stub
+`,
+		"suite.yml": `
+kind: e2e_test:v1
+name: users
+extends: base.yml
+fixtures:
+  - token: suite-token
+units:
+  - name: db
+    kind: stub
+    image: postgres:16
+tests:
+  - name: create user
+    kind: stub
+`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.TestName != "users" || cfg.TestTargetName != "app" || cfg.Retry.MaxRetries() != 1 {
+		t.Errorf("unexpected suite fields: name %q, target %q, retries %d", cfg.TestName, cfg.TestTargetName, cfg.Retry.MaxRetries())
+	}
+
+	if want := map[string]string{"db": "postgres:16", "app": "app:latest"}; !reflect.DeepEqual(unitImages(cfg.Units), want) {
+		t.Errorf("units = %v, want %v", unitImages(cfg.Units), want)
+	}
+
+	if cfg.Units[0].Name() != "db" {
+		t.Errorf("overridden unit must keep its position, got %s first", cfg.Units[0].Name())
+	}
+
+	fixtures := map[string]string{}
+	for _, fixture := range cfg.Fixtures {
+		fixtures[fixture.Name()] = string(fixture.Value())
+	}
+
+	if want := map[string]string{"token": "suite-token", "user": "alice"}; !reflect.DeepEqual(fixtures, want) {
+		t.Errorf("fixtures = %v, want %v", fixtures, want)
+	}
+
+	if want := []string{"health", "create user"}; !reflect.DeepEqual(testNames(cfg.Tests), want) {
+		t.Errorf("tests = %v, want %v", testNames(cfg.Tests), want)
+	}
+}
+
+func TestIncludeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr []string
+	}{
+		{
+			name: "conflicting unit",
+			files: map[string]string{
+				"suite.yml": `
+kind: e2e_test:v1
+name: users
+include: units.yml
+target: db
+units:
+  - name: db
+    kind: stub
+tests:
+  - name: a
+    kind: stub
+`,
+				"units.yml": `
+units:
+  - name: db
+    kind: stub
+`,
+			},
+			wantErr: []string{"duplicate unit name: db", "units.yml:3", "suite.yml:7"},
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"suite.yml": `
+kind: e2e_test:v1
+name: users
+include: a.yml
+`,
+				"a.yml": "include: b.yml\n",
+				"b.yml": "include: a.yml\n",
+			},
+			wantErr: []string{"include cycle at", "b.yml:1", "a.yml -> ", "b.yml -> ", "a.yml"},
+		},
+		{
+			name: "field that cannot be included",
+			files: map[string]string{
+				"suite.yml": `
+kind: e2e_test:v1
+name: users
+include: common.yml
+`,
+				"common.yml": "target: app\n",
+			},
+			wantErr: []string{"target at", "common.yml:1", "cannot be included"},
+		},
+		{
+			name: "missing file",
+			files: map[string]string{
+				"suite.yml": `
+kind: e2e_test:v1
+name: users
+extends: missing.yml
+`,
+			},
+			wantErr: []string{"missing.yml included at", "suite.yml:4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := loadSuiteConfig(t, tt.files)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			var detailedErr *DetailedError
+			if !errors.As(err, &detailedErr) {
+				t.Errorf("expected a DetailedError, got %T", err)
+			}
+
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err.Error(), want)
+				}
+			}
+		})
+	}
+}
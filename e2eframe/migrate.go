@@ -0,0 +1,414 @@
+package e2eframe
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// deprecatedSuiteFields maps the suite fields deprecated in e2e_test:v1, and removed
+// in e2e_test:v2, to the fields replacing them. The test_ names are those of the
+// runtime suite of earlier versions, while suite files used the names without prefix.
+var deprecatedSuiteFields = map[string]string{
+	"test_before_all":  "before_all",
+	"test_after_all":   "after_all",
+	"test_before_each": "before_each",
+	"test_after_each":  "after_each",
+}
+
+// deprecation is a deprecated field set by a suite or by its base suite.
+type deprecation struct {
+	field       string
+	replacement string
+	location    sourceLocation
+}
+
+// deprecate records a deprecated field of a suite, decoded as the field replacing it.
+// Setting both is an error, as one would silently override the other.
+func (t *TestSuiteConfigV1) deprecate(node, key *yaml.Node, replacement string) error {
+	if other, _ := mappingField(node, replacement); other != nil {
+		return &DetailedError{
+			Message: fmt.Sprintf(
+				"%s at %s and %s at line %d set the same field",
+				key.Value,
+				t.location(key),
+				replacement,
+				other.Line,
+			),
+			File:        t.SourceFile,
+			Line:        key.Line,
+			Suggestions: []string{fmt.Sprintf("Remove the deprecated %s", key.Value)},
+		}
+	}
+
+	t.deprecations = append(t.deprecations, deprecation{
+		field:       key.Value,
+		replacement: replacement,
+		location:    t.location(key),
+	})
+
+	return nil
+}
+
+// checkDeprecations rejects the deprecated fields of e2e_test:v2 suites and
+// reports those of older suites as warnings.
+func (t *TestSuiteConfigV1) checkDeprecations() error {
+	for _, deprecated := range t.deprecations {
+		if t.TestKind != ConfigKindE2ETest {
+			return &DetailedError{
+				Message: fmt.Sprintf(
+					"%s at %s was removed in %s, use %s",
+					deprecated.field,
+					deprecated.location,
+					t.TestKind,
+					deprecated.replacement,
+				),
+				File: deprecated.location.File,
+				Line: deprecated.location.Line,
+				Suggestions: []string{
+					fmt.Sprintf("Rename %s to %s", deprecated.field, deprecated.replacement),
+				},
+			}
+		}
+
+		t.Warnings = append(t.Warnings, fmt.Sprintf(
+			"%s: %s is deprecated, rename it to %s or run `ene migrate-config %s`",
+			deprecated.location,
+			deprecated.field,
+			deprecated.replacement,
+			deprecated.location.File,
+		))
+	}
+
+	return nil
+}
+
+// MigrateOpts configure MigrateConfig.
+type MigrateOpts struct {
+	DryRun bool // Report the changes without writing the files
+	// SuiteFiles are the name patterns of the suite files of a directory,
+	// `suite_files` of ene.yml or suite.yml if empty
+	SuiteFiles []string
+}
+
+// MigratedFile lists the changes made to a suite file by MigrateConfig.
+type MigratedFile struct {
+	Path    string
+	Changes []string
+}
+
+// MigrateConfig rewrites the suites of path, a suite file or a directory of suite
+// files, to LatestConfigKind and renames their deprecated fields. The files they
+// extend or include are migrated with them, since their deprecated fields would be
+// rejected once the suite is of the newest kind. Only the renamed keys and the kind
+// are rewritten, the rest of the files is kept as is. No file is written if one
+// cannot be migrated.
+func MigrateConfig(path string, opts *MigrateOpts) ([]MigratedFile, error) {
+	if opts == nil {
+		opts = &MigrateOpts{}
+	}
+
+	// A file is migrated whatever its name, e.g. a base suite of `extends:`
+	files := []string{path}
+
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		files, err = discoverTestSuites(path, LoadOptions{SuiteFiles: opts.SuiteFiles})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range files {
+		files[i] = filepath.Clean(files[i])
+	}
+
+	var (
+		migrated []MigratedFile
+		outputs  [][]byte
+	)
+
+	// Files extended or included by the files migrated are appended as they are found
+	for i := 0; i < len(files); i++ {
+		file := files[i]
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", file, err)
+		}
+
+		bases, err := referencedFiles(data, file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, base := range bases {
+			if !slices.Contains(files, base) {
+				files = append(files, base)
+			}
+		}
+
+		output, changes, err := migrateSuiteFile(data, file)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(changes) == 0 {
+			continue
+		}
+
+		migrated = append(migrated, MigratedFile{Path: file, Changes: changes})
+		outputs = append(outputs, output)
+	}
+
+	if opts.DryRun {
+		return migrated, nil
+	}
+
+	// Files are written once every file migrated, so that no suite is of the newest
+	// kind while a file it extends still has deprecated fields
+	for i, file := range migrated {
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("stat %s: %w", file.Path, err)
+		}
+
+		if err := os.WriteFile(file.Path, outputs[i], info.Mode().Perm()); err != nil {
+			return nil, fmt.Errorf("write %s: %w", file.Path, err)
+		}
+	}
+
+	return migrated, nil
+}
+
+// referencedFiles returns the files extended or included by the documents of a suite
+// file, resolved from its directory.
+func referencedFiles(data []byte, path string) ([]string, error) {
+	var files []string
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		var document yaml.Node
+
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, NewYAMLError(fmt.Sprintf("invalid YAML syntax: %s", err.Error()), path)
+		}
+
+		if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+
+		var pathNodes []*yaml.Node
+
+		if _, extends := mappingField(document.Content[0], "extends"); extends != nil {
+			pathNodes = append(pathNodes, extends)
+		}
+
+		if _, include := mappingField(document.Content[0], "include"); include != nil {
+			if include.Kind == yaml.SequenceNode {
+				pathNodes = append(pathNodes, include.Content...)
+			} else {
+				pathNodes = append(pathNodes, include)
+			}
+		}
+
+		for _, pathNode := range pathNodes {
+			// Invalid entries are reported when the suite is loaded
+			if pathNode.Kind != yaml.ScalarNode || pathNode.Value == "" {
+				continue
+			}
+
+			file := pathNode.Value
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(path), file)
+			}
+
+			files = append(files, filepath.Clean(file))
+		}
+	}
+
+	return files, nil
+}
+
+// migrateSuiteFile migrates every YAML document of a suite file, returning the
+// file and the changes made to it. Only the renamed keys and the kind are rewritten
+// in the original bytes, so comments, blank lines, indentation and quoting are kept.
+func migrateSuiteFile(data []byte, path string) ([]byte, []string, error) {
+	var (
+		edits   []nodeEdit
+		changes []string
+	)
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		var document yaml.Node
+
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, nil, NewYAMLError(fmt.Sprintf("invalid YAML syntax: %s", err.Error()), path)
+		}
+
+		if len(document.Content) > 0 && document.Content[0].Kind == yaml.MappingNode {
+			documentEdits, documentChanges, err := migrateSuiteDocument(document.Content[0], path)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			edits = append(edits, documentEdits...)
+			changes = append(changes, documentChanges...)
+		}
+	}
+
+	if len(changes) == 0 {
+		return data, nil, nil
+	}
+
+	output, err := applyNodeEdits(data, edits)
+	if err != nil {
+		return nil, nil, fmt.Errorf("migrate %s: %w", path, err)
+	}
+
+	return output, changes, nil
+}
+
+// nodeEdit replaces the value of a scalar node of a suite file.
+type nodeEdit struct {
+	node  *yaml.Node
+	value string
+}
+
+// applyNodeEdits rewrites the scalars of data at the positions of the edited nodes,
+// keeping their quotes.
+func applyNodeEdits(data []byte, edits []nodeEdit) ([]byte, error) {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+
+	lineStart := make([]int, len(lines))
+	for i := 1; i < len(lines); i++ {
+		lineStart[i] = lineStart[i-1] + len(lines[i-1])
+	}
+
+	type span struct {
+		start, end int
+		text       string
+	}
+
+	spans := make([]span, 0, len(edits))
+
+	for _, edit := range edits {
+		node := edit.node
+		if node.Line < 1 || node.Line > len(lines) {
+			return nil, fmt.Errorf("line %d of %s is out of the file", node.Line, node.Value)
+		}
+
+		// Columns count characters, not bytes
+		line := lines[node.Line-1]
+		column := 0
+
+		for i := 1; i < node.Column && column < len(line); i++ {
+			_, size := utf8.DecodeRune(line[column:])
+			column += size
+		}
+
+		quote := ""
+		switch node.Style {
+		case yaml.DoubleQuotedStyle:
+			quote = `"`
+		case yaml.SingleQuotedStyle:
+			quote = "'"
+		}
+
+		old := quote + node.Value + quote
+		if !bytes.HasPrefix(line[column:], []byte(old)) {
+			return nil, fmt.Errorf("%s at line %d is not written as a simple scalar, rename it by hand", node.Value, node.Line)
+		}
+
+		start := lineStart[node.Line-1] + column
+		spans = append(spans, span{start: start, end: start + len(old), text: quote + edit.value + quote})
+	}
+
+	// Later spans are replaced first, so that the offsets of earlier ones stay valid
+	slices.SortFunc(spans, func(a, b span) int { return b.start - a.start })
+
+	output := slices.Clone(data)
+	for _, s := range spans {
+		output = slices.Concat(output[:s.start], []byte(s.text), output[s.end:])
+	}
+
+	return output, nil
+}
+
+// migrateSuiteDocument returns the edits renaming the deprecated fields of a suite,
+// or of a base suite without `kind:`, and setting the kind of the suite to
+// LatestConfigKind, with a description of each change.
+func migrateSuiteDocument(root *yaml.Node, path string) ([]nodeEdit, []string, error) {
+	var (
+		edits   []nodeEdit
+		changes []string
+	)
+
+	location := func(node *yaml.Node) string {
+		return sourceLocation{File: filepath.Clean(path), Line: node.Line}.String()
+	}
+
+	_, kind := mappingField(root, "kind")
+	if kind != nil && !slices.Contains(ConfigKinds, ConfigKind(kind.Value)) {
+		return nil, nil, fmt.Errorf("unsupported test suite kind %q at %s", kind.Value, location(kind))
+	}
+
+	if kind != nil && kind.Value != string(LatestConfigKind) {
+		changes = append(changes, fmt.Sprintf("%s: kind %s -> %s", location(kind), kind.Value, LatestConfigKind))
+		edits = append(edits, nodeEdit{node: kind, value: string(LatestConfigKind)})
+	}
+
+	for i := 0; i < len(root.Content); i += 2 {
+		key := root.Content[i]
+
+		replacement, ok := deprecatedSuiteFields[key.Value]
+		if !ok {
+			continue
+		}
+
+		if other, _ := mappingField(root, replacement); other != nil {
+			return nil, nil, fmt.Errorf(
+				"%s at %s and %s at line %d set the same field, remove one of them",
+				key.Value,
+				location(key),
+				replacement,
+				other.Line,
+			)
+		}
+
+		changes = append(changes, fmt.Sprintf("%s: %s -> %s", location(key), key.Value, replacement))
+		edits = append(edits, nodeEdit{node: key, value: replacement})
+	}
+
+	return edits, changes, nil
+}
+
+// describeKinds lists the supported kinds of test suite configuration.
+func describeKinds() string {
+	kinds := make([]string, len(ConfigKinds))
+	for i, kind := range ConfigKinds {
+		kinds[i] = string(kind)
+	}
+
+	return strings.Join(kinds, ", ")
+}
@@ -0,0 +1,209 @@
+package e2eframe
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const deprecatedSuite = `
+kind: %s
+name: users
+# seeds the database
+test_before_all: ./seed.sh
+units:
+  - name: db
+    kind: stub
+target: db
+tests:
+  - name: list
+    kind: stub
+`
+
+func TestDeprecatedSuiteFields(t *testing.T) {
+	cfg, err := decodeProfileSuite(t, strings.Replace(deprecatedSuite, "%s", string(ConfigKindE2ETest), 1), "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.BeforeAll != "./seed.sh" {
+		t.Errorf("before_all = %q, want the deprecated test_before_all", cfg.BeforeAll)
+	}
+
+	want := "suite.yml:5: test_before_all is deprecated, rename it to before_all or run `ene migrate-config suite.yml`"
+	if len(cfg.Warnings) != 1 || cfg.Warnings[0] != want {
+		t.Errorf("warnings = %q, want %q", cfg.Warnings, want)
+	}
+
+	_, err = decodeProfileSuite(t, strings.Replace(deprecatedSuite, "%s", string(ConfigKindE2ETestV2), 1), "", nil)
+	if want := "test_before_all at suite.yml:5 was removed in e2e_test:v2, use before_all"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error containing %q, got %v", want, err)
+	}
+
+	both := strings.Replace(deprecatedSuite, "%s", string(ConfigKindE2ETest), 1) + "before_all: ./other.sh\n"
+	if _, err := decodeProfileSuite(t, both, "", nil); err == nil || !strings.Contains(err.Error(), "set the same field") {
+		t.Errorf("expected an error for both fields, got %v", err)
+	}
+}
+
+func TestMigrateSuiteFile(t *testing.T) {
+	src := `# Users suite
+kind: e2e_test:v1 # migrated by ene
+name: users
+test_after_each: ./reset.sh
+---
+# base suite without kind
+test_before_all: ./seed.sh
+---
+kind: e2e_test:v2
+name: orders
+`
+
+	output, changes, err := migrateSuiteFile([]byte(src), "suite.yml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `# Users suite
+kind: e2e_test:v2 # migrated by ene
+name: users
+after_each: ./reset.sh
+---
+# base suite without kind
+before_all: ./seed.sh
+---
+kind: e2e_test:v2
+name: orders
+`
+	if string(output) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", output, want)
+	}
+
+	wantChanges := []string{
+		"suite.yml:2: kind e2e_test:v1 -> e2e_test:v2",
+		"suite.yml:4: test_after_each -> after_each",
+		"suite.yml:7: test_before_all -> before_all",
+	}
+	if strings.Join(changes, "\n") != strings.Join(wantChanges, "\n") {
+		t.Errorf("changes = %q, want %q", changes, wantChanges)
+	}
+
+	// Only the renamed keys and the kind are rewritten, formatting is kept byte for byte
+	formatted := `# Users suite
+
+kind: "e2e_test:v1"   # quoted kind
+name: users
+
+test_before_all:    ./seed.sh   # seeds the database
+
+units:
+    - name: db
+      kind: stub
+      env: { NAME: 'users' }
+`
+
+	output, _, err = migrateSuiteFile([]byte(formatted), "suite.yml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want = strings.NewReplacer(`"e2e_test:v1"`, `"e2e_test:v2"`, "test_before_all:", "before_all:").Replace(formatted)
+	if string(output) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", output, want)
+	}
+
+	// Files of the newest kind are left untouched
+	latest := "kind: e2e_test:v2\n\nname: orders\n"
+	if output, changes, err := migrateSuiteFile([]byte(latest), "suite.yml"); err != nil || len(changes) > 0 || string(output) != latest {
+		t.Errorf("got %q, %q, %v, want the file unchanged", output, changes, err)
+	}
+
+	for _, tt := range []struct {
+		src     string
+		wantErr string
+	}{
+		{src: "kind: e2e_test:v9\n", wantErr: `unsupported test suite kind "e2e_test:v9" at suite.yml:1`},
+		{src: "test_before_all: a\nbefore_all: b\n", wantErr: "test_before_all at suite.yml:1 and before_all at line 2 set the same field"},
+	} {
+		if _, _, err := migrateSuiteFile([]byte(tt.src), "suite.yml"); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+		}
+	}
+}
+
+func TestMigrateConfigDryRun(t *testing.T) {
+	src := "kind: e2e_test:v1\nname: users\n"
+
+	dir := writeProjectFiles(t, map[string]string{"tests/users/suite.yml": src})
+	path := filepath.Join(dir, "tests", "users", "suite.yml")
+
+	migrated, err := MigrateConfig(dir, &MigrateOpts{DryRun: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(migrated) != 1 || migrated[0].Path != path {
+		t.Fatalf("migrated = %+v, want %s", migrated, path)
+	}
+
+	if data, err := os.ReadFile(path); err != nil || string(data) != src {
+		t.Errorf("file = %q, %v, want it unchanged by a dry run", data, err)
+	}
+
+	if _, err := MigrateConfig(path, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if data, err := os.ReadFile(path); err != nil || !strings.HasPrefix(string(data), "kind: e2e_test:v2\n") {
+		t.Errorf("file = %q, %v, want it migrated", data, err)
+	}
+}
+
+func TestMigrateConfigMigratesBaseSuites(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		"tests/users/suite.yml":   "kind: e2e_test:v1\nname: users\nextends: ../base.yml\ninclude: [../common/units.yml]\n",
+		"tests/orders/suite.yml":  "kind: e2e_test:v1\nname: orders\nextends: ../base.yml\n",
+		"tests/base.yml":          "# shared setup\ntest_before_all: ./seed.sh\n",
+		"tests/common/units.yml":  "units: []\n",
+		"tests/common/README.txt": "not a suite\n",
+	})
+
+	migrated, err := MigrateConfig(filepath.Join(dir, "tests"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var paths []string
+	for _, file := range migrated {
+		rel, err := filepath.Rel(dir, file.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+
+	// The base suite is migrated once, included files without changes are left untouched
+	want := []string{"tests/orders/suite.yml", "tests/users/suite.yml", "tests/base.yml"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("migrated = %v, want %v", paths, want)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "tests", "base.yml")); err != nil || string(data) != "# shared setup\nbefore_all: ./seed.sh\n" {
+		t.Errorf("base = %q, %v, want its deprecated field renamed", data, err)
+	}
+
+	// A base that cannot be read leaves the suites unmigrated
+	dir = writeProjectFiles(t, map[string]string{
+		"tests/users/suite.yml": "kind: e2e_test:v1\nname: users\nextends: ../missing.yml\n",
+	})
+
+	if _, err := MigrateConfig(filepath.Join(dir, "tests"), nil); err == nil || !strings.Contains(err.Error(), "missing.yml") {
+		t.Errorf("expected an error for the missing base suite, got %v", err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "tests", "users", "suite.yml")); err != nil || !strings.HasPrefix(string(data), "kind: e2e_test:v1\n") {
+		t.Errorf("suite = %q, %v, want it unchanged", data, err)
+	}
+}
@@ -1,8 +1,10 @@
 package e2eframe
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"html/template"
 	"io"
@@ -12,6 +14,7 @@ import (
 	"time"
 
 	"github.com/exapsy/ene/e2eframe/ui"
+	"golang.org/x/term"
 )
 
 type EventConsumer interface {
@@ -68,17 +71,28 @@ func NewStdoutHumanOutputProcessor(params StdoutHumanOutputProcessorParams) Outp
 		mode = ui.RenderModeVerbose
 	}
 
+	// Secret values are masked before reaching the output, so the terminal is detected here
+	isTTY := false
+	if f, ok := params.Output.(*os.File); ok {
+		isTTY = term.IsTerminal(int(f.Fd()))
+	}
+
+	output := params.Output
+	if output != nil {
+		output = NewMaskingWriter(output)
+	}
+
 	// Create modern renderer
 	renderer := ui.NewModernRenderer(ui.RendererConfig{
-		Writer: params.Output,
+		Writer: output,
 		Mode:   mode,
 		Pretty: params.Pretty,
 		Debug:  params.Debug,
-		IsTTY:  false, // Will be auto-detected
+		IsTTY:  isTTY,
 	})
 
 	proc := &StdoutHumanOutputProcessor{
-		Sink: params.Output,
+		Sink: output,
 
 		// Formatting options
 		Pretty:  params.Pretty,
@@ -106,7 +120,7 @@ func (p *StdoutHumanOutputProcessor) printf(format string, args ...any) {
 	if p.Sink != nil {
 		fmt.Fprintf(p.Sink, format, args...)
 	} else {
-		fmt.Printf(format, args...)
+		fmt.Print(MaskSecrets(fmt.Sprintf(format, args...)))
 	}
 }
 
@@ -197,6 +211,16 @@ func (p *StdoutHumanOutputProcessor) ConsumeEvent(event Event) error {
 			return p.renderer.RenderTestCompleted(testInfo)
 		}
 
+	case EventTestSkipped:
+		if testEvent, ok := event.(*TestEvent); ok {
+			testInfo := ui.TestInfo{
+				SuiteName:  testEvent.SuiteName(),
+				Name:       testEvent.TestName,
+				SkipReason: testEvent.Message(),
+			}
+			return p.renderer.RenderTestSkipped(testInfo)
+		}
+
 	case EventTestRetrying:
 		if testEvent, ok := event.(*TestRetryingEvent); ok {
 			testInfo := ui.TestInfo{
@@ -301,7 +325,6 @@ func (p *StdoutHumanOutputProcessor) Flush() error {
 	// Convert test secretary data to UI summary format
 	passedTests := p.testsSecretary.PassedTests()
 	failedTests := p.testsSecretary.FailedTests()
-	skippedTests := p.testsSecretary.SkippedTests()
 
 	// Convert to UI test info format
 	passedInfos := make([]ui.TestInfo, len(passedTests))
@@ -356,7 +379,7 @@ func (p *StdoutHumanOutputProcessor) Flush() error {
 		TotalTests:        len(p.testsSecretary.CompletedTests()),
 		PassedTests:       passedInfos,
 		FailedTests:       failedInfos,
-		SkippedTests:      len(skippedTests),
+		SkippedTests:      p.testsSecretary.TotalSkippedTests(),
 		ContainerTime:     containerTime,
 		TestExecutionTime: testTime,
 	}
@@ -434,6 +457,7 @@ func (p *HTMLReportProcessor) Flush() error {
 		"PassedTests":   p.testsSecretary.PassedTests(),
 		"FailedTests":   p.testsSecretary.FailedTests(),
 		"SkippedSuites": p.testsSecretary.SkippedTests(),
+		"SkippedTests":  p.testsSecretary.SkippedTestEvents(),
 		"TotalTests":    len(p.testsSecretary.CompletedTests()),
 		"TotalPassed":   p.testsSecretary.TotalPassedTests(),
 		"TotalFailed":   p.testsSecretary.TotalFailedTests(),
@@ -454,6 +478,7 @@ func (p *HTMLReportProcessor) Flush() error {
 	}
 
 	templateData["TestsBySuite"] = testsBySuite
+	templateData["GeneratedFixtures"] = p.testsSecretary.GeneratedFixtures()
 
 	// Create function map BEFORE parsing the template
 	funcMap := template.FuncMap{
@@ -469,10 +494,16 @@ func (p *HTMLReportProcessor) Flush() error {
 		return fmt.Errorf("parse template: %w", err)
 	}
 
-	if err := tmpl.Execute(file, templateData); err != nil {
+	// The report is rendered whole, so that the values of secret fixtures are masked in one piece
+	var report bytes.Buffer
+	if err := tmpl.Execute(&report, templateData); err != nil {
 		return fmt.Errorf("execute template: %w", err)
 	}
 
+	if _, err := file.WriteString(MaskSecrets(report.String())); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
 	fmt.Printf("HTML report generated: %s\n", p.OutputFile)
 
 	return nil
@@ -545,7 +576,11 @@ func (p *JSONReportProcessor) Flush() error {
 	// Group tests by suite
 	testsBySuite := make(map[string][]TestEvent)
 
-	for _, test := range p.testsSecretary.CompletedTests() {
+	var testEvents []TestEvent
+	testEvents = append(testEvents, p.testsSecretary.CompletedTests()...)
+	testEvents = append(testEvents, p.testsSecretary.SkippedTestEvents()...)
+
+	for _, test := range testEvents {
 		suite := test.SuiteName()
 		if suite == "" {
 			suite = "Unknown Suite"
@@ -558,10 +593,12 @@ func (p *JSONReportProcessor) Flush() error {
 	suites := []map[string]interface{}{}
 
 	for suiteName, tests := range testsBySuite {
-		passCount := 0
+		passCount, skipCount := 0, 0
 
 		for _, test := range tests {
-			if test.Passed {
+			if test.Type() == EventTestSkipped {
+				skipCount++
+			} else if test.Passed {
 				passCount++
 			}
 		}
@@ -570,10 +607,37 @@ func (p *JSONReportProcessor) Flush() error {
 			"name":       suiteName,
 			"totalTests": len(tests),
 			"passed":     passCount,
-			"failed":     len(tests) - passCount,
+			"failed":     len(tests) - passCount - skipCount,
+			"skipped":    skipCount,
 			"tests":      []map[string]interface{}{},
 		}
 
+		// Used by --shard-durations to balance the shards of the next runs
+		if duration, ok := p.testsSecretary.SuiteDurations()[suiteName]; ok {
+			suiteData["durationMs"] = duration.Milliseconds()
+		}
+
+		// Generated values, to reproduce a failing run
+		if generated := p.testsSecretary.GeneratedFixtures()[suiteName]; len(generated) > 0 {
+			fixtures := make([]map[string]interface{}, 0, len(generated))
+
+			for _, fixture := range generated {
+				fixtureData := map[string]interface{}{
+					"name":      fixture.Name,
+					"value":     fixture.Value,
+					"generator": fixture.Generator,
+				}
+
+				if fixture.Seed != nil {
+					fixtureData["seed"] = *fixture.Seed
+				}
+
+				fixtures = append(fixtures, fixtureData)
+			}
+
+			suiteData["generatedFixtures"] = fixtures
+		}
+
 		testItems := []map[string]interface{}{}
 
 		for _, test := range tests {
@@ -583,10 +647,17 @@ func (p *JSONReportProcessor) Flush() error {
 				"duration": test.Duration.Milliseconds(),
 			}
 
-			if !test.Passed {
+			if test.Type() == EventTestSkipped {
+				testData["skipped"] = true
+				testData["message"] = test.Message()
+			} else if !test.Passed {
 				testData["message"] = test.Message()
 			}
 
+			if test.TimedOut {
+				testData["timedOut"] = true
+			}
+
 			testItems = append(testItems, testData)
 		}
 
@@ -610,14 +681,210 @@ func (p *JSONReportProcessor) Flush() error {
 	jsonData["skippedSuites"] = skippedSuites
 
 	// Encode as JSON with indentation for readability
-	encoder := json.NewEncoder(file)
+	out := NewMaskingWriter(file)
+
+	encoder := json.NewEncoder(out)
 	encoder.SetIndent("", "  ")
 
 	if err := encoder.Encode(jsonData); err != nil {
 		return fmt.Errorf("encode JSON: %w", err)
 	}
 
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
 	fmt.Printf("JSON report generated: %s\n", p.OutputFile)
 
 	return nil
 }
+
+// JUnitReportProcessor generates a JUnit XML report of test results, read by most CI servers.
+type JUnitReportProcessor struct {
+	// File where the JUnit report will be written
+	OutputFile string
+	// Used to track tests and their statuses
+	testsSecretary *TestsSecretary
+}
+
+type JUnitReportProcessorParams struct {
+	// Path where the JUnit report will be written
+	OutputFile string
+	// Test secretary to track test execution
+	TestsSecretary *TestsSecretary
+}
+
+// NewJUnitReportProcessor creates a new JUnitReportProcessor.
+func NewJUnitReportProcessor(params JUnitReportProcessorParams) (OutputProcessor, error) {
+	// Create output directory if it doesn't exist
+	dir := filepath.Dir(params.OutputFile)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create output directory: %w", err)
+	}
+
+	return &JUnitReportProcessor{
+		OutputFile:     params.OutputFile,
+		testsSecretary: params.TestsSecretary,
+	}, nil
+}
+
+// ConsumeEvent collects test events (no direct action needed as TestsSecretary handles it).
+func (p *JUnitReportProcessor) ConsumeEvent(event Event) error {
+	// The testsSecretary already collects all the events we need
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitSeconds formats a duration as the seconds of the JUnit `time` attributes.
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// Flush generates the JUnit report and writes it to the output file.
+func (p *JUnitReportProcessor) Flush() error {
+	// Create the output file
+	file, err := os.Create(p.OutputFile)
+	if err != nil {
+		return fmt.Errorf("create output file: %w", err)
+	}
+	defer file.Close()
+
+	var testEvents []TestEvent
+	testEvents = append(testEvents, p.testsSecretary.CompletedTests()...)
+	testEvents = append(testEvents, p.testsSecretary.SkippedTestEvents()...)
+
+	// Group tests by suite, in the order the suites finished
+	var suiteNames []string
+
+	testsBySuite := make(map[string][]TestEvent)
+
+	for _, test := range testEvents {
+		suite := test.SuiteName()
+		if suite == "" {
+			suite = "Unknown Suite"
+		}
+
+		if _, ok := testsBySuite[suite]; !ok {
+			suiteNames = append(suiteNames, suite)
+		}
+
+		testsBySuite[suite] = append(testsBySuite[suite], test)
+	}
+
+	report := junitTestSuites{Time: junitSeconds(time.Since(p.testsSecretary.StartTime()))}
+
+	for _, suiteName := range suiteNames {
+		suite := junitTestSuite{Name: suiteName}
+
+		var suiteTime time.Duration
+
+		for _, test := range testsBySuite[suiteName] {
+			testCase := junitTestCase{
+				Name:      test.TestName,
+				ClassName: suiteName,
+				Time:      junitSeconds(test.Duration),
+			}
+
+			switch {
+			case test.Type() == EventTestSkipped:
+				testCase.Skipped = &junitMessage{Message: test.Message()}
+				suite.Skipped++
+			case !test.Passed:
+				testCase.Failure = &junitMessage{Message: firstLine(test.Message()), Text: test.Message()}
+				suite.Failures++
+			}
+
+			suiteTime += test.Duration
+			suite.Cases = append(suite.Cases, testCase)
+		}
+
+		// The duration of the whole suite includes starting its units
+		if duration, ok := p.testsSecretary.SuiteDurations()[suiteName]; ok {
+			suiteTime = duration
+		}
+
+		suite.Tests = len(suite.Cases)
+		suite.Time = junitSeconds(suiteTime)
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+
+	// Skipped suites are reported as a single skipped test, so that CI servers list them
+	for _, skipped := range p.testsSecretary.SkippedTests() {
+		report.Tests++
+		report.Skipped++
+		report.Suites = append(report.Suites, junitTestSuite{
+			Name:    skipped.SuiteName(),
+			Tests:   1,
+			Skipped: 1,
+			Time:    junitSeconds(0),
+			Cases: []junitTestCase{{
+				Name:      skipped.SuiteName(),
+				ClassName: skipped.SuiteName(),
+				Time:      junitSeconds(0),
+				Skipped:   &junitMessage{Message: skipped.Message()},
+			}},
+		})
+	}
+
+	out := NewMaskingWriter(file)
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("encode JUnit XML: %w", err)
+	}
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	fmt.Printf("JUnit report generated: %s\n", p.OutputFile)
+
+	return nil
+}
+
+// firstLine returns the first line of a multi-line message.
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+
+	return line
+}
@@ -0,0 +1,335 @@
+package e2eframe
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// suiteProfile holds the overrides of a profile of suite.yml, selected with --profile.
+type suiteProfile struct {
+	name string
+	// fixtures replace the suite fixtures of the same name
+	fixtures *yaml.Node
+	// images replace the images of the units, as `images:` of ene.yml
+	images map[string]string
+	// timeout replaces the timeout of the suite
+	timeout time.Duration
+	// testTimeout replaces the timeout of every test
+	testTimeout time.Duration
+	// skip and unskip are the names of the tests skipped, or no longer skipped, by the profile
+	skip   []string
+	unskip []string
+}
+
+// takeProfiles removes `profiles:` from the root of a suite or ene.yml, returning the
+// root without it, the profile called name, nil if it is not defined, and the
+// names of all profiles.
+func takeProfiles(root *yaml.Node, name string) (*yaml.Node, *yaml.Node, []string, error) {
+	key, profiles := mappingField(root, "profiles")
+	if profiles == nil {
+		return root, nil, nil, nil
+	}
+
+	if profiles.Kind != yaml.MappingNode {
+		return nil, nil, nil, fmt.Errorf("profiles at line %d must be a mapping of profile names to their settings", key.Line)
+	}
+
+	var (
+		selected *yaml.Node
+		names    []string
+	)
+
+	for i := 0; i < len(profiles.Content); i += 2 {
+		profileName, profile := profiles.Content[i], profiles.Content[i+1]
+
+		// An empty profile, e.g. `local:`, declares the profile without overriding anything
+		if profile.Tag == "!!null" {
+			profile = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: profile.Line}
+		}
+
+		if profile.Kind != yaml.MappingNode {
+			return nil, nil, nil, fmt.Errorf("profile %s at line %d must be a mapping", profileName.Value, profileName.Line)
+		}
+
+		names = append(names, profileName.Value)
+
+		if profileName.Value == name {
+			selected = profile
+		}
+	}
+
+	return withoutField(root, "profiles"), selected, names, nil
+}
+
+// profileEnv reads the `env:` of the profile called name of a parsed suite or ene.yml.
+// These values are substituted for `${VAR}` before the file is, so they are taken literally.
+func profileEnv(document *yaml.Node, name string) (map[string]string, error) {
+	if name == "" || len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	_, profile, _, err := takeProfiles(document.Content[0], name)
+	if err != nil || profile == nil {
+		return nil, err
+	}
+
+	key, value := mappingField(profile, "env")
+	if value == nil {
+		return nil, nil
+	}
+
+	var env map[string]string
+	if err := value.Decode(&env); err != nil {
+		return nil, fmt.Errorf("could not decode env of profile %s at line %d: %w", name, key.Line, err)
+	}
+
+	return env, nil
+}
+
+// CheckProfile returns an error when --profile selects a profile that neither ene.yml
+// nor any of the suites defines. A suite without the profile runs without overrides,
+// so that a profile may only concern some of the suites.
+func CheckProfile(name string, testSuites []TestSuite) error {
+	if name == "" || len(testSuites) == 0 {
+		return nil
+	}
+
+	var (
+		defined []string
+		files   []string
+	)
+
+	for _, testSuite := range testSuites {
+		suite, ok := testSuite.(*TestSuiteV1)
+		if !ok {
+			continue
+		}
+
+		if slices.Contains(suite.Profiles, name) {
+			return nil
+		}
+
+		defined = append(defined, suite.Profiles...)
+
+		if !slices.Contains(files, suite.SourceFile) {
+			files = append(files, suite.SourceFile)
+		}
+	}
+
+	// Suites of other kinds do not report their profiles
+	if len(files) == 0 {
+		return nil
+	}
+
+	return undefinedProfileError(name, files, defined)
+}
+
+// undefinedProfileError is returned when --profile selects a profile that neither ene.yml nor the suites define.
+func undefinedProfileError(name string, files, defined []string) error {
+	slices.Sort(defined)
+	defined = slices.Compact(defined)
+
+	where := files[0]
+	if len(files) > 1 {
+		where = fmt.Sprintf("any of the %d suite files", len(files))
+	}
+
+	err := &DetailedError{
+		Message: fmt.Sprintf("profile %q is not defined in %s or in %s", name, ProjectConfigFile, where),
+		File:    files[0],
+		Suggestions: []string{
+			"Define the profile under `profiles:` of a suite, or of ene.yml to select it for every suite",
+		},
+		Examples: []string{"profiles:\n  " + name + ":\n    timeout: 2m"},
+	}
+
+	if len(defined) > 0 {
+		err.Suggestions = append(err.Suggestions, "Defined profiles: "+strings.Join(defined, ", "))
+	}
+
+	return err
+}
+
+// takeProfile removes `profiles:` from the root of the suite and decodes the profile
+// selected with --profile. The images of the profile are replaced in the units,
+// its other overrides are applied by applyProfile once the suite is decoded.
+// A suite without the selected profile has no overrides, CheckProfile reports a
+// profile that no suite defines.
+func (t *TestSuiteConfigV1) takeProfile(node *yaml.Node) (*yaml.Node, *suiteProfile, error) {
+	if node.Kind != yaml.MappingNode {
+		return node, nil, nil
+	}
+
+	root, selected, names, err := takeProfiles(node, t.profile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t.profiles = names
+
+	if selected == nil {
+		return root, nil, nil
+	}
+
+	profile, err := t.decodeProfile(selected)
+	if err != nil {
+		return nil, nil, fmt.Errorf("profile %s: %w", t.profile, err)
+	}
+
+	overrideUnitImages(root, profile.images)
+
+	return root, profile, nil
+}
+
+// profileNames returns the names of the profiles of the suite and of its ene.yml.
+func (t *TestSuiteConfigV1) profileNames() []string {
+	var names []string
+	if t.project != nil {
+		names = append(names, t.project.Profiles...)
+	}
+
+	names = append(names, t.profiles...)
+	slices.Sort(names)
+
+	return slices.Compact(names)
+}
+
+// decodeProfile reads the overrides of a suite profile, rejecting unknown fields.
+func (t *TestSuiteConfigV1) decodeProfile(node *yaml.Node) (*suiteProfile, error) {
+	profile := &suiteProfile{name: t.profile}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		value := node.Content[i+1]
+
+		var err error
+
+		switch key.Value {
+		case "env":
+			// Already substituted for ${VAR} when the suite was read
+		case "fixtures":
+			profile.fixtures = value
+		case "images":
+			err = value.Decode(&profile.images)
+		case "skip":
+			err = value.Decode(&profile.skip)
+		case "unskip":
+			err = value.Decode(&profile.unskip)
+		case "timeout", "test_timeout":
+			timeout, err := decodeTimeout(key, value)
+			if err != nil {
+				return nil, err
+			}
+
+			if key.Value == "timeout" {
+				profile.timeout = timeout
+			} else {
+				profile.testTimeout = timeout
+			}
+		default:
+			return nil, fmt.Errorf("unknown field %s at %s, profiles can set env, fixtures, images, timeout, test_timeout, skip and unskip", key.Value, t.location(key))
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not decode %s at line %d: %w", key.Value, key.Line, err)
+		}
+	}
+
+	return profile, nil
+}
+
+// applyProfile overrides the decoded suite with the fixtures, timeouts and skipped tests of its profile.
+func (t *TestSuiteConfigV1) applyProfile(profile *suiteProfile) error {
+	if profile == nil {
+		return nil
+	}
+
+	if profile.fixtures != nil {
+		err := t.decodeFixtures(profile.fixtures, func(fixture Fixture, location sourceLocation) error {
+			if t.definitions == nil {
+				t.definitions = make(map[string]definition)
+			}
+
+			t.definitions["fixture:"+fixture.Name()] = definition{location: location}
+
+			index := slices.IndexFunc(t.Fixtures, func(f Fixture) bool { return f.Name() == fixture.Name() })
+			if index >= 0 {
+				t.Fixtures[index] = fixture
+			} else {
+				t.Fixtures = append(t.Fixtures, fixture)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("profile %s: %w", profile.name, err)
+		}
+	}
+
+	if profile.timeout > 0 {
+		t.Timeout = profile.timeout
+	}
+
+	if profile.testTimeout > 0 {
+		for name, meta := range t.TestMetas {
+			meta.Timeout = profile.testTimeout
+			t.TestMetas[name] = meta
+		}
+	}
+
+	reason := fmt.Sprintf("skipped by profile %s", profile.name)
+
+	for _, skip := range []struct {
+		field  string
+		names  []string
+		reason string
+	}{{"skip", profile.skip, reason}, {"unskip", profile.unskip, ""}} {
+		for _, name := range skip.names {
+			matched := false
+
+			for testName, meta := range t.TestMetas {
+				// A test with cases is selected by its name, without the case suffix
+				if testName == name || strings.HasPrefix(testName, name+" [") {
+					meta.Skip = skip.reason
+					t.TestMetas[testName] = meta
+					matched = true
+				}
+			}
+
+			if !matched {
+				return fmt.Errorf("unknown test %q in %s of profile %s", name, skip.field, profile.name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// overrideUnitImages replaces the `image:` of the units of a suite or included file
+// with the image it is mapped to.
+func overrideUnitImages(root *yaml.Node, images map[string]string) {
+	if len(images) == 0 || root.Kind != yaml.MappingNode {
+		return
+	}
+
+	_, units := mappingField(root, "units")
+	if units == nil || units.Kind != yaml.SequenceNode {
+		return
+	}
+
+	for _, unit := range units.Content {
+		if unit.Kind != yaml.MappingNode {
+			continue
+		}
+
+		if _, image := mappingField(unit, "image"); image != nil && image.Kind == yaml.ScalarNode {
+			if override, ok := images[image.Value]; ok {
+				image.Value = override
+			}
+		}
+	}
+}
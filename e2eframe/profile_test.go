@@ -0,0 +1,322 @@
+package e2eframe
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const profileSuite = `
+kind: e2e_test:v1
+name: users
+timeout: 10m
+fixtures:
+  - base_url: http://localhost
+  - token: local-token
+units:
+  - name: db
+    kind: stub
+    image: postgres:16
+target: db
+tests:
+  - name: list
+    kind: stub
+    timeout: 1m
+  - name: slow
+    kind: stub
+    cases:
+      - size: "1"
+      - size: "2"
+  - name: flaky
+    kind: stub
+    skip: investigating
+profiles:
+  local:
+    images:
+      postgres:16: postgres:16-alpine
+  ci:
+    fixtures:
+      - base_url: http://app:8080
+      - run_id: "42"
+    images:
+      postgres:16: postgres:16.4
+    timeout: 2m
+    test_timeout: 10s
+    skip:
+      - slow
+    unskip:
+      - flaky
+`
+
+// decodeProfileSuite decodes a suite with the profile selected with --profile.
+func decodeProfileSuite(t *testing.T, src, profile string, project *ProjectConfig) (*TestSuiteConfigV1, error) {
+	t.Helper()
+
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &document); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &TestSuiteConfigV1{SourceFile: "suite.yml", project: project, profile: profile}
+
+	return cfg, document.Decode(cfg)
+}
+
+func TestSuiteProfileOverridesSuite(t *testing.T) {
+	cfg, err := decodeProfileSuite(t, profileSuite, "ci", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := unitImages(cfg.Units)["db"]; got != "postgres:16.4" {
+		t.Errorf("db image = %q, want the pinned image of the profile", got)
+	}
+
+	values := make(map[string]string)
+	for _, fixture := range cfg.Fixtures {
+		values[fixture.Name()] = string(fixture.Value())
+	}
+
+	if values["base_url"] != "http://app:8080" || values["token"] != "local-token" || values["run_id"] != "42" {
+		t.Errorf("fixtures = %v, want the profile fixtures over the suite fixtures", values)
+	}
+
+	if cfg.Timeout != 2*time.Minute || cfg.TestMetas["list"].Timeout != 10*time.Second {
+		t.Errorf("timeouts = %v, %v, want the profile timeouts", cfg.Timeout, cfg.TestMetas["list"].Timeout)
+	}
+
+	for _, name := range []string{"slow [size=1]", "slow [size=2]"} {
+		if got := cfg.TestMetas[name].Skip; got != "skipped by profile ci" {
+			t.Errorf("%s skip = %q, want skipped by the profile", name, got)
+		}
+	}
+
+	if got := cfg.TestMetas["flaky"].Skip; got != "" {
+		t.Errorf("flaky skip = %q, want it run by the profile", got)
+	}
+}
+
+func TestSuiteWithoutProfileIgnoresProfiles(t *testing.T) {
+	cfg, err := decodeProfileSuite(t, profileSuite, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := unitImages(cfg.Units)["db"]; got != "postgres:16" {
+		t.Errorf("db image = %q, want the suite image", got)
+	}
+
+	if cfg.Timeout != 10*time.Minute || cfg.TestMetas["flaky"].Skip != "investigating" {
+		t.Errorf("suite = %+v, want its own timeout and skipped tests", cfg)
+	}
+}
+
+func TestSuiteProfileImagesAreMirroredByProject(t *testing.T) {
+	project := &ProjectConfig{Images: map[string]string{"postgres:16-alpine": "mirror.local/postgres:16-alpine"}}
+
+	cfg, err := decodeProfileSuite(t, profileSuite, "local", project)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := unitImages(cfg.Units)["db"]; got != "mirror.local/postgres:16-alpine" {
+		t.Errorf("db image = %q, want the profile image from the ene.yml mirror", got)
+	}
+}
+
+func TestSuiteProfileErrors(t *testing.T) {
+	suite := func(profile string) string {
+		return `
+kind: e2e_test:v1
+name: users
+units:
+  - name: db
+    kind: stub
+target: db
+tests:
+  - name: list
+    kind: stub
+profiles:
+  ci:
+` + profile + "\n"
+	}
+
+	tests := []struct {
+		name    string
+		suite   string
+		profile string
+		wantErr string
+	}{
+		{name: "unknown field", suite: suite("    retries: 1"), profile: "ci", wantErr: "unknown field retries at suite.yml:13"},
+		{name: "unknown skipped test", suite: suite("    skip: [lsit]"), profile: "ci", wantErr: `unknown test "lsit" in skip of profile ci`},
+		{name: "invalid timeout", suite: suite("    test_timeout: soon"), profile: "ci", wantErr: `profile ci: invalid timeout "soon" at line 13`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeProfileSuite(t, tt.suite, tt.profile, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// A suite that does not define the selected profile runs without overrides
+	cfg, err := decodeProfileSuite(t, suite("    timeout: 1m"), "staging", nil)
+	if err != nil {
+		t.Errorf("unexpected error for a profile of another suite: %v", err)
+	} else if cfg.Timeout != 0 || !slices.Equal(cfg.profileNames(), []string{"ci"}) {
+		t.Errorf("timeout = %v, profiles = %v, want no overrides and the ci profile", cfg.Timeout, cfg.profileNames())
+	}
+}
+
+func TestCheckProfile(t *testing.T) {
+	suites := []TestSuite{
+		&TestSuiteV1{TestName: "users", SourceFile: "users/suite.yml", Profiles: []string{"ci", "local"}},
+		&TestSuiteV1{TestName: "orders", SourceFile: "orders/suite.yml"},
+	}
+
+	// A profile defined by one suite or by ene.yml may be selected for every suite
+	if err := CheckProfile("local", suites); err != nil {
+		t.Errorf("unexpected error for a profile of one suite: %v", err)
+	}
+
+	if err := CheckProfile("", suites); err != nil {
+		t.Errorf("unexpected error without a profile: %v", err)
+	}
+
+	err := CheckProfile("staging", suites)
+	if err == nil || !strings.Contains(err.Error(), `profile "staging" is not defined in ene.yml or in any of the 2 suite files`) {
+		t.Fatalf("expected an undefined profile error, got %v", err)
+	}
+
+	var detailed *DetailedError
+	if !errors.As(err, &detailed) || !slices.Contains(detailed.Suggestions, "Defined profiles: ci, local") {
+		t.Errorf("expected the defined profiles to be suggested, got %+v", err)
+	}
+
+	err = CheckProfile("staging", suites[1:])
+	if err == nil || !strings.Contains(err.Error(), `profile "staging" is not defined in ene.yml or in orders/suite.yml`) {
+		t.Errorf("expected an undefined profile error for the suite file, got %v", err)
+	}
+}
+
+func TestProjectProfileOverridesProject(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		"ene.yml": `
+retries: 3
+test_timeout: 1m
+reports:
+  html: build/report.html
+fixtures:
+  - base_url: http://localhost
+  - token: local-token
+images:
+  mongo:6: mongo:6
+profiles:
+  local:
+    verbose: true
+  ci:
+    env:
+      MONGO_TAG: 6.0.14
+    retries: 0
+    test_timeout: 10s
+    reports:
+      junit: build/junit.xml
+    fixtures:
+      - base_url: http://app:8080
+    images:
+      mongo:6: mongo:${MONGO_TAG}
+`,
+	})
+
+	project, err := LoadProjectConfig(dir, "ci")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if *project.Retry.Retries != 0 || project.TestTimeout != 10*time.Second || project.Verbose != nil {
+		t.Errorf("project = %+v, want the ci settings only", project)
+	}
+
+	if project.HTMLReport != "build/report.html" || project.JUnitReport != "build/junit.xml" {
+		t.Errorf("reports = %q, %q, want the html report and the junit report of the profile", project.HTMLReport, project.JUnitReport)
+	}
+
+	if got := project.Images["mongo:6"]; got != "mongo:6.0.14" {
+		t.Errorf("mongo image = %q, want the image pinned with the env of the profile", got)
+	}
+
+	if got := project.Env()["MONGO_TAG"]; got != "6.0.14" {
+		t.Errorf("env = %v, want the env of the profile for the suites", project.Env())
+	}
+
+	fixtures, err := project.Fixtures()
+	if err != nil {
+		t.Fatalf("fixtures: %v", err)
+	}
+
+	values := make(map[string]string)
+	for _, fixture := range fixtures {
+		values[fixture.Name()] = string(fixture.Value())
+	}
+
+	if len(fixtures) != 2 || values["base_url"] != "http://app:8080" || values["token"] != "local-token" {
+		t.Errorf("fixtures = %v, want the profile base_url and the project token", values)
+	}
+
+	if !slices.Equal(project.Profiles, []string{"local", "ci"}) {
+		t.Errorf("profiles = %v, want local and ci", project.Profiles)
+	}
+}
+
+func TestJUnitReport(t *testing.T) {
+	secretary := NewTestsSecretary(nil)
+
+	events := []Event{
+		&TestEvent{BaseEvent: BaseEvent{EventType: EventTestCompleted, Suite: "users"}, TestName: "list", Passed: true, Duration: 1500 * time.Millisecond},
+		&TestEvent{BaseEvent: BaseEvent{EventType: EventTestCompleted, Suite: "users", EventMessage: "status 500 <> 200\nbody: {}"}, TestName: "create", Duration: time.Second},
+		&TestEvent{BaseEvent: BaseEvent{EventType: EventTestSkipped, Suite: "users", EventMessage: "skipped by profile ci"}, TestName: "slow"},
+	}
+
+	for _, event := range events {
+		if err := secretary.ConsumeEvent(event); err != nil {
+			t.Fatalf("consume event: %v", err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "reports", "junit.xml")
+
+	processor, err := NewJUnitReportProcessor(JUnitReportProcessorParams{OutputFile: path, TestsSecretary: secretary})
+	if err != nil {
+		t.Fatalf("new processor: %v", err)
+	}
+
+	if err := processor.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := string(data)
+
+	for _, want := range []string{
+		`<testsuite name="users" tests="3" failures="1" skipped="1" time="2.500">`,
+		`<testcase name="list" classname="users" time="1.500"></testcase>`,
+		`<failure message="status 500 &lt;&gt; 200">status 500 &lt;&gt; 200&#xA;body: {}</failure>`,
+		`<skipped message="skipped by profile ci"></skipped>`,
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report does not contain %s:\n%s", want, report)
+		}
+	}
+}
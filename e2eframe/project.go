@@ -0,0 +1,407 @@
+package e2eframe
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ProjectConfigFile is the project configuration, looked up from the base directory upwards.
+	ProjectConfigFile = "ene.yml"
+	// DefaultLogDir is the directory of the saved container logs, relative to the project.
+	DefaultLogDir = ".ene"
+)
+
+// ProjectRootMarkers mark the project root of projects without ene.yml: the closest
+// directory above the suites containing one of them.
+var ProjectRootMarkers = []string{"go.mod", ".git"}
+
+// LoadOptions select how suites are loaded.
+type LoadOptions struct {
+	// Profile is the profile selected with --profile, empty for none
+	Profile string
+	// Root is the project root given with --root, detected from the suites when empty
+	Root string
+	// SuiteFiles are the name patterns of the suite files given with --suite-files,
+	// `suite_files` of ene.yml or suite.yml when empty
+	SuiteFiles []string
+}
+
+// ProjectRoot returns the project root of the suites of dir: root when set, else the
+// directory of ene.yml, else the closest directory from dir upwards containing one of
+// ProjectRootMarkers, else the current directory.
+func ProjectRoot(dir, root string, project *ProjectConfig) (string, error) {
+	if root != "" {
+		return filepath.Abs(root)
+	}
+
+	if project != nil {
+		return project.Dir, nil
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+
+	for current := dir; ; current = filepath.Dir(current) {
+		for _, marker := range ProjectRootMarkers {
+			if _, err := os.Stat(filepath.Join(current, marker)); err == nil {
+				return current, nil
+			}
+		}
+
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+
+	return os.Getwd()
+}
+
+// ProjectConfig holds the project defaults of ene.yml. Command line flags override them,
+// and suites and tests override the defaults of their timeouts, retries and fixtures.
+type ProjectConfig struct {
+	// Path is the path of the ene.yml file
+	Path string
+	// Dir is the project root, the directory of the ene.yml file
+	Dir string
+	// TestsDir is the directory of the test suites (`tests_dir`, default ./tests)
+	TestsDir string
+	// SuiteFiles are the name patterns of the suite files (`suite_files`, default suite.yml)
+	SuiteFiles []string
+	// LogDir is the directory of the saved container logs (`log_dir`, default .ene under the project root)
+	LogDir string
+	// Retry is the default retry policy (`retries`, `retry_delay`, `retry_backoff`, `retry_on`)
+	Retry RetryPolicy
+	// Verbose enables detailed logs (`verbose`)
+	Verbose *bool
+	// Parallel runs the suites in parallel (`parallel`)
+	Parallel *bool
+	// Jobs is the maximum number of suites or parallel tests run at once (`jobs`)
+	Jobs *int
+	// Timeout is the deadline of the whole run (`timeout`)
+	Timeout time.Duration
+	// SuiteTimeout is the deadline of the suites that set no `timeout` (`suite_timeout`)
+	SuiteTimeout time.Duration
+	// TestTimeout is the deadline of every attempt of the tests that set no `timeout` (`test_timeout`)
+	TestTimeout time.Duration
+	// HTMLReport, JSONReport and JUnitReport are the paths of the reports (`reports: { html, json, junit }`)
+	HTMLReport  string
+	JSONReport  string
+	JUnitReport string
+	// Images replaces the images of the units, e.g. to pull them from a mirror (`images`)
+	Images map[string]string
+	// Profile is the profile selected with --profile, empty for none
+	Profile string
+	// Profiles are the names of the profiles of ene.yml (`profiles`)
+	Profiles []string
+
+	// fixtures are decoded for every suite, so that suites do not share resolved values.
+	// The fixtures of the selected profile come last and override the others.
+	fixtures []*yaml.Node
+	// env holds the `env:` of the selected profile, substituted for `${VAR}` in the suites
+	env map[string]string
+}
+
+// LoadProjectConfig reads the ene.yml of the project of dir, looked up from dir upwards,
+// with the overrides of the profile selected with --profile, if any.
+// It returns nil when there is none.
+func LoadProjectConfig(dir, profile string) (*ProjectConfig, error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+
+	// A file path, e.g. of a suite, is looked up from its directory
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	for {
+		path := filepath.Join(dir, ProjectConfigFile)
+
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return parseProjectConfig(path, data, profile)
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+
+		dir = parent
+	}
+}
+
+// parseProjectConfig decodes an ene.yml file, substituting its `${VAR}` references,
+// and overrides its settings with those of the selected profile.
+func parseProjectConfig(path string, data []byte, profile string) (*ProjectConfig, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, NewYAMLError(fmt.Sprintf("invalid YAML syntax: %s", err.Error()), path)
+	}
+
+	config := &ProjectConfig{Path: path, Dir: filepath.Dir(path), Profile: profile}
+
+	if len(document.Content) == 0 {
+		return config, nil
+	}
+
+	overrides, err := profileEnv(&document, profile)
+	if err != nil {
+		return nil, NewValidationError(fmt.Sprintf("%s: %v", path, err), path, 0)
+	}
+
+	env, err := newSuiteEnv(config.Dir, config.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	env.override(overrides)
+	env.expand(&document, path)
+
+	if err := env.missingError(); err != nil {
+		return nil, err
+	}
+
+	root, selected, names, err := takeProfiles(document.Content[0], profile)
+	if err != nil {
+		return nil, NewValidationError(fmt.Sprintf("%s: %v", path, err), path, 0)
+	}
+
+	config.Profiles = names
+	config.env = overrides
+
+	if err := root.Decode(config); err != nil {
+		return nil, NewValidationError(fmt.Sprintf("%s: %v", path, err), path, 0)
+	}
+
+	if selected != nil {
+		// The env of the profile was substituted above
+		if err := withoutField(selected, "env").Decode(config); err != nil {
+			return nil, NewValidationError(fmt.Sprintf("%s: profile %s: %v", path, profile, err), path, 0)
+		}
+	}
+
+	return config, nil
+}
+
+// UnmarshalYAML reads the fields of ene.yml, rejecting unknown fields.
+func (p *ProjectConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("expected mapping node to yaml mapping, got: %v", node.Kind)
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		value := node.Content[i+1]
+
+		var err error
+
+		switch key.Value {
+		case "tests_dir":
+			err = value.Decode(&p.TestsDir)
+		case "suite_files":
+			// A single pattern or a list of patterns
+			if value.Kind == yaml.ScalarNode {
+				p.SuiteFiles = []string{value.Value}
+			} else {
+				err = value.Decode(&p.SuiteFiles)
+			}
+		case "log_dir":
+			err = value.Decode(&p.LogDir)
+		case "verbose":
+			err = value.Decode(&p.Verbose)
+		case "parallel":
+			err = value.Decode(&p.Parallel)
+		case "jobs":
+			err = value.Decode(&p.Jobs)
+			if err == nil && *p.Jobs < 0 {
+				err = fmt.Errorf("must not be negative, got %d", *p.Jobs)
+			}
+		case "reports":
+			// A profile only overrides the reports it sets
+			reports := struct {
+				HTML  string `yaml:"html"`
+				JSON  string `yaml:"json"`
+				JUnit string `yaml:"junit"`
+			}{p.HTMLReport, p.JSONReport, p.JUnitReport}
+
+			err = value.Decode(&reports)
+			p.HTMLReport, p.JSONReport, p.JUnitReport = reports.HTML, reports.JSON, reports.JUnit
+		case "images":
+			err = value.Decode(&p.Images)
+		case "fixtures":
+			if value.Kind != yaml.SequenceNode && value.Kind != yaml.MappingNode {
+				err = fmt.Errorf("must be either a sequence (array) or mapping (object), got: %v", value.Kind)
+			}
+
+			p.fixtures = append(p.fixtures, value)
+		case "timeout", "suite_timeout", "test_timeout":
+			timeout, err := decodeTimeout(key, value)
+			if err != nil {
+				return err
+			}
+
+			switch key.Value {
+			case "timeout":
+				p.Timeout = timeout
+			case "suite_timeout":
+				p.SuiteTimeout = timeout
+			default:
+				p.TestTimeout = timeout
+			}
+		default:
+			handled, err := p.Retry.decodeField(key, value)
+			if err != nil {
+				return err
+			}
+
+			if !handled {
+				return fmt.Errorf("unknown field %s at line %d", key.Value, key.Line)
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not decode %s at line %d: %w", key.Value, key.Line, err)
+		}
+	}
+
+	return nil
+}
+
+// Fixtures decodes the fixtures shared by every suite of the project. Suites
+// override them with fixtures of the same name. Files are relative to the project.
+func (p *ProjectConfig) Fixtures() ([]Fixture, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	var fixtures []Fixture
+
+	for _, node := range p.fixtures {
+		cfg := &TestSuiteConfigV1{SourceFile: p.Path, RelativePath: p.Dir}
+		if err := cfg.decodeFixtures(node, cfg.addFixture); err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Path, err)
+		}
+
+		// Fixtures of the profile replace those of the same name
+		for _, fixture := range cfg.Fixtures {
+			index := slices.IndexFunc(fixtures, func(f Fixture) bool { return f.Name() == fixture.Name() })
+			if index >= 0 {
+				fixtures[index] = fixture
+			} else {
+				fixtures = append(fixtures, fixture)
+			}
+		}
+	}
+
+	return fixtures, nil
+}
+
+// Env returns the `env:` of the selected profile, substituted for `${VAR}` in the suites.
+func (p *ProjectConfig) Env() map[string]string {
+	if p == nil {
+		return nil
+	}
+
+	return p.env
+}
+
+// TestsPath returns the directory of the test suites.
+func (p *ProjectConfig) TestsPath() string {
+	if p == nil {
+		return TestsDir
+	}
+
+	if p.TestsDir == "" {
+		return filepath.Join(p.Dir, TestsDir)
+	}
+
+	return p.path(p.TestsDir)
+}
+
+// LogPath returns the directory of the saved container logs, `log_dir` of ene.yml
+// or DefaultLogDir under the project root.
+func (p *ProjectConfig) LogPath(root string) string {
+	if p == nil || p.LogDir == "" {
+		return filepath.Join(root, DefaultLogDir)
+	}
+
+	return p.path(p.LogDir)
+}
+
+// ReportPath returns a report path of ene.yml, relative to the project.
+func (p *ProjectConfig) ReportPath(path string) string {
+	if p == nil || path == "" {
+		return path
+	}
+
+	return p.path(path)
+}
+
+func (p *ProjectConfig) path(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(p.Dir, path)
+}
+
+// overrideImages replaces the `image:` of the units of the root of a suite or
+// included file with the image it is mapped to in ene.yml.
+func (p *ProjectConfig) overrideImages(root *yaml.Node) {
+	if p == nil {
+		return
+	}
+
+	overrideUnitImages(root, p.Images)
+}
+
+// applyDefaults gives the suite the fixtures and timeouts of the project it does not set itself.
+func (p *ProjectConfig) applyDefaults(t *TestSuiteConfigV1) error {
+	if p == nil {
+		return nil
+	}
+
+	if t.Timeout == 0 {
+		t.Timeout = p.SuiteTimeout
+	}
+
+	for name, meta := range t.TestMetas {
+		if meta.Timeout == 0 {
+			meta.Timeout = p.TestTimeout
+			t.TestMetas[name] = meta
+		}
+	}
+
+	fixtures, err := p.Fixtures()
+	if err != nil {
+		return err
+	}
+
+	for _, fixture := range fixtures {
+		if _, defined := t.definitions["fixture:"+fixture.Name()]; !defined {
+			t.Fixtures = append(t.Fixtures, fixture)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,275 @@
+package e2eframe
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func writeProjectFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestLoadProjectConfigLooksUpwards(t *testing.T) {
+	t.Setenv("ENE_TEST_REGISTRY", "mirror.local")
+
+	dir := writeProjectFiles(t, map[string]string{
+		"ene.yml": `
+tests_dir: e2e
+log_dir: build/logs
+retries: 1
+retry_on: transport
+parallel: true
+jobs: 4
+timeout: 30m
+test_timeout: 10s
+reports:
+  html: build/report.html
+images:
+  mongo:6: ${ENE_TEST_REGISTRY}/mongo:6
+`,
+		"e2e/users/suite.yml": "kind: e2e_test:v1\n",
+	})
+
+	project, err := LoadProjectConfig(filepath.Join(dir, "e2e", "users", "suite.yml"), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if project == nil || project.Dir != dir {
+		t.Fatalf("project = %+v, want the ene.yml of %s", project, dir)
+	}
+
+	if got, want := project.TestsPath(), filepath.Join(dir, "e2e"); got != want {
+		t.Errorf("tests path = %q, want %q", got, want)
+	}
+
+	if got, want := project.LogPath(dir), filepath.Join(dir, "build", "logs"); got != want {
+		t.Errorf("log path = %q, want %q", got, want)
+	}
+
+	if got, want := project.ReportPath(project.HTMLReport), filepath.Join(dir, "build", "report.html"); got != want {
+		t.Errorf("html report = %q, want %q", got, want)
+	}
+
+	if *project.Retry.Retries != 1 || len(project.Retry.On) != 1 || project.Retry.On[0] != RetryOnTransport {
+		t.Errorf("retry = %+v, want 1 retry on transport failures", project.Retry)
+	}
+
+	if !*project.Parallel || *project.Jobs != 4 || project.Timeout != 30*time.Minute || project.TestTimeout != 10*time.Second {
+		t.Errorf("project = %+v, want parallel with 4 jobs, a 30m run and 10s tests", project)
+	}
+
+	if got := project.Images["mongo:6"]; got != "mirror.local/mongo:6" {
+		t.Errorf("mongo image = %q, want the substituted mirror", got)
+	}
+}
+
+func TestLoadProjectConfigWithoutFile(t *testing.T) {
+	project, err := LoadProjectConfig(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if project != nil {
+		t.Fatalf("project = %+v, want nil", project)
+	}
+
+	if got, want := project.LogPath("/project"), filepath.Join("/project", DefaultLogDir); project.TestsPath() != TestsDir || got != want {
+		t.Errorf("paths = %q, %q, want the defaults", project.TestsPath(), got)
+	}
+}
+
+func TestLoadProjectConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{name: "unknown field", config: "test_dir: e2e", wantErr: "unknown field test_dir at line 1"},
+		{name: "negative jobs", config: "jobs: -1", wantErr: "could not decode jobs at line 1: must not be negative"},
+		{name: "invalid retry", config: "retry_on: sometimes", wantErr: "sometimes"},
+		{name: "invalid fixtures", config: "fixtures: token", wantErr: "could not decode fixtures at line 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeProjectFiles(t, map[string]string{"ene.yml": tt.config})
+
+			_, err := LoadProjectConfig(dir, "")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestProjectDefaultsApplyToSuites(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		"ene.yml": `
+suite_timeout: 5m
+test_timeout: 10s
+fixtures:
+  - token: project-token
+  - region: eu-west-1
+images:
+  mongo:6: mirror.local/mongo:6
+`,
+		"tests/users/suite.yml": `
+kind: e2e_test:v1
+name: users
+fixtures:
+  - token: suite-token
+units:
+  - name: db
+    kind: stub
+    image: mongo:6
+target: db
+tests:
+  - name: default timeout
+    kind: stub
+    path: "{{ region }}"
+  - name: own timeout
+    kind: stub
+    timeout: 1s
+`,
+	})
+
+	project, err := LoadProjectConfig(filepath.Join(dir, "tests", "users"), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	suitePath := filepath.Join(dir, "tests", "users", "suite.yml")
+
+	data, err := os.ReadFile(suitePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &TestSuiteConfigV1{SourceFile: suitePath, project: project}
+	if err := document.Decode(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := unitImages(cfg.Units)["db"]; got != "mirror.local/mongo:6" {
+		t.Errorf("db image = %q, want the ene.yml override", got)
+	}
+
+	if cfg.Timeout != 5*time.Minute {
+		t.Errorf("suite timeout = %v, want the ene.yml default", cfg.Timeout)
+	}
+
+	if got := cfg.TestMetas["default timeout"].Timeout; got != 10*time.Second {
+		t.Errorf("default timeout = %v, want the ene.yml default", got)
+	}
+
+	if got := cfg.TestMetas["own timeout"].Timeout; got != time.Second {
+		t.Errorf("own timeout = %v, want the test value", got)
+	}
+
+	values := make(map[string]string)
+	for _, fixture := range cfg.Fixtures {
+		values[fixture.Name()] = string(fixture.Value())
+	}
+
+	if len(cfg.Fixtures) != 2 || values["token"] != "suite-token" || values["region"] != "eu-west-1" {
+		t.Errorf("fixtures = %v, want the suite token and the project region", values)
+	}
+}
+
+func TestProjectRoot(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		"go.mod":                           "module example.com/app\n",
+		"tests/integration/auth/suite.yml": "kind: e2e_test:v1\n",
+	})
+
+	suiteDir := filepath.Join(dir, "tests", "integration", "auth")
+
+	tests := []struct {
+		name    string
+		root    string
+		project *ProjectConfig
+		want    string
+	}{
+		{name: "marker above a nested suite", want: dir},
+		{name: "ene.yml", project: &ProjectConfig{Dir: filepath.Join(dir, "tests")}, want: filepath.Join(dir, "tests")},
+		{name: "root flag", root: filepath.Join(dir, "tests", "integration"), project: &ProjectConfig{Dir: dir}, want: filepath.Join(dir, "tests", "integration")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProjectRoot(suiteDir, tt.root, tt.project)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("root = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnitsResolvePathsFromTheirFile(t *testing.T) {
+	cfg, dir, err := loadSuiteConfig(t, map[string]string{
+		"suite.yml": `
+kind: e2e_test:v1
+name: users
+include: shared/units.yml
+units:
+  - name: app
+    kind: stub
+target: app
+tests:
+  - name: a
+    kind: stub
+`,
+		"shared/units.yml": `
+units:
+  - name: db
+    kind: stub
+`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testSuite, err := cfg.CreateTestSuite(CreateSuiteParams{RelativePath: dir, WorkingDir: dir})
+	if err != nil {
+		t.Fatalf("create test suite: %v", err)
+	}
+
+	suite := testSuite.(*TestSuiteV1)
+
+	want := map[string]string{"app": dir, "db": filepath.Join(dir, "shared")}
+	for _, unit := range suite.Units() {
+		if got := suite.UnitDir(unit); got != want[unit.Name()] {
+			t.Errorf("%s dir = %q, want %q", unit.Name(), got, want[unit.Name()])
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package e2eframe
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// referenceOwner is the unit, test, suite variable or suite before step whose definition holds references.
+type referenceOwner struct {
+	unit     string
+	test     string
+	variable string
+	step     string
+}
+
+func (o referenceOwner) owner() string {
+	switch {
+	case o.unit != "":
+		return fmt.Sprintf("unit %q", o.unit)
+	case o.variable != "":
+		return fmt.Sprintf("var %q", o.variable)
+	case o.step != "":
+		return fmt.Sprintf("before step %q", o.step)
+	default:
+		return fmt.Sprintf("test %q", o.test)
+	}
+}
+
+// fixtureReference is a `{{ expression }}` found in the definition of a unit, a test or a var.
+type fixtureReference struct {
+	referenceOwner
+	expr     string
+	location sourceLocation
+}
+
+// collectReferences records the fixture references of a unit, test or var definition,
+// and the names of the variables a test captures for the tests that run after it.
+func (t *TestSuiteConfigV1) collectReferences(node *yaml.Node, owner referenceOwner) {
+	if owner.test != "" {
+		if _, capture := mappingField(node, "capture"); capture != nil && capture.Kind == yaml.MappingNode {
+			if t.captureNames == nil {
+				t.captureNames = make(map[string]struct{})
+			}
+
+			for i := 0; i < len(capture.Content); i += 2 {
+				t.captureNames[capture.Content[i].Value] = struct{}{}
+			}
+		}
+	}
+
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.ScalarNode {
+			for _, match := range FixtureInterpolationRegex.FindAllStringSubmatch(node.Value, -1) {
+				t.references = append(t.references, fixtureReference{
+					referenceOwner: owner,
+					expr:           match[1],
+					location:       t.location(node),
+				})
+			}
+
+			return
+		}
+
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+
+	walk(node)
+}
+
+// dropReferences forgets the references of an inherited unit, test or var being overridden.
+func (t *TestSuiteConfigV1) dropReferences(owner referenceOwner) {
+	t.references = slices.DeleteFunc(t.references, func(r fixtureReference) bool {
+		return r.referenceOwner == owner
+	})
+}
+
+// validateReferences checks that every `{{ expression }}` of the units, tests and vars parses
+// and refers to a fixture, a value scoped to its test, a captured variable, a var or the
+// variables of a unit.
+// Referenced fixtures must have a value. `strict: false` disables the check, leaving
+// unresolved references as is in requests, as earlier versions did.
+func (t *TestSuiteConfigV1) validateReferences() error {
+	if t.Strict != nil && !*t.Strict {
+		return nil
+	}
+
+	var (
+		problems []string
+		first    *fixtureReference
+	)
+
+	for _, ref := range t.references {
+		problem := t.checkReference(ref)
+		if problem == "" {
+			continue
+		}
+
+		if first == nil {
+			first = &ref
+		}
+
+		problems = append(problems, problem)
+	}
+
+	if first == nil {
+		return nil
+	}
+
+	return &DetailedError{
+		Message: strings.Join(problems, "\n"),
+		File:    first.location.File,
+		Line:    first.location.Line,
+		Suggestions: []string{
+			"Define the missing fixtures or fix the names of the references",
+			`Give optional values a default: {{ name | default "value" }}`,
+			"Set 'strict: false' in the suite to leave unresolved references as is",
+		},
+	}
+}
+
+// checkReference returns what is wrong with a reference, empty if it resolves.
+func (t *TestSuiteConfigV1) checkReference(ref fixtureReference) string {
+	expr, err := parseFixtureExpression(ref.expr)
+	if err != nil {
+		return fmt.Sprintf("invalid expression {{ %s }} in %s at %s: %v", ref.expr, ref.owner(), ref.location, err)
+	}
+
+	var testNames []string
+	if ref.test != "" {
+		testNames = t.TestMetas[ref.test].scopedNames()
+	}
+
+	names := expr.argumentRoots()
+	if root := expr.root(); root != "" && !expr.hasDefault() {
+		names = append([]string{root}, names...)
+	}
+
+	for _, name := range names {
+		if slices.Contains(testNames, name) {
+			continue
+		}
+
+		if _, captured := t.captureNames[name]; captured {
+			continue
+		}
+
+		if slices.ContainsFunc(t.Vars, func(v SuiteVar) bool { return v.Name == name }) {
+			continue
+		}
+
+		// The values of the suite before steps only exist while a test runs
+		isStep := slices.ContainsFunc(t.Before, func(step SuiteVar) bool { return step.Name == name })
+		if isStep && (ref.test != "" || ref.step != "") {
+			continue
+		}
+
+		// Units expose their variables, e.g. `{{ db.dsn }}`, but have no value of their own
+		isUnit := slices.ContainsFunc(t.Units, func(u Unit) bool { return u.Name() == name })
+		if isUnit && (name != expr.root() || expr.operand.ref != name) {
+			continue
+		}
+
+		index := slices.IndexFunc(t.Fixtures, func(f Fixture) bool { return f.Name() == name })
+		if index < 0 {
+			problem := fmt.Sprintf("unknown fixture %q referenced by {{ %s }} in %s at %s", name, ref.expr, ref.owner(), ref.location)
+
+			if closest := closestName(name, t.referenceableNames(testNames)); closest != "" {
+				problem += fmt.Sprintf(", did you mean %q?", closest)
+			}
+
+			return problem
+		}
+
+		if problem := t.checkFixtureValue(t.Fixtures[index]); problem != "" && name == expr.root() {
+			return fmt.Sprintf("fixture %q referenced by {{ %s }} in %s at %s %s", name, ref.expr, ref.owner(), ref.location, problem)
+		}
+	}
+
+	return ""
+}
+
+// testReferences groups the references of the tests by test, checked when they run.
+// `strict: false` leaves the references that fail as is.
+func (t *TestSuiteConfigV1) testReferences() map[string][]fixtureReference {
+	if t.Strict != nil && !*t.Strict {
+		return nil
+	}
+
+	refs := make(map[string][]fixtureReference)
+
+	for _, ref := range t.references {
+		if ref.test != "" {
+			refs[ref.test] = append(refs[ref.test], ref)
+		}
+	}
+
+	return refs
+}
+
+// checkTestReferences evaluates the references of a test with the fixtures it runs
+// with, so that an expression failing on its input or a path missing from its
+// fixture fails the test with its location, instead of being sent as is.
+func (t *TestSuiteV1) checkTestReferences(test TestSuiteTest, fixtures []Fixture) error {
+	var errs []error
+
+	for _, ref := range t.testReferences[test.Name()] {
+		if _, _, _, err := evaluateReference(fixtures, ref.expr); err != nil {
+			errs = append(errs, fmt.Errorf("%s at %s", err, ref.location))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &InterpolationError{err: errors.Join(errs...)}
+	}
+
+	return nil
+}
+
+// referencedUnits adds to the `depends_on` of every unit the units whose variables
+// its configuration references, e.g. `image: "{{ registry.host }}:5000/app"`, so
+// that they start first.
+func (t *TestSuiteConfigV1) referencedUnits() map[string][]string {
+	dependsOn := make(map[string][]string, len(t.UnitDependsOn))
+	for unit, dependencies := range t.UnitDependsOn {
+		dependsOn[unit] = slices.Clone(dependencies)
+	}
+
+	for _, ref := range t.references {
+		if ref.unit == "" {
+			continue
+		}
+
+		expr, err := parseFixtureExpression(ref.expr)
+		if err != nil {
+			continue
+		}
+
+		refs := expr.argumentRefs()
+		if expr.root() != "" {
+			refs = append(refs, expr.operand.ref)
+		}
+
+		for _, r := range refs {
+			name, path, _ := strings.Cut(r, ".")
+			if path == "" || name == ref.unit || slices.Contains(dependsOn[ref.unit], name) ||
+				!slices.ContainsFunc(t.Units, func(u Unit) bool { return u.Name() == name }) {
+				continue
+			}
+
+			dependsOn[ref.unit] = append(dependsOn[ref.unit], name)
+		}
+	}
+
+	return dependsOn
+}
+
+// checkFixtureValue reports a fixture that would resolve empty. Values of env and
+// generated fixtures are only known when the suite starts.
+func (t *TestSuiteConfigV1) checkFixtureValue(fixture Fixture) string {
+	f, ok := fixture.(*FixtureV1)
+	if !ok || f.Source != nil {
+		return ""
+	}
+
+	if f.FixtureFile == "" {
+		if f.FixtureValue == "" {
+			return "is empty"
+		}
+
+		return ""
+	}
+
+	dir := f.RelativePath
+	if dir == "" && t.SourceFile != "" {
+		dir = filepath.Dir(t.SourceFile)
+	}
+
+	// Without a suite file, relative paths are resolved when the suite is created
+	if dir == "" && !filepath.IsAbs(f.FixtureFile) {
+		return ""
+	}
+
+	info, err := os.Stat(filepath.Join(dir, f.FixtureFile))
+	if err != nil {
+		return fmt.Sprintf("reads a file that cannot be opened: %v", err)
+	}
+
+	if info.Size() == 0 {
+		return fmt.Sprintf("reads the empty file %s", f.FixtureFile)
+	}
+
+	return ""
+}
+
+// referenceableNames returns the names a reference may use, given the names scoped to its test.
+func (t *TestSuiteConfigV1) referenceableNames(testNames []string) []string {
+	names := slices.Clone(testNames)
+
+	for _, fixture := range t.Fixtures {
+		names = append(names, fixture.Name())
+	}
+
+	for name := range t.captureNames {
+		names = append(names, name)
+	}
+
+	for _, v := range t.Vars {
+		names = append(names, v.Name)
+	}
+
+	for _, step := range t.Before {
+		names = append(names, step.Name)
+	}
+
+	for _, unit := range t.Units {
+		names = append(names, unit.Name())
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// closestName returns the candidate closest to name, empty if none is close enough to be a typo.
+func closestName(name string, candidates []string) string {
+	closest, best := "", max(2, len(name)/3)+1
+
+	for _, candidate := range candidates {
+		if distance := levenshtein(name, candidate); distance < best {
+			closest, best = candidate, distance
+		}
+	}
+
+	return closest
+}
+
+// levenshtein returns the number of single character edits turning a into b.
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,201 @@
+package e2eframe
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStrictReferencesReportUnresolvedFixtures(t *testing.T) {
+	_, dir, err := loadSuiteConfig(t, map[string]string{
+		"suite.yml": `
+kind: e2e_test:v1
+name: users
+fixtures:
+  - token: abc
+  - empty: ""
+  - payload:
+      file: missing.json
+units:
+  - name: app
+    kind: stub
+    image: "app:{{ versoin }}"
+target: app
+tests:
+  - name: login
+    kind: stub
+    path: "/login?token={{ tokn }}"
+    body: "{{ payload }}"
+  - name: other
+    kind: stub
+    headers:
+      X-Empty: "{{ empty }}"
+      X-Upper: "{{ token | shout }}"
+      X-Signature: "{{ token | hmac secert }}"
+`,
+	})
+
+	var detailedErr *DetailedError
+	if !errors.As(err, &detailedErr) {
+		t.Fatalf("expected a detailed error, got %v", err)
+	}
+
+	want := []string{
+		`unknown fixture "versoin" referenced by {{ versoin }} in unit "app" at ` + dir + "/suite.yml:12",
+		`unknown fixture "tokn" referenced by {{ tokn }} in test "login" at ` + dir + `/suite.yml:17, did you mean "token"?`,
+		`fixture "payload" referenced by {{ payload }} in test "login" at ` + dir + "/suite.yml:18 reads a file that cannot be opened",
+		`fixture "empty" referenced by {{ empty }} in test "other" at ` + dir + "/suite.yml:22 is empty",
+		`invalid expression {{ token | shout }} in test "other" at ` + dir + `/suite.yml:23: unknown function "shout"`,
+		`unknown fixture "secert" referenced by {{ token | hmac secert }}`,
+	}
+
+	lines := strings.Split(detailedErr.Message, "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d problems, got:\n%s", len(want), detailedErr.Message)
+	}
+
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("problem %d = %q, want prefix %q", i, lines[i], prefix)
+		}
+	}
+
+	if detailedErr.File != dir+"/suite.yml" || detailedErr.Line != 12 {
+		t.Errorf("error location = %s:%d, want the first reference", detailedErr.File, detailedErr.Line)
+	}
+}
+
+func TestStrictReferencesAcceptResolvableReferences(t *testing.T) {
+	_, _, err := loadSuiteConfig(t, map[string]string{
+		"suite.yml": `
+kind: e2e_test:v1
+name: users
+extends: base.yml
+fixtures:
+  - secret: s3cret
+  - user:
+      file: user.json
+  - region:
+      env: AWS_REGION
+units:
+  - name: app
+    kind: stub
+    image: "app:{{ db.version }}-{{ region }}"
+target: app
+tests:
+  - name: create
+    kind: stub
+    body: '{"id": "{{ user.id }}", "sig": "{{ user | hmac secret }}", "at": "{{ now }}"}'
+    capture:
+      user_id: body.id
+  - name: read
+    kind: stub
+    path: "/users/{{ user_id }}?lang={{ lang | default 'en' }}&db={{ db.host }}"
+  - name: list
+    kind: stub
+    path: "/users?page={{ page }}"
+    cases:
+      - page: 1
+      - page: 2
+  - name: inherited
+    kind: stub
+`,
+		"base.yml": `
+kind: e2e_test:v1
+name: base
+units:
+  - name: db
+    kind: stub
+tests:
+  - name: inherited
+    kind: stub
+    path: "{{ overridden }}"
+`,
+		"user.json": `{"id": 42}`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNonStrictSuitesKeepUnresolvedReferences(t *testing.T) {
+	cfg, _, err := loadSuiteConfig(t, map[string]string{
+		"suite.yml": `
+kind: e2e_test:v1
+name: users
+strict: false
+fixtures:
+  - payload:
+      file: missing.json
+units:
+  - name: app
+    kind: stub
+target: app
+tests:
+  - name: login
+    kind: stub
+    path: "/login?token={{ tokn }}&body={{ payload }}"
+`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := InterpolateString(FixtureInterpolationRegex, "{{ tokn }}", cfg.Fixtures); got != "{{ tokn }}" {
+		t.Errorf("unresolved reference = %q, want it left as is", got)
+	}
+
+	// An unreadable file fails the suite when it starts rather than sending an empty value
+	suite := &TestSuiteV1{Fixtures: cfg.Fixtures}
+	if _, err := suite.resolveFixtures(); err == nil || !strings.Contains(err.Error(), "fixture payload: could not read file missing.json") {
+		t.Errorf("expected a fixture file error, got %v", err)
+	}
+}
+
+func TestTestsFailOnReferencesThatCannotBeEvaluated(t *testing.T) {
+	suite := `
+kind: e2e_test:v1
+name: users
+%sfixtures:
+  - page: 2
+units:
+  - name: app
+    kind: stub
+target: app
+tests:
+  - name: list
+    kind: stub
+    path: "/users?page={{ page | div 0 }}"
+`
+
+	cfg, _, err := loadSuiteConfig(t, map[string]string{"suite.yml": fmt.Sprintf(suite, "")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testSuite, err := cfg.CreateTestSuite(CreateSuiteParams{})
+	if err != nil {
+		t.Fatalf("create test suite: %v", err)
+	}
+
+	err = testSuite.(*TestSuiteV1).checkTestReferences(cfg.Tests[0], cfg.Fixtures)
+	if err == nil || !strings.Contains(err.Error(), "{{ page | div 0 }}: div: division by zero at ") || !strings.HasSuffix(err.Error(), "suite.yml:13") {
+		t.Errorf("expected a division by zero error at suite.yml:13, got %v", err)
+	}
+
+	// `strict: false` leaves them as is
+	cfg, _, err = loadSuiteConfig(t, map[string]string{"suite.yml": fmt.Sprintf(suite, "strict: false\n")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testSuite, err = cfg.CreateTestSuite(CreateSuiteParams{})
+	if err != nil {
+		t.Fatalf("create test suite: %v", err)
+	}
+
+	if err := testSuite.(*TestSuiteV1).checkTestReferences(cfg.Tests[0], cfg.Fixtures); err != nil {
+		t.Errorf("unexpected error with strict: false: %v", err)
+	}
+}
@@ -0,0 +1,320 @@
+package e2eframe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxRetryDelay caps the delay between retries with exponential backoff.
+const maxRetryDelay = 30 * time.Second
+
+// RetryBackoff defines how the delay between retries grows.
+type RetryBackoff string
+
+const (
+	// RetryBackoffConstant waits the same delay before every retry.
+	RetryBackoffConstant RetryBackoff = "constant"
+	// RetryBackoffExponential doubles the delay before every retry and adds jitter.
+	RetryBackoffExponential RetryBackoff = "exponential"
+)
+
+func (b RetryBackoff) IsValid() bool {
+	return b == RetryBackoffConstant || b == RetryBackoffExponential
+}
+
+// RetryCondition selects which test failures are retried.
+type RetryCondition string
+
+const (
+	// RetryOnAny retries every failure.
+	RetryOnAny RetryCondition = "any"
+	// RetryOnTransport retries failures to reach the target, such as refused connections and timeouts.
+	RetryOnTransport RetryCondition = "transport"
+	// RetryOnAssertion retries failures where the target answered but the expectations did not match.
+	RetryOnAssertion RetryCondition = "assertion"
+)
+
+func (c RetryCondition) IsValid() bool {
+	return c == RetryOnAny || c == RetryOnTransport || c == RetryOnAssertion
+}
+
+// ParseRetryConditions parses a comma separated list of retry conditions, e.g. `transport,assertion`.
+func ParseRetryConditions(value string) ([]RetryCondition, error) {
+	var conditions []RetryCondition
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		condition := RetryCondition(part)
+		if !condition.IsValid() {
+			return nil, fmt.Errorf(
+				"invalid retry condition %q: expected %s, %s or %s",
+				part,
+				RetryOnAny,
+				RetryOnTransport,
+				RetryOnAssertion,
+			)
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	return conditions, nil
+}
+
+// TransportError marks a test failure caused by not being able to reach
+// the target, as opposed to the target answering with unexpected results.
+// Test kinds wrap connection failures with it so that they can be retried
+// with `retry_on: transport`.
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// IsTransportError reports whether err was caused by a transport failure,
+// either explicitly marked with TransportError or a connection level error.
+// A test exceeding its own timeout, or cancelled with its run, is never a
+// transport failure, even when the deadline interrupted a request.
+func IsTransportError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var transportErr *TransportError
+	if errors.As(err, &transportErr) {
+		return true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+
+	// EOF only counts when the HTTP transport hit it, not when a body or result
+	// ended early
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && (errors.Is(urlErr.Err, io.EOF) || errors.Is(urlErr.Err, io.ErrUnexpectedEOF)) {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// RetryPolicy describes how failed tests are retried.
+// Unset fields are inherited from the less specific level:
+// test, then suite, then the CLI defaults.
+type RetryPolicy struct {
+	// Retries is the number of retries after the first attempt
+	Retries *int
+	// Delay is the delay before the first retry (e.g. "2s")
+	Delay string
+	// Backoff defines how the delay grows between retries
+	Backoff RetryBackoff
+	// On selects which failures are retried
+	On []RetryCondition
+}
+
+// Merge returns the policy with the fields set in override replacing its own.
+func (p RetryPolicy) Merge(override RetryPolicy) RetryPolicy {
+	if override.Retries != nil {
+		p.Retries = override.Retries
+	}
+
+	if override.Delay != "" {
+		p.Delay = override.Delay
+	}
+
+	if override.Backoff != "" {
+		p.Backoff = override.Backoff
+	}
+
+	if override.On != nil {
+		p.On = override.On
+	}
+
+	return p
+}
+
+// MaxRetries returns the number of retries after the first attempt.
+func (p RetryPolicy) MaxRetries() int {
+	if p.Retries == nil || *p.Retries < 0 {
+		return 0
+	}
+
+	return *p.Retries
+}
+
+// DelayFor returns how long to wait before the given retry attempt (starting at 1).
+func (p RetryPolicy) DelayFor(attempt int) time.Duration {
+	delay, err := time.ParseDuration(p.Delay)
+	if err != nil || delay <= 0 {
+		return 0
+	}
+
+	if p.Backoff != RetryBackoffExponential {
+		return delay
+	}
+
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	// Equal jitter: keep half of the delay and randomize the other half,
+	// so that retries of concurrent suites do not hit the target at once
+	half := delay / 2
+
+	return half + rand.N(half+1)
+}
+
+// ShouldRetry reports whether a failed test attempt must be retried.
+// err is the error returned by the test run, result its result, if any.
+func (p RetryPolicy) ShouldRetry(result *TestResult, err error) bool {
+	if err == nil && result != nil {
+		err = result.Err
+	}
+
+	failure := RetryOnAssertion
+	if IsTransportError(err) {
+		failure = RetryOnTransport
+	}
+
+	// Without conditions every failure is retried
+	if len(p.On) == 0 {
+		return true
+	}
+
+	for _, condition := range p.On {
+		if condition == RetryOnAny || condition == failure {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks the values of the policy.
+func (p RetryPolicy) Validate() error {
+	if p.Retries != nil && *p.Retries < 0 {
+		return fmt.Errorf("retries must not be negative, got %d", *p.Retries)
+	}
+
+	if p.Delay != "" {
+		if _, err := time.ParseDuration(p.Delay); err != nil {
+			return fmt.Errorf("invalid retry_delay %q: %w", p.Delay, err)
+		}
+	}
+
+	if p.Backoff != "" && !p.Backoff.IsValid() {
+		return fmt.Errorf(
+			"invalid retry_backoff %q: expected %s or %s",
+			p.Backoff,
+			RetryBackoffConstant,
+			RetryBackoffExponential,
+		)
+	}
+
+	for _, condition := range p.On {
+		if !condition.IsValid() {
+			return fmt.Errorf(
+				"invalid retry_on %q: expected %s, %s or %s",
+				condition,
+				RetryOnAny,
+				RetryOnTransport,
+				RetryOnAssertion,
+			)
+		}
+	}
+
+	return nil
+}
+
+// retryFields are the YAML fields of a retry policy, at suite and test level.
+var retryFields = map[string]struct{}{
+	"retries":       {},
+	"retry_delay":   {},
+	"retry_backoff": {},
+	"retry_on":      {},
+}
+
+// decodeField decodes a retry field of a suite or test mapping.
+// It returns false if the key is not a retry field.
+func (p *RetryPolicy) decodeField(key, value *yaml.Node) (bool, error) {
+	switch key.Value {
+	case "retries":
+		var retries int
+		if err := value.Decode(&retries); err != nil {
+			return true, fmt.Errorf("could not decode retries at line %d: %w", key.Line, err)
+		}
+
+		p.Retries = &retries
+	case "retry_delay":
+		if err := value.Decode(&p.Delay); err != nil {
+			return true, fmt.Errorf("could not decode retry_delay at line %d: %w", key.Line, err)
+		}
+	case "retry_backoff":
+		if err := value.Decode(&p.Backoff); err != nil {
+			return true, fmt.Errorf("could not decode retry_backoff at line %d: %w", key.Line, err)
+		}
+	case "retry_on":
+		// Either a single condition or a list of conditions
+		if value.Kind == yaml.ScalarNode {
+			p.On = []RetryCondition{RetryCondition(value.Value)}
+		} else if err := value.Decode(&p.On); err != nil {
+			return true, fmt.Errorf("could not decode retry_on at line %d: %w", key.Line, err)
+		}
+	default:
+		return false, nil
+	}
+
+	if err := p.Validate(); err != nil {
+		return true, fmt.Errorf("%w at line %d", err, key.Line)
+	}
+
+	return true, nil
+}
+
+// sleepContext waits for the given duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,200 @@
+package e2eframe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func intPtr(i int) *int { return &i }
+
+func TestRetryPolicyMerge(t *testing.T) {
+	suite := &TestSuiteV1{
+		Retry: RetryPolicy{Retries: intPtr(1), Backoff: RetryBackoffExponential},
+		TestMetas: map[string]TestMeta{
+			"flaky": {Retry: RetryPolicy{Retries: intPtr(5), On: []RetryCondition{RetryOnTransport}}},
+		},
+	}
+
+	opts := &RunTestOptions{MaxRetries: 3, RetryDelay: "2s"}
+
+	got := suite.retryPolicy(&namedTest{name: "flaky"}, opts)
+	want := RetryPolicy{
+		Retries: intPtr(5),
+		Delay:   "2s",
+		Backoff: RetryBackoffExponential,
+		On:      []RetryCondition{RetryOnTransport},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("test policy = %+v, want %+v", got, want)
+	}
+
+	if got := suite.retryPolicy(&namedTest{name: "other"}, opts).MaxRetries(); got != 1 {
+		t.Errorf("suite retries = %d, want 1", got)
+	}
+
+	if got := (&TestSuiteV1{}).retryPolicy(&namedTest{name: "other"}, opts).MaxRetries(); got != 3 {
+		t.Errorf("default retries = %d, want 3", got)
+	}
+}
+
+func TestRetryPolicyDelayFor(t *testing.T) {
+	constant := RetryPolicy{Delay: "100ms", Backoff: RetryBackoffConstant}
+	for attempt := 1; attempt <= 3; attempt++ {
+		if got := constant.DelayFor(attempt); got != 100*time.Millisecond {
+			t.Errorf("constant delay for attempt %d = %v, want 100ms", attempt, got)
+		}
+	}
+
+	exponential := RetryPolicy{Delay: "100ms", Backoff: RetryBackoffExponential}
+	for attempt, base := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		3:  400 * time.Millisecond,
+		20: maxRetryDelay,
+	} {
+		got := exponential.DelayFor(attempt)
+		if got < base/2 || got > base {
+			t.Errorf("exponential delay for attempt %d = %v, want between %v and %v", attempt, got, base/2, base)
+		}
+	}
+
+	if got := (RetryPolicy{}).DelayFor(1); got != 0 {
+		t.Errorf("delay without retry_delay = %v, want 0", got)
+	}
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	transport := &TestResult{Err: &TransportError{Err: errors.New("dial tcp: connection refused")}}
+	assertion := &TestResult{Err: errors.New("expected status code 200, got 500")}
+	testTimeout := &TestResult{Err: &TimeoutError{Scope: TimeoutScopeTest, Name: "slow", Timeout: time.Second}}
+
+	tests := []struct {
+		name   string
+		on     []RetryCondition
+		result *TestResult
+		err    error
+		want   bool
+	}{
+		{"no conditions retries assertions", nil, assertion, nil, true},
+		{"any retries assertions", []RetryCondition{RetryOnAny}, assertion, nil, true},
+		{"transport skips assertions", []RetryCondition{RetryOnTransport}, assertion, nil, false},
+		{"transport retries marked errors", []RetryCondition{RetryOnTransport}, transport, nil, true},
+		{"transport retries network errors", []RetryCondition{RetryOnTransport}, nil, fmt.Errorf("query: %w", refused), true},
+		{"transport retries transport EOF", []RetryCondition{RetryOnTransport}, nil, &url.Error{Op: "Get", URL: "http://app", Err: io.EOF}, true},
+		{"transport skips body EOF", []RetryCondition{RetryOnTransport}, nil, fmt.Errorf("read body: %w", io.EOF), false},
+		{"transport skips test timeout", []RetryCondition{RetryOnTransport}, testTimeout, nil, false},
+		{"transport skips cancelled run", []RetryCondition{RetryOnTransport}, nil, fmt.Errorf("query: %w", context.Canceled), false},
+		{"assertion skips transport", []RetryCondition{RetryOnAssertion}, transport, nil, false},
+		{"assertion retries assertions", []RetryCondition{RetryOnAssertion}, assertion, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := RetryPolicy{On: tt.on}
+			if got := policy.ShouldRetry(tt.result, tt.err); got != tt.want {
+				t.Errorf("ShouldRetry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    RetryPolicy
+		wantErr string
+	}{
+		{
+			name: "all fields",
+			yaml: "name: a\nretries: 2\nretry_delay: 500ms\nretry_backoff: exponential\nretry_on: [transport]\n",
+			want: RetryPolicy{
+				Retries: intPtr(2),
+				Delay:   "500ms",
+				Backoff: RetryBackoffExponential,
+				On:      []RetryCondition{RetryOnTransport},
+			},
+		},
+		{
+			name: "single retry condition",
+			yaml: "name: a\nretry_on: assertion\n",
+			want: RetryPolicy{On: []RetryCondition{RetryOnAssertion}},
+		},
+		{
+			name: "zero retries",
+			yaml: "name: a\nretries: 0\n",
+			want: RetryPolicy{Retries: intPtr(0)},
+		},
+		{
+			name:    "negative retries",
+			yaml:    "name: a\nretries: -1\n",
+			wantErr: "retries must not be negative, got -1 at line 2",
+		},
+		{
+			name:    "invalid delay",
+			yaml:    "name: a\nretry_delay: soon\n",
+			wantErr: `invalid retry_delay "soon"`,
+		},
+		{
+			name:    "invalid backoff",
+			yaml:    "name: a\nretry_backoff: linear\n",
+			wantErr: `invalid retry_backoff "linear"`,
+		},
+		{
+			name:    "invalid condition",
+			yaml:    "name: a\nretry_on: [timeout]\n",
+			wantErr: `invalid retry_on "timeout"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var meta TestMeta
+
+			err := yaml.Unmarshal([]byte(tt.yaml), &meta)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(meta.Retry, tt.want) {
+				t.Errorf("got %+v, want %+v", meta.Retry, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryConditions(t *testing.T) {
+	got, err := ParseRetryConditions("transport, assertion")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []RetryCondition{RetryOnTransport, RetryOnAssertion}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := ParseRetryConditions("transport,flaky"); err == nil {
+		t.Error("expected an error for an unknown condition")
+	}
+}
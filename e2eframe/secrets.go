@@ -0,0 +1,213 @@
+package e2eframe
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"html"
+	"html/template"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// SecretMask replaces the values of secret fixtures in every output.
+const SecretMask = "****"
+
+// minSecretLength is the length under which values are not masked, so that a
+// short secret such as "1" does not mask every digit of the output.
+const minSecretLength = 3
+
+// SecretFixture is implemented by fixtures whose value must not appear in the
+// output, logs and reports (`secret: true`).
+type SecretFixture interface {
+	Fixture
+	IsSecret() bool
+}
+
+// IsSecret reports whether the fixture was marked with `secret: true`.
+func (f *FixtureV1) IsSecret() bool {
+	return f.Secret
+}
+
+// secrets holds the values masked by MaskSecrets, for every suite of the run.
+var secrets = struct {
+	sync.RWMutex
+	values   []string
+	replacer *strings.Replacer
+	// starts are the first bytes of the values, to skip the rest of the output quickly
+	starts [256]bool
+}{}
+
+// htmlTemplateText escapes a value as html/template does in the text and attributes
+// of the HTML report, which also encodes characters such as `+` as `&#43;`.
+var htmlTemplateText = template.Must(template.New("secret").Parse("{{ . }}"))
+
+// RegisterSecret masks a value, and its base64, URL, HTML and JSON encodings,
+// in everything later passed to MaskSecrets.
+func RegisterSecret(value string) {
+	if len(value) < minSecretLength {
+		return
+	}
+
+	quoted, _ := json.Marshal(value)
+
+	var reportText strings.Builder
+	if err := htmlTemplateText.Execute(&reportText, value); err != nil {
+		reportText.Reset()
+	}
+
+	forms := []string{
+		value,
+		base64.StdEncoding.EncodeToString([]byte(value)),
+		url.QueryEscape(value),
+		url.PathEscape(value),
+		html.EscapeString(value),
+		reportText.String(),
+		string(quoted[1 : len(quoted)-1]),
+	}
+
+	secrets.Lock()
+	defer secrets.Unlock()
+
+	for _, form := range forms {
+		if form != "" && !slices.Contains(secrets.values, form) {
+			secrets.values = append(secrets.values, form)
+			secrets.starts[form[0]] = true
+		}
+	}
+
+	// Longer values are replaced first, so that a secret containing another is fully masked
+	slices.SortFunc(secrets.values, func(a, b string) int { return len(b) - len(a) })
+
+	pairs := make([]string, 0, 2*len(secrets.values))
+	for _, secret := range secrets.values {
+		pairs = append(pairs, secret, SecretMask)
+	}
+
+	secrets.replacer = strings.NewReplacer(pairs...)
+}
+
+// MaskSecrets replaces the values of secret fixtures in s.
+func MaskSecrets(s string) string {
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	if secrets.replacer == nil {
+		return s
+	}
+
+	return secrets.replacer.Replace(s)
+}
+
+// maskStream masks the values of secret fixtures in data, written to a stream. It
+// returns the masked output and the end of data that may be the beginning of a
+// secret, to mask with the data of the next write. Values are replaced from the
+// left, the longest first at each position, as with MaskSecrets.
+func maskStream(data string) (masked, pending string) {
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	if secrets.replacer == nil {
+		return data, ""
+	}
+
+	var b strings.Builder
+
+	start := 0
+
+	for i := 0; i < len(data); i++ {
+		if !secrets.starts[data[i]] {
+			continue
+		}
+
+		rest := data[i:]
+
+		// The next write may complete a secret, possibly longer than one matching now
+		if slices.ContainsFunc(secrets.values, func(secret string) bool {
+			return len(rest) < len(secret) && strings.HasPrefix(secret, rest)
+		}) {
+			b.WriteString(data[start:i])
+
+			return b.String(), rest
+		}
+
+		index := slices.IndexFunc(secrets.values, func(secret string) bool {
+			return strings.HasPrefix(rest, secret)
+		})
+		if index < 0 {
+			continue
+		}
+
+		b.WriteString(data[start:i])
+		b.WriteString(SecretMask)
+
+		start = i + len(secrets.values[index])
+		i = start - 1
+	}
+
+	b.WriteString(data[start:])
+
+	return b.String(), ""
+}
+
+// MaskingWriter masks the values of secret fixtures written to an output. A secret
+// may be split across writes, e.g. by streamed build output, so the end of a write
+// that may be the beginning of a secret is held until the next write or Flush.
+type MaskingWriter struct {
+	w io.Writer
+
+	mu      sync.Mutex
+	pending string
+}
+
+// NewMaskingWriter returns a writer masking the values of secret fixtures
+// before writing to w. Flush writes the output held back at the end.
+func NewMaskingWriter(w io.Writer) *MaskingWriter {
+	return &MaskingWriter{w: w}
+}
+
+func (m *MaskingWriter) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	masked, pending := maskStream(m.pending + string(p))
+	m.pending = pending
+
+	if _, err := io.WriteString(m.w, masked); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Flush writes the output held back as the possible beginning of a secret.
+func (m *MaskingWriter) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.pending == "" {
+		return nil
+	}
+
+	pending := m.pending
+	m.pending = ""
+
+	_, err := io.WriteString(m.w, MaskSecrets(pending))
+
+	return err
+}
+
+// Sync flushes the writer and the underlying output, e.g. a terminal.
+func (m *MaskingWriter) Sync() error {
+	if err := m.Flush(); err != nil {
+		return err
+	}
+
+	if f, ok := m.w.(interface{ Sync() error }); ok {
+		return f.Sync()
+	}
+
+	return nil
+}
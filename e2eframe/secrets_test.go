@@ -0,0 +1,194 @@
+package e2eframe
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaskSecrets(t *testing.T) {
+	RegisterSecret("p@ss word/42")
+	RegisterSecret("p@ss")
+	RegisterSecret("ab")
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "raw value", in: "password=p@ss word/42", want: "password=****"},
+		{name: "base64", in: "Basic " + base64.StdEncoding.EncodeToString([]byte("p@ss word/42")), want: "Basic ****"},
+		{name: "query escaped", in: "/login?pw=p%40ss+word%2F42", want: "/login?pw=****"},
+		{name: "path escaped", in: "/users/p@ss%20word%2F42", want: "/users/****"},
+		{name: "contained secret", in: "p@ss and p@ss word/42", want: "**** and ****"},
+		{name: "short value", in: "ab cd", want: "ab cd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaskSecrets(tt.in); got != tt.want {
+				t.Errorf("MaskSecrets(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskingWriter(t *testing.T) {
+	RegisterSecret("writer-s3cret")
+
+	var out bytes.Buffer
+	w := NewMaskingWriter(&out)
+
+	n, err := w.Write([]byte("token: writer-s3cret\n"))
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if n != len("token: writer-s3cret\n") {
+		t.Errorf("n = %d, want the length of the unmasked input", n)
+	}
+
+	if got, want := out.String(), "token: ****\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// A secret split across writes, as in streamed build output
+	out.Reset()
+
+	for _, chunk := range []string{"token: wri", "ter-s3", "cret and writ", "ten"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	if got, want := out.String(), "token: **** and written"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestHTMLReportMasksSecrets(t *testing.T) {
+	RegisterSecret("sk+live/AbC==")
+
+	secretary := NewTestsSecretary(nil)
+	if err := secretary.ConsumeEvent(&TestEvent{
+		BaseEvent: BaseEvent{
+			EventType:    EventTestCompleted,
+			Suite:        "users",
+			EventMessage: "unexpected token sk+live/AbC==",
+		},
+		TestName: "login",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(t.TempDir(), "report.html")
+
+	proc, err := NewHTMLReportProcessor(HTMLReportProcessorParams{
+		OutputFile:     output,
+		Template:       GetDefaultHTMLTemplate(),
+		TestsSecretary: secretary,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := proc.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	report, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(report), "unexpected token ****") {
+		t.Errorf("report does not contain the masked error")
+	}
+
+	for _, leaked := range []string{"sk+live", "sk&#43;live", "AbC=="} {
+		if strings.Contains(string(report), leaked) {
+			t.Errorf("report contains %q", leaked)
+		}
+	}
+}
+
+func TestSecretFixturesAreMasked(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "key.pem"), []byte("file-s3cret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ENE_TEST_SECRET_KEY", "env-s3cret")
+
+	fixtures := decodeFixtures(t, `
+kind: e2e_test:v1
+name: users
+target: app
+units:
+  - name: app
+    kind: stub
+tests:
+  - name: a
+    kind: stub
+fixtures:
+  - api_key: { env: ENE_TEST_SECRET_KEY, secret: true }
+  - password: { value: value-s3cret, secret: true }
+  - key: { file: ./key.pem, secret: true }
+  - user: { value: visible-user }
+`)
+
+	for _, fixture := range fixtures {
+		fixture.(*FixtureV1).RelativePath = dir
+	}
+
+	suite := &TestSuiteV1{Fixtures: fixtures}
+	if _, err := suite.resolveFixtures(); err != nil {
+		t.Fatalf("resolve fixtures: %v", err)
+	}
+
+	if got := string(fixtures[1].Value()); got != "value-s3cret" {
+		t.Errorf("password = %q, want the value itself", got)
+	}
+
+	got := MaskSecrets("env-s3cret value-s3cret file-s3cret visible-user")
+	if want := "**** **** **** visible-user"; got != want {
+		t.Errorf("masked = %q, want %q", got, want)
+	}
+}
+
+func TestSecretFixtureDecodingErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		wantErr string
+	}{
+		{name: "not a boolean", fixture: "{ value: x, secret: maybe }", wantErr: "could not decode secret"},
+		{name: "value with other fields", fixture: "{ value: x, file: ./x.json }", wantErr: "a value fixture can only set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := loadSuiteConfig(t, map[string]string{
+				"suite.yml": "kind: e2e_test:v1\nname: users\nfixtures:\n  - token: " + tt.fixture + `
+units:
+  - name: app
+    kind: stub
+target: app
+tests:
+  - name: me
+    kind: stub
+`,
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package e2eframe
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shard selects the subset of the suites run by one of several runners (`--shard=i/n`).
+// Every runner computes the same assignment from the discovered suites,
+// so that together the shards run every suite exactly once.
+type Shard struct {
+	// Index is the 1-based index of this shard
+	Index int
+	// Total is the number of shards, zero when sharding is disabled
+	Total int
+	// Durations are the durations of the suites in a previous run, used to
+	// balance the shards by duration instead of by number of suites
+	Durations map[string]time.Duration
+}
+
+// ParseShard parses a shard expression such as `2/4`. An empty expression disables sharding.
+func ParseShard(expr string) (Shard, error) {
+	if expr == "" {
+		return Shard{}, nil
+	}
+
+	index, total, ok := strings.Cut(expr, "/")
+	if !ok {
+		return Shard{}, fmt.Errorf("invalid shard %q: expected i/n, e.g. 1/4", expr)
+	}
+
+	shard := Shard{}
+
+	var err error
+	if shard.Index, err = strconv.Atoi(strings.TrimSpace(index)); err != nil {
+		return Shard{}, fmt.Errorf("invalid shard %q: expected i/n, e.g. 1/4", expr)
+	}
+
+	if shard.Total, err = strconv.Atoi(strings.TrimSpace(total)); err != nil {
+		return Shard{}, fmt.Errorf("invalid shard %q: expected i/n, e.g. 1/4", expr)
+	}
+
+	if shard.Total < 1 || shard.Index < 1 || shard.Index > shard.Total {
+		return Shard{}, fmt.Errorf("invalid shard %q: the index must be between 1 and the number of shards", expr)
+	}
+
+	return shard, nil
+}
+
+// IsEnabled reports whether the suites are split across several shards.
+func (s Shard) IsEnabled() bool {
+	return s.Total > 1
+}
+
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Total)
+}
+
+// Select returns the suites assigned to this shard, in their original order.
+func (s Shard) Select(testSuites []TestSuite) []TestSuite {
+	if !s.IsEnabled() {
+		return testSuites
+	}
+
+	assignments := s.assign(testSuites)
+
+	selected := make([]TestSuite, 0, len(testSuites)/s.Total+1)
+
+	for i, testSuite := range testSuites {
+		if assignments[i] == s.Index-1 {
+			selected = append(selected, testSuite)
+		}
+	}
+
+	return selected
+}
+
+// assign returns the 0-based shard of every suite.
+// Without known durations the suites are dealt round-robin in discovery order.
+// With durations, the longest suites are placed first, each on the shard with
+// the least total duration so far. Suites without a known duration count as
+// the average known duration.
+func (s Shard) assign(testSuites []TestSuite) []int {
+	assignments := make([]int, len(testSuites))
+
+	var known time.Duration
+
+	knownCount := 0
+
+	for _, testSuite := range testSuites {
+		if duration, ok := s.Durations[testSuite.Name()]; ok {
+			known += duration
+			knownCount++
+		}
+	}
+
+	if knownCount == 0 {
+		for i := range testSuites {
+			assignments[i] = i % s.Total
+		}
+
+		return assignments
+	}
+
+	average := known / time.Duration(knownCount)
+
+	durations := make([]time.Duration, len(testSuites))
+	order := make([]int, len(testSuites))
+
+	for i, testSuite := range testSuites {
+		duration, ok := s.Durations[testSuite.Name()]
+		if !ok {
+			duration = average
+		}
+
+		durations[i] = duration
+		order[i] = i
+	}
+
+	// Stable, so that suites with the same duration keep their discovery order
+	sort.SliceStable(order, func(a, b int) bool {
+		return durations[order[a]] > durations[order[b]]
+	})
+
+	loads := make([]time.Duration, s.Total)
+
+	for _, i := range order {
+		lightest := 0
+		for shard := 1; shard < s.Total; shard++ {
+			if loads[shard] < loads[lightest] {
+				lightest = shard
+			}
+		}
+
+		assignments[i] = lightest
+		loads[lightest] += durations[i]
+	}
+
+	return assignments
+}
+
+// LoadSuiteDurations reads the duration of every suite from a report written with `--json`.
+// Suites without a recorded duration count the durations of their tests.
+func LoadSuiteDurations(reportPath string) (map[string]time.Duration, error) {
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		return nil, fmt.Errorf("read report: %w", err)
+	}
+
+	var report struct {
+		Suites []struct {
+			Name       string `json:"name"`
+			DurationMs *int64 `json:"durationMs"`
+			Tests      []struct {
+				Duration int64 `json:"duration"`
+			} `json:"tests"`
+		} `json:"suites"`
+	}
+
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("parse report %s: %w", reportPath, err)
+	}
+
+	durations := make(map[string]time.Duration, len(report.Suites))
+
+	for _, suite := range report.Suites {
+		if suite.DurationMs != nil {
+			durations[suite.Name] = time.Duration(*suite.DurationMs) * time.Millisecond
+
+			continue
+		}
+
+		var total int64
+		for _, test := range suite.Tests {
+			total += test.Duration
+		}
+
+		durations[suite.Name] = time.Duration(total) * time.Millisecond
+	}
+
+	return durations, nil
+}
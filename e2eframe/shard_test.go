@@ -0,0 +1,137 @@
+package e2eframe
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseShard(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    Shard
+		wantErr string
+	}{
+		{expr: "", want: Shard{}},
+		{expr: "1/1", want: Shard{Index: 1, Total: 1}},
+		{expr: "2/4", want: Shard{Index: 2, Total: 4}},
+		{expr: "0/4", wantErr: "between 1 and the number of shards"},
+		{expr: "5/4", wantErr: "between 1 and the number of shards"},
+		{expr: "2", wantErr: "expected i/n"},
+		{expr: "a/b", wantErr: "expected i/n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ParseShard(tt.expr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseShard(%q) = %+v, want %+v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func shardSuites(names ...string) []TestSuite {
+	suites := make([]TestSuite, 0, len(names))
+	for _, name := range names {
+		suites = append(suites, &TestSuiteV1{TestName: name})
+	}
+
+	return suites
+}
+
+func shardNames(shard Shard, suites []TestSuite) []string {
+	var names []string
+	for _, suite := range shard.Select(suites) {
+		names = append(names, suite.Name())
+	}
+
+	return names
+}
+
+func TestShardSelectByCount(t *testing.T) {
+	suites := shardSuites("a", "b", "c", "d", "e")
+
+	want := map[int][]string{
+		1: {"a", "d"},
+		2: {"b", "e"},
+		3: {"c"},
+	}
+
+	for index, names := range want {
+		got := shardNames(Shard{Index: index, Total: 3}, suites)
+		if !reflect.DeepEqual(got, names) {
+			t.Errorf("shard %d/3 = %v, want %v", index, got, names)
+		}
+	}
+
+	if got := shardNames(Shard{}, suites); len(got) != len(suites) {
+		t.Errorf("disabled sharding selected %v", got)
+	}
+}
+
+func TestShardSelectByDuration(t *testing.T) {
+	suites := shardSuites("a", "b", "c", "d", "new")
+	durations := map[string]time.Duration{
+		"a": 10 * time.Minute,
+		"b": 2 * time.Minute,
+		"c": 3 * time.Minute,
+		"d": 5 * time.Minute,
+	}
+
+	// "new" counts as the average duration (5m), so the shards take 13m and 12m
+	want := map[int][]string{
+		1: {"a", "c"},
+		2: {"b", "d", "new"},
+	}
+
+	for index, names := range want {
+		got := shardNames(Shard{Index: index, Total: 2, Durations: durations}, suites)
+		if !reflect.DeepEqual(got, names) {
+			t.Errorf("shard %d/2 = %v, want %v", index, got, names)
+		}
+	}
+}
+
+func TestLoadSuiteDurations(t *testing.T) {
+	report := `{
+  "suites": [
+    {"name": "users", "durationMs": 65000, "tests": [{"name": "a", "duration": 100}]},
+    {"name": "orders", "tests": [{"name": "a", "duration": 1500}, {"name": "b", "duration": 500}]}
+  ]
+}`
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(path, []byte(report), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	durations, err := LoadSuiteDurations(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]time.Duration{
+		"users":  65 * time.Second,
+		"orders": 2 * time.Second,
+	}
+
+	if !reflect.DeepEqual(durations, want) {
+		t.Errorf("durations = %v, want %v", durations, want)
+	}
+}
@@ -2,11 +2,15 @@ package e2eframe
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
@@ -129,6 +133,9 @@ type TestResult struct {
 	Message   string
 	Err       error
 	Duration  time.Duration
+	// Captured holds runtime variables captured from the test response.
+	// They are made available as fixtures to the tests that run after it.
+	Captured map[string]string
 }
 
 func (t *TestResult) Unwrap() error {
@@ -193,7 +200,12 @@ type RunTestOptions struct {
 	FlushableEvents EventSinkWithFlush // Optional: if provided, ensures event ordering
 	MaxRetries      int                // Number of retries for failed tests
 	RetryDelay      string             // Delay between retries (e.g. "2s")
+	RetryBackoff    RetryBackoff       // How the delay grows between retries
+	RetryOn         []RetryCondition   // Which failures are retried, all of them if empty
 	BaseDir         string             // Base directory for the test suite, used for relative paths
+	Tags            TagFilter          // Only run tests matching these tags
+	TestPattern     *regexp.Regexp     // Only run tests whose name matches this pattern
+	Jobs            int                // Maximum number of parallel tests run at once, the number of CPUs if zero
 
 	// Performance optimizations
 	CacheImages bool // Enable image caching for faster builds
@@ -227,17 +239,51 @@ type FixtureV1 struct {
 	FixtureFile string
 	// RelativePath is the relative path to the fixture file
 	RelativePath string
+	// Source reads the value from the environment or generates it when the suite starts
+	Source *FixtureSource
+	// Secret masks the value in the output, logs and reports (`secret: true`)
+	Secret bool
+
+	// resolved is set once the value of Source was produced
+	resolved bool
 }
 
 func (f *FixtureV1) Name() string {
 	return f.FixtureName
 }
 
+// Value returns the value of the fixture, nil if its file cannot be read or its
+// source cannot be resolved. These errors are reported when the suite is
+// validated and when it starts.
 func (f *FixtureV1) Value() []byte {
+	value, err := f.load()
+	if err != nil {
+		return nil
+	}
+
+	return value
+}
+
+// load returns the value of the fixture, reading its file or resolving its source on first use.
+func (f *FixtureV1) load() ([]byte, error) {
+	// Values of sources are produced when the suite starts, or on first use
+	if f.Source != nil {
+		if !f.resolved {
+			value, _, err := f.Source.resolve(f.FixtureName)
+			if err != nil {
+				return nil, err
+			}
+
+			f.FixtureValue, f.resolved = value, true
+		}
+
+		return []byte(f.FixtureValue), nil
+	}
+
 	// Return the fixture value if it is already set
 	// Could be the cached value from a previous call
 	if f.FixtureValue != "" {
-		return []byte(f.FixtureValue)
+		return []byte(f.FixtureValue), nil
 	}
 
 	// If the fixture file is set, read the file and set the fixture value
@@ -246,23 +292,24 @@ func (f *FixtureV1) Value() []byte {
 
 		data, err := os.ReadFile(pathToFixture)
 		if err != nil {
-			fmt.Printf("Error reading fixture file %s: %v\n", f.FixtureFile, err)
-
-			return nil
+			return nil, fmt.Errorf("fixture %s: could not read file %s: %w", f.FixtureName, f.FixtureFile, err)
 		}
 
 		f.FixtureValue = string(data)
 
-		return data
+		return data, nil
 	}
 
-	return nil
+	return nil, nil
 }
 
 // UnmarshalYAML implements custom YAML unmarshaling for FixtureV1.
-// It supports two formats:
+// It supports four formats:
 // 1. Simple key-value: `- fixtureName: value`
 // 2. File-based: `- fixtureName: { file: ./path.json }`
+// 3. Environment or generated: `- fixtureName: { env: VAR }`, `- fixtureName: { generate: uuid }`
+// 4. Explicit value: `- fixtureName: { value: s3cret, secret: true }`
+// Every mapping may set `secret: true`.
 func (f *FixtureV1) UnmarshalYAML(node *yaml.Node) error {
 	if node.Kind != yaml.MappingNode {
 		return fmt.Errorf("fixture must be a mapping, got %v", node.Kind)
@@ -273,37 +320,61 @@ func (f *FixtureV1) UnmarshalYAML(node *yaml.Node) error {
 		return fmt.Errorf("fixture must have exactly one key-value pair, got %d pairs", len(node.Content)/2)
 	}
 
-	keyNode := node.Content[0]
-	valueNode := node.Content[1]
-
 	// The key is the fixture name
-	f.FixtureName = keyNode.Value
+	f.FixtureName = node.Content[0].Value
+
+	return f.decodeValue(node.Content[1])
+}
 
-	// The value can be either:
-	// 1. A scalar (string, number, bool) - direct value
-	// 2. A mapping with "file" key - file reference
+// decodeValue decodes the value of a fixture, which can be either:
+// 1. A scalar (string, number, bool) - direct value
+// 2. A mapping with "file" key - file reference
+// 3. A mapping with "env" or "generate" key - value produced when the suite starts
+// 4. A mapping with "value" key - direct value, e.g. to mark it secret
+func (f *FixtureV1) decodeValue(valueNode *yaml.Node) error {
 	switch valueNode.Kind {
 	case yaml.ScalarNode:
 		// Direct value (string, number, bool, etc.)
 		f.FixtureValue = valueNode.Value
 	case yaml.MappingNode:
-		// File reference: { file: ./path.json }
-		for i := 0; i < len(valueNode.Content); i += 2 {
-			key := valueNode.Content[i]
-			val := valueNode.Content[i+1]
+		if key, secret := mappingField(valueNode, "secret"); secret != nil {
+			if err := secret.Decode(&f.Secret); err != nil {
+				return fmt.Errorf("fixture %s: could not decode secret at line %d: %w", f.FixtureName, key.Line, err)
+			}
 
-			if key.Value == "file" {
-				f.FixtureFile = val.Value
-			} else {
-				return fmt.Errorf("unknown field in fixture value mapping: %s", key.Value)
+			valueNode = withoutField(valueNode, "secret")
+		}
+
+		// Explicit value: { value: s3cret }
+		if _, value := mappingField(valueNode, "value"); value != nil {
+			if len(valueNode.Content) != 2 || value.Kind != yaml.ScalarNode {
+				return fmt.Errorf("fixture %s: a value fixture can only set a scalar value and secret", f.FixtureName)
 			}
+
+			f.FixtureValue = value.Value
+
+			return nil
 		}
 
-		if f.FixtureFile == "" {
-			return fmt.Errorf("fixture mapping must have 'file' field")
+		// File reference: { file: ./path.json }
+		if _, file := mappingField(valueNode, "file"); file != nil {
+			if len(valueNode.Content) != 2 {
+				return fmt.Errorf("fixture %s: a file fixture cannot have other fields", f.FixtureName)
+			}
+
+			f.FixtureFile = file.Value
+
+			return nil
 		}
+
+		source, err := decodeFixtureSource(valueNode)
+		if err != nil {
+			return fmt.Errorf("fixture %s: %w", f.FixtureName, err)
+		}
+
+		f.Source = source
 	default:
-		return fmt.Errorf("fixture value must be a scalar or mapping with 'file' key, got %v", valueNode.Kind)
+		return fmt.Errorf("fixture value must be a scalar or a mapping with a value, file, env or generate key, got %v", valueNode.Kind)
 	}
 
 	return nil
@@ -312,24 +383,62 @@ func (f *FixtureV1) UnmarshalYAML(node *yaml.Node) error {
 type TestSuiteV1 struct {
 	TestName string
 	Fixtures []Fixture
+	// Vars are the suite variables, computed in order once every unit is ready
+	Vars []SuiteVar
+	// Before are the steps run before every attempt of every test, whose values are scoped to the test
+	Before []SuiteVar
+	// Profiles are the names of the profiles of the suite and of its ene.yml
+	Profiles []string
+	// SourceFile is the path of the suite file
+	SourceFile string
 	// TestBeforeAll is a script that runs before all tests
-	TestBeforeAll string `yaml:"test_before_all,omitempty"`
+	TestBeforeAll string `yaml:"before_all,omitempty"`
 	// TestAfterAll is a script that runs after all tests
-	TestAfterAll string `yaml:"test_after_all,omitempty"`
+	TestAfterAll string `yaml:"after_all,omitempty"`
 	// TestBeforeEach is a script that runs before each test
-	TestBeforeEach string `yaml:"test_before_each,omitempty"`
+	TestBeforeEach string `yaml:"before_each,omitempty"`
 	// TestAfterEach is a script that runs after each test
-	TestAfterEach  string `yaml:"test_after_each,omitempty"`
+	TestAfterEach  string `yaml:"after_each,omitempty"`
 	TestKind       ConfigKind
 	TestUnits      []Unit
 	TestTarget     Unit
 	TestSuiteTests []TestSuiteTest
-	Debug          bool   // Suite-level debug flag
-	RelativePath   string // Relative path to the test suite file
-	WorkingDir     string // Working directory for the test suite, used for relative paths
+	// UnitDependsOn maps unit names to the units they explicitly depend on (`depends_on:`)
+	UnitDependsOn map[string][]string
+	// TestMetas maps test names to their tags, skip/only markers and retry policy
+	TestMetas map[string]TestMeta
+	// Retry is the suite retry policy, overridden per test by TestMetas
+	Retry RetryPolicy
+	// Timeout is the deadline of the whole suite, zero for none
+	Timeout time.Duration
+	// Parallel runs the tests of the suite concurrently, unless a test sets `parallel: false`
+	Parallel     bool
+	Debug        bool   // Suite-level debug flag
+	RelativePath string // Relative path to the test suite file
+	WorkingDir   string // Project root of the test suite
+	LogDir       string // Directory of the saved container logs, DefaultLogDir if empty
+	// UnitDirs are the directories of the files defining the units, that their
+	// dockerfile, env_file and migrations are relative to
+	UnitDirs map[string]string
+	// Warnings report the deprecated fields of the suite, sent as warnings when it runs
+	Warnings []string
 
 	// cleanupRegistry is the central registry for tracking cleanable resources
 	cleanupRegistry *CleanupRegistry
+
+	// captured holds the runtime variables captured by tests during the current run
+	captured []Fixture
+	// capturedMu guards captured, which parallel tests read and write concurrently
+	capturedMu sync.RWMutex
+	// vars holds the values of the suite variables of the current run
+	vars []Fixture
+	// testReferences are the `{{ }}` references of every test, by test name
+	testReferences map[string][]fixtureReference
+	// unitFixtures expose the variables of the units to every test of the run,
+	// created once the units started so that each variable is read once
+	unitFixtures []Fixture
+	// unitFixturesMu guards unitFixtures
+	unitFixturesMu sync.Mutex
 }
 
 // NewTestSuiteV1 creates a new test suite with the given name, kind, units, target, and tests.
@@ -354,6 +463,16 @@ func (t *TestSuiteV1) Name() string {
 	return t.TestName
 }
 
+// UnitDir returns the directory that the paths of a unit are relative to,
+// the directory of the file defining it.
+func (t *TestSuiteV1) UnitDir(unit Unit) string {
+	if dir, ok := t.UnitDirs[unit.Name()]; ok {
+		return dir
+	}
+
+	return t.RelativePath
+}
+
 func (t *TestSuiteV1) Units() []Unit {
 	units := make([]Unit, len(t.TestUnits))
 	for i, unit := range t.TestUnits {
@@ -386,12 +505,30 @@ func (t *TestSuiteV1) runTest(
 		fmt.Sprintf("Running test %s in suite %s", test.Name(), t.Name()),
 	)
 
+	// A failing before step fails the attempt, which may be retried
+	before, err := t.runBeforeSteps(ctx, test, opts)
+	if err != nil {
+		return &TestResult{TestName: test.Name(), Message: err.Error(), Err: err}, nil
+	}
+
+	scopes := t.testScopes(test, before)
+	if opts.Debug || t.Debug {
+		t.sendEvent(opts.EventSink, EventInfo, describeFixtureScopes(test.Name(), scopes))
+	}
+
+	fixtures := t.scopedFixtures(scopes)
+
+	// A failing expression would be sent as is and fail in confusing ways
+	if err := t.checkTestReferences(test, fixtures); err != nil {
+		return &TestResult{TestName: test.Name(), Message: err.Error(), Err: err}, nil
+	}
+
 	// Measure test execution time
 	startTime := time.Now()
 	result, err := test.Run(ctx, &TestSuiteTestRunOptions{
 		Verbose:      opts.Verbose,
 		Debug:        t.Debug, // Pass suite-level debug flag
-		Fixtures:     t.Fixtures,
+		Fixtures:     fixtures,
 		RelativePath: t.RelativePath,
 	})
 	duration := time.Since(startTime)
@@ -408,6 +545,32 @@ func (t *TestSuiteV1) runTest(
 	return result, nil
 }
 
+// runTestWithTimeout runs a single attempt of a test within the test timeout.
+// When a timeout fires, the result of the test is replaced by a timeout result.
+func (t *TestSuiteV1) runTestWithTimeout(
+	ctx context.Context,
+	test TestSuiteTest,
+	opts *RunTestOptions,
+) (*TestResult, error) {
+	testCtx, cancel := withTimeout(ctx, TimeoutScopeTest, test.Name(), t.TestMetas[test.Name()].Timeout)
+	defer cancel()
+
+	startTime := time.Now()
+	result, err := t.runTest(testCtx, test, opts)
+
+	if cause := timeoutCause(testCtx); cause != nil {
+		return &TestResult{
+			TestName: test.Name(),
+			Passed:   false,
+			Message:  cause.Error(),
+			Err:      cause,
+			Duration: time.Since(startTime),
+		}, nil
+	}
+
+	return result, err
+}
+
 func (t *TestSuiteV1) runScript(ctx context.Context, script string, opts *RunTestOptions) error {
 	if script == "" {
 		return nil
@@ -528,6 +691,117 @@ func (t *TestSuiteV1) getFixture(name string) Fixture {
 	return nil
 }
 
+// runtimeScopes returns the scopes of the fixtures visible to every test, in
+// resolution order: the values captured by earlier tests, the suite vars, the
+// suite fixtures and the suite fixtures read from the environment.
+func (t *TestSuiteV1) runtimeScopes() []fixtureScope {
+	t.capturedMu.RLock()
+	captured := slices.Clone(t.captured)
+	t.capturedMu.RUnlock()
+
+	var suite, env []Fixture
+
+	for _, fixture := range t.Fixtures {
+		if f, ok := fixture.(*FixtureV1); ok && f.Source != nil && f.Source.Env != "" {
+			env = append(env, fixture)
+		} else {
+			suite = append(suite, fixture)
+		}
+	}
+
+	return []fixtureScope{
+		{name: FixtureScopeCaptured, fixtures: captured},
+		{name: FixtureScopeVars, fixtures: t.vars},
+		{name: FixtureScopeSuite, fixtures: suite},
+		{name: FixtureScopeEnv, fixtures: env},
+	}
+}
+
+// testScopes returns the scopes of the fixtures visible to a test, in resolution
+// order. The values of its `before:` steps, of the case it was expanded from and
+// of its `fixtures:` shadow the runtime fixtures.
+func (t *TestSuiteV1) testScopes(test TestSuiteTest, before []Fixture) []fixtureScope {
+	scoped := append(slices.Clone(before), t.TestMetas[test.Name()].Fixtures...)
+
+	return append([]fixtureScope{{name: FixtureScopeTest, fixtures: scoped}}, t.runtimeScopes()...)
+}
+
+// runtimeFixtures returns the fixtures visible to every test, in resolution order.
+func (t *TestSuiteV1) runtimeFixtures() []Fixture {
+	return t.scopedFixtures(t.runtimeScopes())
+}
+
+// testFixtures returns the fixtures visible to a test, in resolution order.
+func (t *TestSuiteV1) testFixtures(test TestSuiteTest) []Fixture {
+	return t.scopedFixtures(t.testScopes(test, nil))
+}
+
+// scopedFixtures returns the variables of the units, e.g. `{{ postgres.dsn }}`,
+// followed by the fixtures of the scopes. Unit variables are only referenced by
+// their path, so they never shadow a fixture referenced by name.
+func (t *TestSuiteV1) scopedFixtures(scopes []fixtureScope) []Fixture {
+	fixtures := slices.Clone(t.unitVariables())
+	for _, scope := range scopes {
+		fixtures = append(fixtures, scope.fixtures...)
+	}
+
+	return fixtures
+}
+
+// unitVariables returns the fixtures exposing the variables of the units, shared
+// by every test of the run. They are set once the units started, and created on
+// first use otherwise.
+func (t *TestSuiteV1) unitVariables() []Fixture {
+	t.unitFixturesMu.Lock()
+	defer t.unitFixturesMu.Unlock()
+
+	if t.unitFixtures == nil {
+		t.unitFixtures = UnitFixtures(t.TestUnits)
+	}
+
+	return t.unitFixtures
+}
+
+// storeCaptured records the runtime variables captured by a test,
+// replacing previously captured values with the same name.
+func (t *TestSuiteV1) storeCaptured(captured map[string]string) {
+	t.capturedMu.Lock()
+	defer t.capturedMu.Unlock()
+
+	for name, value := range captured {
+		replaced := false
+
+		for i, fixture := range t.captured {
+			if fixture.Name() == name {
+				t.captured[i] = &CapturedFixture{CaptureName: name, CaptureValue: value}
+				replaced = true
+
+				break
+			}
+		}
+
+		if !replaced {
+			t.captured = append(t.captured, &CapturedFixture{CaptureName: name, CaptureValue: value})
+		}
+	}
+}
+
+// unitByName returns the unit with the given name, nil if there is none.
+func (t *TestSuiteV1) unitByName(name string) Unit {
+	for _, unit := range t.TestUnits {
+		if unit != nil && unit.Name() == name {
+			return unit
+		}
+	}
+
+	return nil
+}
+
+// calculateEnvDependencies lists the unit variables and fixtures referenced by the
+// env of every unit. A value may hold any number of references mixed with text, e.g.
+// `amqp://{{ rabbit.user }}:{{ secret }}@{{ rabbit.host }}/vh`, and every referenced
+// unit is a dependency. `{{ name.var }}` refers to a unit variable when a unit has
+// that name, and to a path into a fixture otherwise.
 func (t *TestSuiteV1) calculateEnvDependencies() ([]EnvDependency, error) {
 	var varDependencies []EnvDependency
 
@@ -537,137 +811,88 @@ func (t *TestSuiteV1) calculateEnvDependencies() ([]EnvDependency, error) {
 		}
 
 		envVars := unit.GetEnvRaw(&GetEnvRawOptions{
-			WorkingDir: t.WorkingDir,
+			WorkingDir: t.UnitDir(unit),
+			Fixtures:   t.Fixtures,
 		})
 		for key, value := range envVars {
 			if value == "" {
 				continue
 			}
 
-			// Check if the value is a fixture variable
-			if FixtureInterpolationRegex.MatchString(value) {
-				fixtureName := FixtureInterpolationRegex.FindStringSubmatch(value)[1]
-				fixture := t.getFixture(fixtureName)
-
-				if fixture == nil {
-					return nil, fmt.Errorf(
-						"fixture %s not found for unit %s",
-						fixtureName,
-						unit.Name(),
-					)
-				}
-
-				varDependencies = append(varDependencies, EnvDependency{
-					DependencyPosition: depPos,
-					DependantUnitName:  unit.Name(),
-					DependencyUnitName: fixture.Name(),
-					AssignedEnvName:    key,
-					VarName:            fixture.Name(),
-					RawVarValue:        value,
-					IsFixture:          true,
-				})
-
-				continue
-			}
-
-			// Check if the value is a service variable
-			if !ServiceVariableInterpolationRegex.MatchString(value) {
-				continue
-			}
-
-			matches := ServiceVariableInterpolationRegex.FindStringSubmatch(value)
+			matches := FixtureInterpolationRegex.FindAllStringSubmatch(value, -1)
 			if len(matches) == 0 {
 				continue
 			}
 
-			unitName, varName := matches[1], matches[2]
-			varDependencies = append(varDependencies, EnvDependency{
-				DependencyPosition: depPos,
-				DependantUnitName:  unit.Name(),
-				DependencyUnitName: unitName,
-				AssignedEnvName:    key,
-				VarName:            varName,
-				RawVarValue:        value,
-			})
-		}
-	}
-
-	return varDependencies, nil
-}
+			var valueDependencies []EnvDependency
 
-func (t *TestSuiteV1) hasCircularDependency(varDependencies []EnvDependency) bool {
-	// Check for circular dependencies in the varDependencies slice
-	for i, depA := range varDependencies {
-		for j := i + 1; j < len(varDependencies); j++ {
-			depB := varDependencies[j]
-			if depA.DependencyUnitName == depB.DependantUnitName &&
-				depA.DependantUnitName == depB.DependencyUnitName {
-				return true
-			}
-		}
-	}
-
-	return false
-}
+			for _, match := range matches {
+				expr, err := parseFixtureExpression(match[1])
+				if err != nil {
+					return nil, fmt.Errorf("env %s of unit %s: %w", key, unit.Name(), err)
+				}
 
-func (t *TestSuiteV1) orderUnitsByDependencies(varDependencies []EnvDependency) ([]Unit, error) {
-	// Reorder units based on their dependencies
-	reorderedUnits := make([]Unit, len(t.TestUnits))
-	copy(reorderedUnits, t.TestUnits)
+				refs := expr.argumentRefs()
+				if expr.root() != "" {
+					refs = append([]string{expr.operand.ref}, refs...)
+				}
 
-	// Track which units we've already processed to avoid duplicate swaps
-	processed := make(map[string]map[string]bool)
+				for _, ref := range refs {
+					name, path, _ := strings.Cut(ref, ".")
+					if path != "" && t.unitByName(name) != nil {
+						valueDependencies = append(valueDependencies, EnvDependency{
+							DependencyUnitName: name,
+							VarName:            refRoot(path),
+						})
 
-	for _, dep := range varDependencies {
-		dependantName := dep.DependantUnitName
-		dependencyName := dep.DependencyUnitName
+						continue
+					}
 
-		// Skip if we've already processed this dependency relationship
-		if processed[dependantName] == nil {
-			processed[dependantName] = make(map[string]bool)
-		}
-		if processed[dependantName][dependencyName] {
-			continue
-		}
-		processed[dependantName][dependencyName] = true
+					name = refRoot(ref)
+					if t.getFixture(name) == nil {
+						if ref == expr.operand.ref && expr.hasDefault() {
+							continue
+						}
 
-		// Find current positions in reorderedUnits
-		var dependantIdx, dependencyIdx int = -1, -1
+						return nil, fmt.Errorf("fixture %s not found for unit %s", name, unit.Name())
+					}
 
-		for i, unit := range reorderedUnits {
-			if unit.Name() == dependantName {
-				dependantIdx = i
+					valueDependencies = append(valueDependencies, EnvDependency{
+						DependencyUnitName: name,
+						VarName:            name,
+						IsFixture:          true,
+					})
+				}
 			}
-			if unit.Name() == dependencyName {
-				dependencyIdx = i
+
+			// Values without references to units or fixtures, e.g. `{{ now }}`, are still evaluated
+			if len(valueDependencies) == 0 {
+				valueDependencies = append(valueDependencies, EnvDependency{IsFixture: true})
 			}
-		}
 
-		if dependantIdx == -1 {
-			return nil, fmt.Errorf(
-				"unit %s not found for dependency %s.%s",
-				dependantName,
-				dep.DependencyUnitName,
-				dep.VarName,
-			)
-		}
+			for _, dep := range valueDependencies {
+				dep.DependencyPosition = depPos
+				dep.DependantUnitName = unit.Name()
+				dep.AssignedEnvName = key
+				dep.RawVarValue = value
 
-		if dependencyIdx == -1 {
-			return nil, fmt.Errorf(
-				"dependency unit %s not found for %s.%s",
-				dependencyName,
-				dep.DependencyUnitName,
-				dep.VarName,
-			)
+				varDependencies = append(varDependencies, dep)
+			}
 		}
+	}
 
-		// If dependency comes after dependant, swap them
-		if dependencyIdx > dependantIdx {
-			reorderedUnits[dependantIdx], reorderedUnits[dependencyIdx] = reorderedUnits[dependencyIdx], reorderedUnits[dependantIdx]
-		}
+	return varDependencies, nil
+}
+
+// unitStartupLevels orders the units by their dependencies.
+// Units of the same level do not depend on each other and can start concurrently.
+func (t *TestSuiteV1) unitStartupLevels(varDependencies []EnvDependency) ([][]Unit, error) {
+	graph, err := newUnitGraph(t.TestUnits, varDependencies, t.UnitDependsOn)
+	if err != nil {
+		return nil, err
 	}
 
-	return reorderedUnits, nil
+	return graph.levels()
 }
 
 func (t *TestSuiteV1) Run(ctx context.Context, opts *RunTestOptions) error {
@@ -682,6 +907,11 @@ func (t *TestSuiteV1) Run(ctx context.Context, opts *RunTestOptions) error {
 		return fmt.Errorf("no units found in test suite %s", t.TestName)
 	}
 
+	// The suite timeout covers unit startup, hooks and tests but not cleanup,
+	// which runs with its own context
+	ctx, cancelSuite := withTimeout(ctx, TimeoutScopeSuite, t.TestName, t.Timeout)
+	defer cancelSuite()
+
 	// Track suite timing
 	suiteStartTime := time.Now()
 	var setupEndTime time.Time
@@ -691,23 +921,82 @@ func (t *TestSuiteV1) Run(ctx context.Context, opts *RunTestOptions) error {
 	// Create cleanup registry for centralized resource management
 	t.cleanupRegistry = NewCleanupRegistry()
 
+	// Captured variables only live for the duration of a single run
+	t.capturedMu.Lock()
+	t.captured = nil
+	t.capturedMu.Unlock()
+
+	t.unitFixturesMu.Lock()
+	t.unitFixtures = nil
+	t.unitFixturesMu.Unlock()
+
+	// Decide which tests run before starting any unit, so that suites
+	// without selected tests do not start containers
+	plan := t.planTests(opts)
+
+	runnable := 0
+	for _, planned := range plan {
+		if planned.skipReason == "" {
+			runnable++
+		}
+	}
+
+	if runnable == 0 {
+		for _, planned := range plan {
+			t.sendTestSkippedEvent(opts.EventSink, planned.test.Name(), planned.skipReason)
+		}
+
+		if opts.EventSink != nil {
+			opts.EventSink <- &SuiteFinishedEvent{
+				BaseEvent: BaseEvent{
+					EventType:    EventSuiteFinished,
+					EventTime:    time.Now(),
+					Suite:        t.TestName,
+					EventMessage: fmt.Sprintf("Suite %s completed, all tests skipped", t.TestName),
+				},
+				TotalTime:    time.Since(suiteStartTime),
+				SkippedCount: len(plan),
+			}
+		}
+
+		return nil
+	}
+
+	// Produce env and generated fixture values before units interpolate them
+	generated, err := t.resolveFixtures()
+	if err != nil {
+		return fmt.Errorf("resolve fixtures: %w", err)
+	}
+
+	if len(generated) > 0 && opts.EventSink != nil {
+		opts.EventSink <- &FixturesGeneratedEvent{
+			BaseEvent: BaseEvent{
+				EventType:    EventFixturesGenerated,
+				EventTime:    time.Now(),
+				Suite:        t.TestName,
+				EventMessage: fmt.Sprintf("Generated %d fixture values for suite %s", len(generated), t.TestName),
+			},
+			Fixtures: generated,
+		}
+	}
+
 	// Calculate environment variable dependencies
 	varDependencies, err := t.calculateEnvDependencies()
 	if err != nil {
 		return fmt.Errorf("calculate env dependencies: %w", err)
 	}
 
-	// Check circular dependencies
-	if t.hasCircularDependency(varDependencies) {
-		return fmt.Errorf("circular dependency found in env vars")
-	}
-
-	// Reorder units based on their dependencies
-	reorderedUnits, err := t.orderUnitsByDependencies(varDependencies)
+	// Group units into startup levels based on their dependencies
+	unitLevels, err := t.unitStartupLevels(varDependencies)
 	if err != nil {
 		return fmt.Errorf("order units by dependencies: %w", err)
 	}
 
+	var reorderedUnits []Unit
+	for _, level := range unitLevels {
+		reorderedUnits = append(reorderedUnits, level...)
+	}
+
 	net, err := tcnetwork.New(ctx)
 	if err != nil {
 		return &NetworkCreationError{err: err}
@@ -827,7 +1116,11 @@ func (t *TestSuiteV1) Run(ctx context.Context, opts *RunTestOptions) error {
 	}()
 
 	// Start all units (containers, services, etc.)
-	if err = t.interpolateVarsAndStartUnits(ctx, opts, reorderedUnits, varDependencies, net); err != nil {
+	if err = t.interpolateVarsAndStartUnits(ctx, opts, unitLevels, varDependencies, net); err != nil {
+		if cause := timeoutCause(ctx); cause != nil {
+			return fmt.Errorf("start units: %w", cause)
+		}
+
 		// Check if this is a migration error - if so, return it directly for cleaner output
 		if strings.Contains(err.Error(), "migration failed in") {
 			return err
@@ -835,111 +1128,66 @@ func (t *TestSuiteV1) Run(ctx context.Context, opts *RunTestOptions) error {
 		return fmt.Errorf("interpolate vars and start units: %w", err)
 	}
 
+	// Compute the suite vars now that every unit is ready
+	if err := t.evaluateVars(ctx, opts); err != nil {
+		if cause := timeoutCause(ctx); cause != nil {
+			return fmt.Errorf("evaluate vars: %w", cause)
+		}
+
+		return fmt.Errorf("evaluate vars: %w", err)
+	}
+
 	// Mark end of setup phase (containers are ready)
 	setupEndTime = time.Now()
 
 	// Run before all tests script if provided
 	if err := t.runBeforeAll(ctx, opts); err != nil {
-		return fmt.Errorf("run before all tests script: %w", err)
-	}
-
-	for _, test := range t.Tests() {
-		// Run before each test script if provided
-		err = t.runBeforeEach(ctx, opts)
-		if err != nil {
-			return err
+		if cause := timeoutCause(ctx); cause != nil {
+			return fmt.Errorf("run before all tests script: %w", cause)
 		}
 
-		// Run the test
-		var result *TestResult
-
-		var testErr error
-
-		retryCount := 0
-		retryDelay, _ := time.ParseDuration(opts.RetryDelay)
+		return fmt.Errorf("run before all tests script: %w", err)
+	}
 
-		for retryCount <= opts.MaxRetries {
-			if retryCount > 0 {
-				t.sendTestRetryEvent(
-					opts.EventSink,
-					test.Name(),
-					retryCount,
-					opts.MaxRetries,
-				)
-				time.Sleep(retryDelay)
+	for _, batch := range t.testBatches(plan) {
+		for _, planned := range batch {
+			if planned.skipReason != "" {
+				skippedTests++
+				t.sendTestSkippedEvent(opts.EventSink, planned.test.Name(), planned.skipReason)
 			}
+		}
 
-			// Run the test
-			result, testErr = t.runTest(ctx, test, opts)
+		batchStartTime := time.Now()
+		results, err := t.runTestBatch(ctx, batch, opts)
 
-			if result != nil && result.Passed {
-				break // Test passed, no need to retry
+		var batchTime time.Duration
+		for _, result := range results {
+			if result.Passed {
+				passedTests++
+			} else {
+				failedTests++
 			}
 
-			retryCount++
-			if retryCount > opts.MaxRetries {
-				break // Max retries reached
-			}
+			batchTime += result.Duration
 		}
 
-		if testErr != nil {
-			// For errors without a result, we don't have timing data
-			t.sendTestEvent(
-				opts.EventSink,
-				test.Name(),
-				false,
-				testErr.Error(),
-				time.Duration(0),
-				testErr,
-				nil,
-			)
-
-			return fmt.Errorf("run test %s: %w", test.Name(), testErr)
+		// Tests of a parallel batch overlap, count the time the batch took instead of their sum
+		if len(results) > 1 {
+			batchTime = time.Since(batchStartTime)
 		}
 
-		if result != nil {
-			result.SuiteName = t.TestName
-			totalTestTime += result.Duration
-			if !result.Passed {
-				failedTests++
-
-				// Capture container logs from all units that support it
-				logPaths := t.captureLogsOnFailure(opts, result.TestName, result.MessageOrErr())
-
-				t.sendTestEvent(
-					opts.EventSink,
-					result.TestName,
-					false,
-					result.MessageOrErr(),
-					result.Duration,
-					result.Err,
-					logPaths,
-				)
-
-				// Don't return early - continue to next test
-				// The suite will finish naturally after all tests
-			} else {
-				passedTests++
-				t.sendTestEvent(
-					opts.EventSink,
-					result.TestName,
-					true,
-					"",
-					result.Duration,
-					nil,
-					nil,
-				)
-			}
-		}
+		totalTestTime += batchTime
 
-		// Run after each test script if provided
-		if err := t.runAfterEach(ctx, opts); err != nil {
-			return fmt.Errorf("run after each test script: %w", err)
+		if err != nil {
+			return err
 		}
 	}
 
-	// Run after all tests script if provided
-	if err := t.runAfterAll(ctx, opts); err != nil {
+	// Run after all tests script if provided, even once a deadline passed
+	afterAllCtx, cancelAfterAll := cleanupContext(ctx)
+	defer cancelAfterAll()
+
+	if err := t.runAfterAll(afterAllCtx, opts); err != nil {
 		return fmt.Errorf("run after all tests script: %w", err)
 	}
 
@@ -958,13 +1206,18 @@ func (t *TestSuiteV1) Run(ctx context.Context, opts *RunTestOptions) error {
 		setupTime = setupEndTime.Sub(suiteStartTime)
 	}
 
+	finishedMessage := fmt.Sprintf("Suite %s completed", t.TestName)
+	if cause := timeoutCause(ctx); cause != nil {
+		finishedMessage = cause.Error()
+	}
+
 	if opts.EventSink != nil {
 		opts.EventSink <- &SuiteFinishedEvent{
 			BaseEvent: BaseEvent{
 				EventType:    EventSuiteFinished,
 				EventTime:    time.Now(),
 				Suite:        t.TestName,
-				EventMessage: fmt.Sprintf("Suite %s completed", t.TestName),
+				EventMessage: finishedMessage,
 			},
 			SetupTime:    setupTime,
 			TestTime:     totalTestTime,
@@ -978,6 +1231,153 @@ func (t *TestSuiteV1) Run(ctx context.Context, opts *RunTestOptions) error {
 	return nil
 }
 
+// runPlannedTest runs a test with its before/after each scripts and retries,
+// and reports its result. It returns the final result of the test, if any,
+// and an error if the suite cannot continue.
+func (t *TestSuiteV1) runPlannedTest(
+	ctx context.Context,
+	test TestSuiteTest,
+	opts *RunTestOptions,
+) (*TestResult, error) {
+	// The test cannot run once the suite or run deadline passed
+	if cause := timeoutCause(ctx); cause != nil {
+		return t.timedOutResult(test, cause, opts), nil
+	}
+
+	// Run before each test script if provided
+	if err := t.runBeforeEach(ctx, opts); err != nil {
+		if cause := timeoutCause(ctx); cause != nil {
+			return t.timedOutResult(test, cause, opts), nil
+		}
+
+		return nil, err
+	}
+
+	// Run the test
+	var result *TestResult
+
+	var testErr error
+
+	retry := t.retryPolicy(test, opts)
+	maxRetries := retry.MaxRetries()
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			t.sendTestRetryEvent(
+				opts.EventSink,
+				test.Name(),
+				attempt,
+				maxRetries,
+			)
+
+			if err := sleepContext(ctx, retry.DelayFor(attempt)); err != nil {
+				break // Run cancelled, keep the last result
+			}
+		}
+
+		// Run the test
+		result, testErr = t.runTestWithTimeout(ctx, test, opts)
+
+		if result != nil && result.Passed {
+			break // Test passed, no need to retry
+		}
+
+		if ctx.Err() != nil {
+			break // Suite or run deadline passed, retrying cannot succeed
+		}
+
+		if !retry.ShouldRetry(result, testErr) {
+			break // Failure is not retried by the policy
+		}
+	}
+
+	if testErr != nil {
+		// For errors without a result, we don't have timing data
+		t.sendTestEvent(
+			opts.EventSink,
+			test.Name(),
+			false,
+			testErr.Error(),
+			time.Duration(0),
+			testErr,
+			nil,
+		)
+
+		return nil, fmt.Errorf("run test %s: %w", test.Name(), testErr)
+	}
+
+	if result != nil {
+		result.SuiteName = t.TestName
+		if !result.Passed {
+			// Capture container logs from all units that support it
+			logPaths := t.captureLogsOnFailure(opts, result.TestName, result.MessageOrErr())
+
+			t.sendTestEvent(
+				opts.EventSink,
+				result.TestName,
+				false,
+				result.MessageOrErr(),
+				result.Duration,
+				result.Err,
+				logPaths,
+			)
+
+			// Don't return an error - continue to next test
+			// The suite will finish naturally after all tests
+		} else {
+			t.storeCaptured(result.Captured)
+			t.sendTestEvent(
+				opts.EventSink,
+				result.TestName,
+				true,
+				"",
+				result.Duration,
+				nil,
+				nil,
+			)
+		}
+	}
+
+	// Run after each test script if provided, even once a deadline passed
+	cleanupCtx, cancel := cleanupContext(ctx)
+	defer cancel()
+
+	if err := t.runAfterEach(cleanupCtx, opts); err != nil {
+		return result, fmt.Errorf("run after each test script: %w", err)
+	}
+
+	return result, nil
+}
+
+// timedOutResult reports a test that could not run because the suite or run
+// deadline passed, and returns its timeout result.
+func (t *TestSuiteV1) timedOutResult(test TestSuiteTest, cause *TimeoutError, opts *RunTestOptions) *TestResult {
+	t.sendTestEvent(opts.EventSink, test.Name(), false, cause.Error(), 0, cause, nil)
+
+	return &TestResult{
+		TestName:  test.Name(),
+		SuiteName: t.TestName,
+		Passed:    false,
+		Message:   cause.Error(),
+		Err:       cause,
+	}
+}
+
+// retryPolicy returns the retry policy of a test: the test settings override
+// the suite settings, which override the run defaults.
+func (t *TestSuiteV1) retryPolicy(test TestSuiteTest, opts *RunTestOptions) RetryPolicy {
+	maxRetries := opts.MaxRetries
+
+	defaults := RetryPolicy{
+		Retries: &maxRetries,
+		Delay:   opts.RetryDelay,
+		Backoff: opts.RetryBackoff,
+		On:      opts.RetryOn,
+	}
+
+	return defaults.Merge(t.Retry).Merge(t.TestMetas[test.Name()].Retry)
+}
+
 // captureLogsOnFailure saves runtime logs from all units that support log capture
 // when a test fails. Returns the paths to the saved log files.
 func (t *TestSuiteV1) captureLogsOnFailure(opts *RunTestOptions, testName, failureReason string) []string {
@@ -1062,6 +1462,21 @@ func (t *TestSuiteV1) sendTestEvent(
 			Error:    err,
 			Duration: duration,
 			LogPaths: logPaths,
+			TimedOut: errors.As(err, new(*TimeoutError)),
+		}
+	}
+}
+
+func (t *TestSuiteV1) sendTestSkippedEvent(eventSink EventSink, testName, reason string) {
+	if eventSink != nil {
+		eventSink <- &TestEvent{
+			BaseEvent: BaseEvent{
+				EventType:    EventTestSkipped,
+				EventTime:    time.Now(),
+				Suite:        t.TestName,
+				EventMessage: reason,
+			},
+			TestName: testName,
 		}
 	}
 }
@@ -1069,74 +1484,132 @@ func (t *TestSuiteV1) sendTestEvent(
 func (t *TestSuiteV1) interpolateVarsAndStartUnits(
 	ctx context.Context,
 	opts *RunTestOptions,
-	reorderedUnits []Unit,
+	unitLevels [][]Unit,
 	varDependencies []EnvDependency,
 	net *testcontainers.DockerNetwork,
 ) error {
-	var err error
+	// Load fixture values up front so that units starting concurrently
+	// only ever read the cached values
+	for _, fixture := range t.Fixtures {
+		fixture.Value()
+	}
 
-	for _, unit := range reorderedUnits {
-		if unit == nil {
-			continue
+	// Units see the variables of the units started before them, and the tests the
+	// variables of every unit. They share the same fixtures, so that each variable
+	// is read once from its unit.
+	var started []Fixture
+
+	for _, level := range unitLevels {
+		fixtures := append(slices.Clone(started), t.Fixtures...)
+		errs := make([]error, len(level))
+
+		var wg sync.WaitGroup
+
+		for i, unit := range level {
+			wg.Add(1)
+
+			go func(i int, unit Unit) {
+				defer wg.Done()
+
+				errs[i] = t.interpolateVarsAndStartUnit(ctx, opts, unit, varDependencies, fixtures, net)
+			}(i, unit)
 		}
 
-		// Get dependant env vars from unit
-		envVars := map[string]string{}
-
-		for _, dep := range varDependencies {
-			if dep.DependantUnitName == unit.Name() {
-				// Get the value of the env var from the dependency unit
-				for _, dependencyUnit := range t.TestUnits {
-					if dependencyUnit.Name() == dep.DependencyUnitName {
-						envVars[dep.AssignedEnvName], err = dependencyUnit.Get(dep.VarName)
-						if err != nil {
-							err = fmt.Errorf(
-								"get env var %s from unit %s: %w",
-								dep.VarName,
-								dep.DependencyUnitName,
-								err,
-							)
-						}
+		wg.Wait()
 
-						break
-					}
-				}
+		started = append(started, UnitFixtures(level)...)
+
+		// Report the first failure in declaration order, all units of the level have settled
+		for _, err := range errs {
+			if err != nil {
+				return err
 			}
 		}
+	}
 
-		unit.SetEnvs(envVars)
-
-		if err = unit.Start(ctx, &UnitStartOptions{
-			Network:         net,
-			Verbose:         opts.Verbose,
-			CacheImages:     true,
-			CleanupCache:    opts.CleanupCache,
-			EventSink:       opts.EventSink,
-			Fixtures:        t.Fixtures,
-			Debug:           opts.Debug,
-			WorkingDir:      t.RelativePath,
-			SuiteName:       t.TestName,
-			CleanupRegistry: t.cleanupRegistry,
-		}); err != nil {
-			// Check if this is a migration error and format it cleanly
-			if strings.Contains(err.Error(), "migration failed in") {
-				// Migration errors are already well-formatted, don't add extra wrapping
-				err = fmt.Errorf("failed to start %s: %w", unit.Name(), err)
-			} else {
-				err = fmt.Errorf("start unit %s: %w", unit.Name(), err)
+	t.unitFixturesMu.Lock()
+	t.unitFixtures = started
+	t.unitFixturesMu.Unlock()
+
+	return nil
+}
+
+// interpolateVarsAndStartUnit resolves the env vars a unit takes from its dependencies,
+// starts the unit and waits for it to be ready. All dependencies must already be ready.
+// fixtures are the suite fixtures and the variables of the units already started.
+func (t *TestSuiteV1) interpolateVarsAndStartUnit(
+	ctx context.Context,
+	opts *RunTestOptions,
+	unit Unit,
+	varDependencies []EnvDependency,
+	fixtures []Fixture,
+	net *testcontainers.DockerNetwork,
+) error {
+	// Get dependant env vars from unit
+	envVars := map[string]string{}
+
+	for _, dep := range varDependencies {
+		if dep.DependantUnitName != unit.Name() {
+			continue
+		}
+
+		// Report unit variables that cannot be read, unless a fixture has the same name
+		if !dep.IsFixture && t.getFixture(dep.DependencyUnitName) == nil {
+			variables := unitFixture(fixtures, dep.DependencyUnitName)
+			if variables == nil {
+				return fmt.Errorf("unit %s must start before unit %s", dep.DependencyUnitName, unit.Name())
 			}
 
-			break
+			if _, err := variables.Get(dep.VarName); err != nil {
+				return fmt.Errorf(
+					"get env var %s from unit %s: %w",
+					dep.VarName,
+					dep.DependencyUnitName,
+					err,
+				)
+			}
 		}
 
-		if err = unit.WaitForReady(context.Background()); err != nil {
-			err = fmt.Errorf("wait for unit %s: %w", unit.Name(), err)
+		// Every reference of the value is evaluated, with the variables of the started units
+		if _, done := envVars[dep.AssignedEnvName]; !done {
+			value, err := InterpolateStringErr(FixtureInterpolationRegex, dep.RawVarValue, fixtures)
+			if err != nil {
+				return fmt.Errorf("env %s of unit %s: %w", dep.AssignedEnvName, unit.Name(), err)
+			}
 
-			break
+			envVars[dep.AssignedEnvName] = value
 		}
 	}
 
-	return err
+	unit.SetEnvs(envVars)
+
+	if err := unit.Start(ctx, &UnitStartOptions{
+		Network:         net,
+		Verbose:         opts.Verbose,
+		CacheImages:     true,
+		CleanupCache:    opts.CleanupCache,
+		EventSink:       opts.EventSink,
+		Fixtures:        fixtures,
+		Debug:           opts.Debug,
+		WorkingDir:      t.UnitDir(unit),
+		SuiteName:       t.TestName,
+		LogDir:          t.LogDir,
+		CleanupRegistry: t.cleanupRegistry,
+	}); err != nil {
+		// Check if this is a migration error and format it cleanly
+		if strings.Contains(err.Error(), "migration failed in") {
+			// Migration errors are already well-formatted, don't add extra wrapping
+			return fmt.Errorf("failed to start %s: %w", unit.Name(), err)
+		}
+
+		return fmt.Errorf("start unit %s: %w", unit.Name(), err)
+	}
+
+	if err := unit.WaitForReady(ctx); err != nil {
+		return fmt.Errorf("wait for unit %s: %w", unit.Name(), err)
+	}
+
+	return nil
 }
 
 // ForceCleanupNetwork forcefully removes all containers from a network before attempting to delete it.
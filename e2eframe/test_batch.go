@@ -0,0 +1,121 @@
+package e2eframe
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// runsInParallel reports whether a test may run concurrently with its neighbours.
+// The `parallel` field of the test overrides the one of the suite.
+func (t *TestSuiteV1) runsInParallel(test TestSuiteTest) bool {
+	if parallel := t.TestMetas[test.Name()].Parallel; parallel != nil {
+		return *parallel
+	}
+
+	return t.Parallel
+}
+
+// testBatches splits the planned tests into batches that run one after the other.
+// Consecutive parallel tests share a batch and run concurrently, every other
+// test runs alone in its batch. Skipped tests never break a batch.
+func (t *TestSuiteV1) testBatches(plan []plannedTest) [][]plannedTest {
+	var batches [][]plannedTest
+
+	var current []plannedTest
+
+	currentRunnable := false
+	currentParallel := false
+
+	for _, planned := range plan {
+		if planned.skipReason != "" {
+			current = append(current, planned)
+
+			continue
+		}
+
+		parallel := t.runsInParallel(planned.test)
+		if currentRunnable && (!parallel || !currentParallel) {
+			batches = append(batches, current)
+			current = nil
+		}
+
+		current = append(current, planned)
+		currentRunnable = true
+		currentParallel = parallel
+	}
+
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+
+	return batches
+}
+
+// runTestBatch runs the tests of a batch that are not skipped, concurrently when
+// there are several of them, at most opts.Jobs at once, or the number of CPUs
+// if opts.Jobs is zero. It returns the results
+// of the tests in declaration order and the first error that stops the suite.
+func (t *TestSuiteV1) runTestBatch(
+	ctx context.Context,
+	batch []plannedTest,
+	opts *RunTestOptions,
+) ([]*TestResult, error) {
+	tests := make([]TestSuiteTest, 0, len(batch))
+
+	for _, planned := range batch {
+		if planned.skipReason == "" {
+			tests = append(tests, planned.test)
+		}
+	}
+
+	results := make([]*TestResult, len(tests))
+	errs := make([]error, len(tests))
+
+	if len(tests) == 1 {
+		results[0], errs[0] = t.runPlannedTest(ctx, tests[0], opts)
+	} else {
+		limit := opts.Jobs
+		if limit <= 0 {
+			limit = runtime.NumCPU()
+		}
+
+		limit = min(limit, len(tests))
+
+		semaphore := make(chan struct{}, limit)
+
+		var wg sync.WaitGroup
+
+		for i, test := range tests {
+			wg.Add(1)
+
+			go func(i int, test TestSuiteTest) {
+				defer wg.Done()
+
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+
+				results[i], errs[i] = t.runPlannedTest(ctx, test, opts)
+			}(i, test)
+		}
+
+		wg.Wait()
+	}
+
+	finished := make([]*TestResult, 0, len(results))
+
+	for _, result := range results {
+		if result != nil {
+			finished = append(finished, result)
+		}
+	}
+
+	// Report the first failure in declaration order, all tests of the batch have settled
+	for _, err := range errs {
+		if err != nil {
+			return finished, err
+		}
+	}
+
+	return finished, nil
+}
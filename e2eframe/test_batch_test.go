@@ -0,0 +1,186 @@
+package e2eframe
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrentTest is a TestSuiteTest that records how many tests run at once.
+type concurrentTest struct {
+	namedTest
+	running *atomic.Int32
+	peak    *atomic.Int32
+}
+
+func (c *concurrentTest) Run(_ context.Context, _ *TestSuiteTestRunOptions) (*TestResult, error) {
+	running := c.running.Add(1)
+	defer c.running.Add(-1)
+
+	for {
+		peak := c.peak.Load()
+		if running <= peak || c.peak.CompareAndSwap(peak, running) {
+			break
+		}
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	return &TestResult{
+		TestName: c.name,
+		Passed:   true,
+		Captured: map[string]string{c.name: "done"},
+	}, nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func batchNames(batches [][]plannedTest) [][]string {
+	names := make([][]string, 0, len(batches))
+
+	for _, batch := range batches {
+		batchNames := make([]string, 0, len(batch))
+		for _, planned := range batch {
+			batchNames = append(batchNames, planned.test.Name())
+		}
+
+		names = append(names, batchNames)
+	}
+
+	return names
+}
+
+func TestTestBatches(t *testing.T) {
+	plan := []plannedTest{
+		{test: &namedTest{name: "create"}},
+		{test: &namedTest{name: "get"}},
+		{test: &namedTest{name: "list"}},
+		{test: &namedTest{name: "flaky"}, skipReason: "marked as skip"},
+		{test: &namedTest{name: "search"}},
+		{test: &namedTest{name: "delete"}},
+		{test: &namedTest{name: "count"}},
+	}
+
+	t.Run("sequential suite", func(t *testing.T) {
+		suite := &TestSuiteV1{TestMetas: map[string]TestMeta{
+			"get":    {Parallel: boolPtr(true)},
+			"list":   {Parallel: boolPtr(true)},
+			"search": {Parallel: boolPtr(true)},
+		}}
+
+		want := [][]string{{"create"}, {"get", "list", "flaky", "search"}, {"delete"}, {"count"}}
+		if got := batchNames(suite.testBatches(plan)); !reflect.DeepEqual(got, want) {
+			t.Errorf("batches = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("parallel suite", func(t *testing.T) {
+		suite := &TestSuiteV1{
+			Parallel: true,
+			TestMetas: map[string]TestMeta{
+				"create": {Parallel: boolPtr(false)},
+				"delete": {Parallel: boolPtr(false)},
+			},
+		}
+
+		want := [][]string{{"create"}, {"get", "list", "flaky", "search"}, {"delete"}, {"count"}}
+		if got := batchNames(suite.testBatches(plan)); !reflect.DeepEqual(got, want) {
+			t.Errorf("batches = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestRunTestBatch(t *testing.T) {
+	var running, peak atomic.Int32
+
+	batch := make([]plannedTest, 0, 6)
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		batch = append(batch, plannedTest{test: &concurrentTest{
+			namedTest: namedTest{name: name},
+			running:   &running,
+			peak:      &peak,
+		}})
+	}
+
+	batch[2].skipReason = "filtered out"
+
+	events := make(chan Event, 20)
+	suite := &TestSuiteV1{TestName: "users", Parallel: true}
+
+	results, err := suite.runTestBatch(context.Background(), batch, &RunTestOptions{EventSink: events, Jobs: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, result.TestName)
+	}
+
+	if want := []string{"a", "b", "d", "e", "f"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("results = %v, want %v", names, want)
+	}
+
+	if got := peak.Load(); got != 2 {
+		t.Errorf("peak concurrency = %d, want 2", got)
+	}
+
+	close(events)
+
+	completed := map[string]bool{}
+	for event := range events {
+		if testEvent, ok := event.(*TestEvent); ok && testEvent.Type() == EventTestCompleted {
+			completed[testEvent.TestName] = testEvent.Passed
+		}
+	}
+
+	if len(completed) != 5 || completed["c"] {
+		t.Errorf("unexpected completed events %v", completed)
+	}
+
+	for _, name := range []string{"a", "b", "d", "e", "f"} {
+		if capturedValue(suite, name) != "done" {
+			t.Errorf("capture of %s was not stored", name)
+		}
+	}
+}
+
+func TestRunTestBatchDefaultsJobsToCPUs(t *testing.T) {
+	var running, peak atomic.Int32
+
+	batch := make([]plannedTest, 0, runtime.NumCPU()+2)
+	for i := range cap(batch) {
+		batch = append(batch, plannedTest{test: &concurrentTest{
+			namedTest: namedTest{name: fmt.Sprintf("test %d", i)},
+			running:   &running,
+			peak:      &peak,
+		}})
+	}
+
+	suite := &TestSuiteV1{TestName: "users", Parallel: true}
+
+	if _, err := suite.runTestBatch(context.Background(), batch, &RunTestOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := peak.Load(); got > int32(runtime.NumCPU()) {
+		t.Errorf("peak concurrency = %d, want at most %d", got, runtime.NumCPU())
+	}
+}
+
+// capturedValue returns the value captured under name in the suite, if any.
+func capturedValue(suite *TestSuiteV1, name string) string {
+	for _, fixture := range suite.runtimeFixtures() {
+		if fixture.Name() == name {
+			return string(fixture.Value())
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,282 @@
+package e2eframe
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TagNameRegex validates the names of test tags.
+var TagNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// testMetaFields are the test fields handled by the framework for every test kind.
+var testMetaFields = map[string]struct{}{
+	"tags":     {},
+	"skip":     {},
+	"only":     {},
+	"timeout":  {},
+	"parallel": {},
+	"fixtures": {},
+	"before":   {},
+}
+
+// IsTestMetaField reports whether a test field is handled by the framework
+// instead of the test kind. Test kinds that reject unknown fields must accept these.
+func IsTestMetaField(key string) bool {
+	if _, ok := testMetaFields[key]; ok {
+		return true
+	}
+
+	_, ok := retryFields[key]
+
+	return ok
+}
+
+// TestMeta holds the settings shared by every test kind that decide
+// whether and how a test runs.
+type TestMeta struct {
+	// Tags are used to select tests with `--tags`
+	Tags []string
+	// Skip is the reason the test is skipped, empty if the test is not skipped
+	Skip string
+	// Only marks the test as the only tests to run in its suite
+	Only bool
+	// Retry overrides the suite retry policy for this test
+	Retry RetryPolicy
+	// Timeout is the deadline of every attempt of the test, zero for none
+	Timeout time.Duration
+	// Parallel overrides the suite `parallel` setting for this test when set
+	Parallel *bool
+	// Fixtures are the values scoped to the test: the values of the case the test was
+	// expanded from (`cases:` or `matrix:`), then the test `fixtures:`
+	Fixtures []Fixture
+	// Before are the steps run before every attempt of the test (`before:`), whose values are scoped to the test
+	Before []SuiteVar
+}
+
+// UnmarshalYAML reads the meta fields from a test mapping, ignoring any other field.
+func (m *TestMeta) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("expected mapping node for test, got %v", node.Kind)
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		value := node.Content[i+1]
+
+		switch key.Value {
+		case "tags":
+			if err := value.Decode(&m.Tags); err != nil {
+				return fmt.Errorf("could not decode tags at line %d: %w", key.Line, err)
+			}
+
+			for _, tag := range m.Tags {
+				if !TagNameRegex.MatchString(tag) {
+					return fmt.Errorf(
+						"invalid tag %q at line %d: only letters, digits, '_', '.' and '-' are allowed",
+						tag,
+						value.Line,
+					)
+				}
+			}
+		case "skip":
+			if value.Kind != yaml.ScalarNode {
+				return fmt.Errorf("skip at line %d must be a reason or a boolean", key.Line)
+			}
+
+			// `skip: true` skips without a reason, `skip: false` keeps the test
+			if value.Tag == "!!bool" {
+				var skip bool
+				if err := value.Decode(&skip); err != nil {
+					return fmt.Errorf("could not decode skip at line %d: %w", key.Line, err)
+				}
+
+				m.Skip = ""
+				if skip {
+					m.Skip = "marked as skip"
+				}
+
+				continue
+			}
+
+			m.Skip = value.Value
+		case "only":
+			if err := value.Decode(&m.Only); err != nil {
+				return fmt.Errorf("could not decode only at line %d: %w", key.Line, err)
+			}
+		case "timeout":
+			timeout, err := decodeTimeout(key, value)
+			if err != nil {
+				return err
+			}
+
+			m.Timeout = timeout
+		case "parallel":
+			var parallel bool
+			if err := value.Decode(&parallel); err != nil {
+				return fmt.Errorf("could not decode parallel at line %d: %w", key.Line, err)
+			}
+
+			m.Parallel = &parallel
+		default:
+			if _, err := m.Retry.decodeField(key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// scopedNames returns the names of the values scoped to the test.
+func (m TestMeta) scopedNames() []string {
+	names := make([]string, 0, len(m.Fixtures)+len(m.Before))
+
+	for _, fixture := range m.Fixtures {
+		names = append(names, fixture.Name())
+	}
+
+	for _, step := range m.Before {
+		names = append(names, step.Name)
+	}
+
+	return names
+}
+
+// HasTag reports whether the test is tagged with the given tag.
+func (m TestMeta) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TagFilter selects tests by their tags.
+// A test matches when it has at least one of the included tags (if any)
+// and none of the excluded ones.
+type TagFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// ParseTagFilter parses a comma separated tag expression such as `smoke,!slow`.
+// Tags prefixed with `!` are excluded.
+func ParseTagFilter(expr string) (TagFilter, error) {
+	var filter TagFilter
+
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		exclude := strings.HasPrefix(part, "!")
+		tag := strings.TrimSpace(strings.TrimPrefix(part, "!"))
+
+		if !TagNameRegex.MatchString(tag) {
+			return TagFilter{}, fmt.Errorf("invalid tag %q in tag filter %q", part, expr)
+		}
+
+		if exclude {
+			filter.Exclude = append(filter.Exclude, tag)
+		} else {
+			filter.Include = append(filter.Include, tag)
+		}
+	}
+
+	return filter, nil
+}
+
+// IsEmpty reports whether the filter selects every test.
+func (f TagFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Match reports whether a test with the given meta is selected by the filter.
+func (f TagFilter) Match(meta TestMeta) bool {
+	for _, tag := range f.Exclude {
+		if meta.HasTag(tag) {
+			return false
+		}
+	}
+
+	if len(f.Include) == 0 {
+		return true
+	}
+
+	for _, tag := range f.Include {
+		if meta.HasTag(tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f TagFilter) String() string {
+	parts := make([]string, 0, len(f.Include)+len(f.Exclude))
+	parts = append(parts, f.Include...)
+
+	for _, tag := range f.Exclude {
+		parts = append(parts, "!"+tag)
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// plannedTest is a test of a suite run together with the reason it is skipped, if any.
+type plannedTest struct {
+	test       TestSuiteTest
+	skipReason string
+}
+
+// planTests decides which tests of the suite run with the given options.
+// When any test is marked `only`, the other tests of the suite are skipped.
+func (t *TestSuiteV1) planTests(opts *RunTestOptions) []plannedTest {
+	hasOnly := false
+
+	for _, test := range t.TestSuiteTests {
+		if t.TestMetas[test.Name()].Only {
+			hasOnly = true
+
+			break
+		}
+	}
+
+	plan := make([]plannedTest, 0, len(t.TestSuiteTests))
+
+	for _, test := range t.TestSuiteTests {
+		plan = append(plan, plannedTest{
+			test:       test,
+			skipReason: t.skipReason(test, opts, hasOnly),
+		})
+	}
+
+	return plan
+}
+
+// skipReason returns why a test must not run, or an empty string if it runs.
+func (t *TestSuiteV1) skipReason(test TestSuiteTest, opts *RunTestOptions, hasOnly bool) string {
+	meta := t.TestMetas[test.Name()]
+
+	switch {
+	case meta.Skip != "":
+		return meta.Skip
+	case opts.FilterFunc != nil && !opts.FilterFunc(t.TestName, test.Name()):
+		return "filtered out"
+	case opts.TestPattern != nil && !opts.TestPattern.MatchString(test.Name()):
+		return fmt.Sprintf("does not match test pattern %q", opts.TestPattern.String())
+	case !opts.Tags.Match(meta):
+		return fmt.Sprintf("does not match tags %q", opts.Tags.String())
+	case hasOnly && !meta.Only:
+		return "another test in the suite is marked only"
+	}
+
+	return ""
+}
@@ -0,0 +1,291 @@
+package e2eframe
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// namedTest is a minimal TestSuiteTest used to exercise test selection.
+type namedTest struct {
+	name string
+}
+
+func (n *namedTest) Name() string { return n.name }
+func (n *namedTest) Kind() string { return "named" }
+func (n *namedTest) Run(_ context.Context, _ *TestSuiteTestRunOptions) (*TestResult, error) {
+	return &TestResult{TestName: n.name, Passed: true}, nil
+}
+func (n *namedTest) UnmarshalYAML(_ *yaml.Node) error { return nil }
+func (n *namedTest) Initialize(_ TestSuite) error     { return nil }
+
+func TestParseTagFilter(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    TagFilter
+		wantErr string
+	}{
+		{expr: "", want: TagFilter{}},
+		{expr: "smoke", want: TagFilter{Include: []string{"smoke"}}},
+		{expr: "smoke, !slow", want: TagFilter{Include: []string{"smoke"}, Exclude: []string{"slow"}}},
+		{expr: "!slow,!flaky", want: TagFilter{Exclude: []string{"slow", "flaky"}}},
+		{expr: "smoke,!", wantErr: `invalid tag "!"`},
+		{expr: "smo ke", wantErr: `invalid tag "smo ke"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ParseTagFilter(tt.expr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTagFilter(%q) = %+v, want %+v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagFilterMatch(t *testing.T) {
+	filter := TagFilter{Include: []string{"smoke", "critical"}, Exclude: []string{"slow"}}
+
+	tests := []struct {
+		name string
+		tags []string
+		want bool
+	}{
+		{"included tag", []string{"smoke"}, true},
+		{"any included tag", []string{"critical", "users"}, true},
+		{"excluded tag wins", []string{"smoke", "slow"}, false},
+		{"no included tag", []string{"users"}, false},
+		{"untagged", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filter.Match(TestMeta{Tags: tt.tags}); got != tt.want {
+				t.Errorf("Match(%v) = %v, want %v", tt.tags, got, tt.want)
+			}
+		})
+	}
+
+	if !(TagFilter{}).Match(TestMeta{}) {
+		t.Error("empty filter must match untagged tests")
+	}
+}
+
+func TestTestMetaUnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    TestMeta
+		wantErr string
+	}{
+		{
+			name: "all markers",
+			yaml: "name: a\nkind: http\ntags: [smoke, users]\nskip: waiting for fix\nonly: true\n",
+			want: TestMeta{Tags: []string{"smoke", "users"}, Skip: "waiting for fix", Only: true},
+		},
+		{
+			name: "skip true without reason",
+			yaml: "name: a\nskip: true\n",
+			want: TestMeta{Skip: "marked as skip"},
+		},
+		{
+			name: "skip false",
+			yaml: "name: a\nskip: false\n",
+			want: TestMeta{},
+		},
+		{
+			name:    "invalid tag",
+			yaml:    "name: a\ntags: [\"!smoke\"]\n",
+			wantErr: `invalid tag "!smoke"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got TestMeta
+
+			err := yaml.Unmarshal([]byte(tt.yaml), &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanTests(t *testing.T) {
+	suiteTests := []TestSuiteTest{
+		&namedTest{name: "create_user"},
+		&namedTest{name: "delete_user"},
+		&namedTest{name: "list_users"},
+		&namedTest{name: "export_users"},
+	}
+
+	metas := map[string]TestMeta{
+		"create_user":  {Tags: []string{"smoke"}},
+		"delete_user":  {Tags: []string{"smoke", "slow"}},
+		"list_users":   {Tags: []string{"smoke"}, Skip: "flaky"},
+		"export_users": {Tags: []string{"nightly"}},
+	}
+
+	tests := []struct {
+		name  string
+		metas map[string]TestMeta
+		opts  *RunTestOptions
+		want  map[string]string
+	}{
+		{
+			name: "no filters",
+			opts: &RunTestOptions{},
+			want: map[string]string{"list_users": "flaky"},
+		},
+		{
+			name: "tag filter",
+			opts: &RunTestOptions{Tags: TagFilter{Include: []string{"smoke"}, Exclude: []string{"slow"}}},
+			want: map[string]string{
+				"delete_user":  `does not match tags "smoke,!slow"`,
+				"list_users":   "flaky",
+				"export_users": `does not match tags "smoke,!slow"`,
+			},
+		},
+		{
+			name: "test pattern",
+			opts: &RunTestOptions{TestPattern: regexp.MustCompile(`_user$`)},
+			want: map[string]string{
+				"list_users":   "flaky",
+				"export_users": `does not match test pattern "_user$"`,
+			},
+		},
+		{
+			name: "filter func receives test names",
+			opts: &RunTestOptions{FilterFunc: func(_, testName string) bool { return testName != "create_user" }},
+			want: map[string]string{"create_user": "filtered out", "list_users": "flaky"},
+		},
+		{
+			name: "only",
+			metas: map[string]TestMeta{
+				"delete_user": {Only: true},
+			},
+			opts: &RunTestOptions{},
+			want: map[string]string{
+				"create_user":  "another test in the suite is marked only",
+				"list_users":   "another test in the suite is marked only",
+				"export_users": "another test in the suite is marked only",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suite := &TestSuiteV1{TestName: "users", TestSuiteTests: suiteTests, TestMetas: metas}
+			if tt.metas != nil {
+				suite.TestMetas = tt.metas
+			}
+
+			got := make(map[string]string)
+			for _, planned := range suite.planTests(tt.opts) {
+				if planned.skipReason != "" {
+					got[planned.test.Name()] = planned.skipReason
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("skipped = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSkipsSuiteWithoutSelectedTests(t *testing.T) {
+	events := make(chan Event, 10)
+
+	suite := &TestSuiteV1{
+		TestName:       "users",
+		TestUnits:      []Unit{&graphUnit{name: "app"}},
+		TestSuiteTests: []TestSuiteTest{&namedTest{name: "a"}, &namedTest{name: "b"}},
+		TestMetas:      map[string]TestMeta{"a": {Tags: []string{"slow"}}},
+	}
+
+	err := suite.Run(context.Background(), &RunTestOptions{
+		EventSink: events,
+		Tags:      TagFilter{Include: []string{"smoke"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	close(events)
+
+	var skipped []string
+
+	var finished *SuiteFinishedEvent
+
+	for event := range events {
+		switch e := event.(type) {
+		case *TestEvent:
+			if e.Type() != EventTestSkipped {
+				t.Errorf("unexpected test event %s for %s", e.Type(), e.TestName)
+			}
+
+			skipped = append(skipped, e.TestName)
+		case *SuiteFinishedEvent:
+			finished = e
+		}
+	}
+
+	if !reflect.DeepEqual(skipped, []string{"a", "b"}) {
+		t.Errorf("skipped tests = %v, want [a b]", skipped)
+	}
+
+	if finished == nil || finished.SkippedCount != 2 {
+		t.Fatalf("expected suite finished event with 2 skipped tests, got %+v", finished)
+	}
+}
+
+func TestTestsSecretaryCountsSkippedTests(t *testing.T) {
+	secretary := NewTestsSecretary(nil)
+
+	skipped := &TestEvent{
+		BaseEvent: BaseEvent{EventType: EventTestSkipped, Suite: "users", EventMessage: "flaky"},
+		TestName:  "list_users",
+	}
+
+	if err := secretary.ConsumeEvent(skipped); err != nil {
+		t.Fatalf("consume event: %v", err)
+	}
+
+	if got := secretary.TotalSkippedTests(); got != 1 {
+		t.Errorf("TotalSkippedTests() = %d, want 1", got)
+	}
+
+	if got := len(secretary.CompletedTests()); got != 0 {
+		t.Errorf("skipped tests must not count as completed, got %d", got)
+	}
+}
@@ -0,0 +1,237 @@
+package e2eframe
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture scopes, in resolution order: a name resolves to the value of the first scope defining it.
+const (
+	// FixtureScopeTest holds the values of a single test: its `before:` steps, the suite `before:` steps, its case values and its `fixtures:`
+	FixtureScopeTest = "test"
+	// FixtureScopeCaptured holds the values captured by earlier tests
+	FixtureScopeCaptured = "captured"
+	// FixtureScopeVars holds the suite vars
+	FixtureScopeVars = "vars"
+	// FixtureScopeSuite holds the suite fixtures
+	FixtureScopeSuite = "suite"
+	// FixtureScopeEnv holds the suite fixtures read from the environment (`{ env: VAR }`)
+	FixtureScopeEnv = "env"
+)
+
+// fixtureScope is the set of fixtures of one scope.
+type fixtureScope struct {
+	name     string
+	fixtures []Fixture
+}
+
+// decodeFixtures reads a `fixtures:` section, as a list of single-key mappings or as a mapping.
+func (t *TestSuiteConfigV1) decodeFixtures(value *yaml.Node, add func(Fixture, sourceLocation) error) error {
+	switch value.Kind {
+	case yaml.SequenceNode:
+		// Array format: - key: value
+		for _, fixtureValue := range value.Content {
+			fixture := &FixtureV1{RelativePath: t.RelativePath}
+
+			if err := fixtureValue.Decode(fixture); err != nil {
+				return err
+			}
+
+			if err := add(fixture, t.location(fixtureValue)); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		// Map format: key: value (direct mapping)
+		for i := 0; i < len(value.Content); i += 2 {
+			keyNode := value.Content[i]
+
+			fixture := &FixtureV1{
+				RelativePath: t.RelativePath,
+				FixtureName:  keyNode.Value,
+			}
+
+			// Handle scalar values, file references, env and generated values
+			if err := fixture.decodeValue(value.Content[i+1]); err != nil {
+				return err
+			}
+
+			if err := add(fixture, t.location(keyNode)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("fixtures must be either a sequence (array) or mapping (object), got: %v", value.Kind)
+	}
+
+	return nil
+}
+
+// decodeTestScope reads the `fixtures:` and `before:` of a test, whose values shadow
+// the suite values for that test only.
+func (t *TestSuiteConfigV1) decodeTestScope(testName string, node *yaml.Node, meta *TestMeta) error {
+	defined := make(map[string]sourceLocation)
+
+	define := func(name string, location sourceLocation) error {
+		if previous, ok := defined[name]; ok {
+			return fmt.Errorf("test %s defines %s at %s, already defined at %s", testName, name, location, previous)
+		}
+
+		defined[name] = location
+
+		return nil
+	}
+
+	// Test fixtures come after the case values, which shadow them
+	if _, value := mappingField(node, "fixtures"); value != nil {
+		err := t.decodeFixtures(value, func(fixture Fixture, location sourceLocation) error {
+			if err := define(fixture.Name(), location); err != nil {
+				return err
+			}
+
+			meta.Fixtures = append(meta.Fixtures, fixture)
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("test %s: %w", testName, err)
+		}
+	}
+
+	if _, value := mappingField(node, "before"); value != nil {
+		entries, err := t.varEntries("before", value)
+		if err != nil {
+			return fmt.Errorf("test %s: %w", testName, err)
+		}
+
+		for i := 0; i < len(entries); i += 2 {
+			step, err := t.decodeVar(entries[i], entries[i+1])
+			if err != nil {
+				return fmt.Errorf("test %s: %w", testName, err)
+			}
+
+			if err := define(step.Name, t.location(entries[i])); err != nil {
+				return err
+			}
+
+			meta.Before = append(meta.Before, *step)
+		}
+	}
+
+	return nil
+}
+
+// decodeBeforeSteps reads the suite `before:` steps, run before every test. A suite
+// extending a base suite replaces the base steps with the same name.
+func (t *TestSuiteConfigV1) decodeBeforeSteps(value *yaml.Node) error {
+	entries, err := t.varEntries("before", value)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(entries); i += 2 {
+		step, err := t.decodeVar(entries[i], entries[i+1])
+		if err != nil {
+			return err
+		}
+
+		if err := t.define("before step", step.Name, t.location(entries[i])); err != nil {
+			return err
+		}
+
+		owner := referenceOwner{step: step.Name}
+
+		index := slices.IndexFunc(t.Before, func(other SuiteVar) bool { return other.Name == step.Name })
+		if index >= 0 {
+			t.Before[index] = *step
+			t.dropReferences(owner)
+		} else {
+			t.Before = append(t.Before, *step)
+		}
+
+		t.collectReferences(entries[i+1], owner)
+	}
+
+	return nil
+}
+
+// runBeforeSteps runs the suite `before:` steps, then the `before:` steps of a test,
+// in order and returns their values, which are scoped to this attempt of the test.
+// Suite steps whose name the test defines itself are skipped.
+func (t *TestSuiteV1) runBeforeSteps(ctx context.Context, test TestSuiteTest, opts *RunTestOptions) ([]Fixture, error) {
+	shadowed := t.TestMetas[test.Name()].scopedNames()
+
+	steps := slices.DeleteFunc(slices.Clone(t.Before), func(step SuiteVar) bool {
+		return slices.Contains(shadowed, step.Name)
+	})
+	steps = append(steps, t.TestMetas[test.Name()].Before...)
+
+	if len(steps) == 0 {
+		return nil, nil
+	}
+
+	values := make([]Fixture, 0, len(steps))
+
+	for _, step := range steps {
+		// Each step sees the values of the steps before it
+		fixtures := append(slices.Clone(values), t.testFixtures(test)...)
+
+		value, err := t.evaluateVar(ctx, step, fixtures, opts)
+		if err != nil {
+			return nil, fmt.Errorf("before step %s: %w", step.Name, err)
+		}
+
+		values = append(values, &CapturedFixture{CaptureName: step.Name, CaptureValue: value})
+	}
+
+	return values, nil
+}
+
+// describeFixtureScopes lists every fixture visible to a test with the scope its
+// value comes from, and the scopes it shadows, for `--debug`.
+func describeFixtureScopes(testName string, scopes []fixtureScope) string {
+	var (
+		b      strings.Builder
+		order  []string
+		scoped = make(map[string][]string)
+		values = make(map[string]string)
+	)
+
+	for _, scope := range scopes {
+		for _, fixture := range scope.fixtures {
+			name := fixture.Name()
+			if _, ok := scoped[name]; !ok {
+				order = append(order, name)
+				// The value is masked before quoting, which would escape the characters of a secret
+				values[name] = MaskSecrets(string(fixture.Value()))
+			}
+
+			if !slices.Contains(scoped[name], scope.name) {
+				scoped[name] = append(scoped[name], scope.name)
+			}
+		}
+	}
+
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = scope.name
+	}
+
+	fmt.Fprintf(&b, "Fixtures of test %s (%s):", testName, strings.Join(names, " > "))
+
+	for _, name := range order {
+		fmt.Fprintf(&b, "\n  %s = %q (%s", name, values[name], scoped[name][0])
+
+		if len(scoped[name]) > 1 {
+			fmt.Fprintf(&b, ", shadows %s", strings.Join(scoped[name][1:], ", "))
+		}
+
+		b.WriteString(")")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,312 @@
+package e2eframe
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTestFixturesShadowSuiteValues(t *testing.T) {
+	t.Setenv("ENE_TEST_REGION", "eu-west-1")
+
+	cfg, _, err := loadSuiteConfig(t, map[string]string{
+		"suite.yml": `
+kind: e2e_test:v1
+name: users
+fixtures:
+  - token: valid-token
+  - user_id: "42"
+  - region: { env: ENE_TEST_REGION }
+units:
+  - name: app
+    kind: stub
+target: app
+tests:
+  - name: me
+    kind: lookup
+    response: "{{ token }} {{ user_id }} {{ region }}"
+    capture:
+      out: body
+  - name: me forbidden
+    kind: lookup
+    response: "{{ token }} {{ user_id }} {{ session }} {{ region }}"
+    capture:
+      out: body
+    fixtures:
+      - token: expired-token
+      - user_id: "0"
+    before:
+      - session:
+          kind: lookup
+          response: "session-{{ token }}"
+          capture: body.session
+    cases:
+      - user_id: "7"
+`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	suite := &TestSuiteV1{
+		Fixtures:       cfg.Fixtures,
+		TestSuiteTests: cfg.Tests,
+		TestMetas:      cfg.TestMetas,
+	}
+
+	if _, err := suite.resolveFixtures(); err != nil {
+		t.Fatalf("resolve fixtures: %v", err)
+	}
+
+	want := map[string]string{
+		"me":                       "valid-token 42 eu-west-1",
+		"me forbidden [user_id=7]": "expired-token 7 session-expired-token eu-west-1",
+	}
+
+	for _, test := range cfg.Tests {
+		result, err := suite.runTest(context.Background(), test, &RunTestOptions{})
+		if err != nil {
+			t.Fatalf("run %s: %v", test.Name(), err)
+		}
+
+		if !result.Passed || result.Captured["out"] != want[test.Name()] {
+			t.Errorf("%s: got %q (passed %v), want %q", test.Name(), result.Captured["out"], result.Passed, want[test.Name()])
+		}
+	}
+
+	// Values scoped to a test are not visible to the others
+	if got := InterpolateString(FixtureInterpolationRegex, "{{ token }}", suite.testFixtures(cfg.Tests[0])); got != "valid-token" {
+		t.Errorf("token of me = %q, want the suite value", got)
+	}
+}
+
+func TestFailingBeforeStepFailsTheTest(t *testing.T) {
+	cfg, _, err := loadSuiteConfig(t, map[string]string{
+		"suite.yml": `
+kind: e2e_test:v1
+name: users
+units:
+  - name: app
+    kind: stub
+target: app
+tests:
+  - name: me
+    kind: lookup
+    response: "{{ session }}"
+    before:
+      - session:
+          kind: lookup
+          response: ""
+          capture: body.session
+`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	suite := &TestSuiteV1{TestSuiteTests: cfg.Tests, TestMetas: cfg.TestMetas}
+
+	result, err := suite.runTest(context.Background(), cfg.Tests[0], &RunTestOptions{})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if want := "before step session: lookup query failed: empty response"; result.Passed || result.Message != want {
+		t.Errorf("result = %q (passed %v), want %q", result.Message, result.Passed, want)
+	}
+}
+
+func TestSuiteBeforeStepsRunBeforeEveryTest(t *testing.T) {
+	cfg, _, err := loadSuiteConfig(t, map[string]string{
+		"suite.yml": `
+kind: e2e_test:v1
+name: users
+fixtures:
+  - user: alice
+before:
+  - session:
+      kind: lookup
+      response: "session-{{ user }}"
+      capture: body.session
+  - greeting: "hi {{ session }}"
+units:
+  - name: app
+    kind: stub
+target: app
+tests:
+  - name: me
+    kind: lookup
+    response: "{{ greeting }}"
+    capture:
+      out: body
+  - name: bob
+    kind: lookup
+    response: "{{ greeting }}"
+    capture:
+      out: body
+    fixtures:
+      - user: bob
+  - name: anonymous
+    kind: lookup
+    response: "{{ greeting }}"
+    capture:
+      out: body
+    before:
+      - greeting: "hi stranger"
+`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	suite := &TestSuiteV1{
+		Fixtures:       cfg.Fixtures,
+		Before:         cfg.Before,
+		TestSuiteTests: cfg.Tests,
+		TestMetas:      cfg.TestMetas,
+	}
+
+	if _, err := suite.resolveFixtures(); err != nil {
+		t.Fatalf("resolve fixtures: %v", err)
+	}
+
+	want := map[string]string{
+		"me":        "hi session-alice",
+		"bob":       "hi session-bob",
+		"anonymous": "hi stranger",
+	}
+
+	for _, test := range cfg.Tests {
+		result, err := suite.runTest(context.Background(), test, &RunTestOptions{})
+		if err != nil {
+			t.Fatalf("run %s: %v", test.Name(), err)
+		}
+
+		if !result.Passed || result.Captured["out"] != want[test.Name()] {
+			t.Errorf("%s: got %q (passed %v), want %q", test.Name(), result.Captured["out"], result.Passed, want[test.Name()])
+		}
+	}
+
+	// Values of before steps are scoped to the test that ran them
+	if got := InterpolateString(FixtureInterpolationRegex, "{{ session }}", suite.runtimeFixtures()); got != "{{ session }}" {
+		t.Errorf("session after the tests = %q, want it unset", got)
+	}
+}
+
+func TestSuiteBeforeStepsShadowedByTheTestAreSkipped(t *testing.T) {
+	cfg, _, err := loadSuiteConfig(t, map[string]string{
+		"suite.yml": `
+kind: e2e_test:v1
+name: users
+before:
+  - session:
+      kind: lookup
+      response: ""
+      capture: body.session
+units:
+  - name: app
+    kind: stub
+target: app
+tests:
+  - name: me
+    kind: lookup
+    response: "{{ session }}"
+  - name: expired session
+    kind: lookup
+    response: "{{ session }}"
+    fixtures:
+      - session: expired
+`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	suite := &TestSuiteV1{Before: cfg.Before, TestSuiteTests: cfg.Tests, TestMetas: cfg.TestMetas}
+
+	want := map[string]string{
+		"me":              "before step session: lookup query failed: empty response",
+		"expired session": "",
+	}
+
+	for _, test := range cfg.Tests {
+		result, err := suite.runTest(context.Background(), test, &RunTestOptions{})
+		if err != nil {
+			t.Fatalf("run %s: %v", test.Name(), err)
+		}
+
+		if result.Passed != (want[test.Name()] == "") || result.Message != want[test.Name()] {
+			t.Errorf("%s: result = %q (passed %v), want %q", test.Name(), result.Message, result.Passed, want[test.Name()])
+		}
+	}
+}
+
+func TestTestScopeDecodingErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		test    string
+		wantErr string
+	}{
+		{
+			name:    "duplicate test fixture",
+			test:    "    fixtures:\n      - token: a\n      - token: b",
+			wantErr: "test me defines token at",
+		},
+		{
+			name:    "before step named like a test fixture",
+			test:    "    fixtures:\n      - token: a\n    before:\n      - token: \"{{ user }}\"",
+			wantErr: "test me defines token at",
+		},
+		{
+			name:    "unknown reference",
+			test:    "    fixtures:\n      - token: a\n    path: \"{{ tokn }}\"",
+			wantErr: `unknown fixture "tokn" referenced by {{ tokn }} in test "me"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := loadSuiteConfig(t, map[string]string{
+				"suite.yml": `
+kind: e2e_test:v1
+name: users
+fixtures:
+  - user: alice
+units:
+  - name: app
+    kind: stub
+target: app
+tests:
+  - name: me
+    kind: stub
+` + tt.test + "\n",
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDescribeFixtureScopes(t *testing.T) {
+	RegisterSecret("sk+live/AbC==")
+
+	got := describeFixtureScopes("me", []fixtureScope{
+		{name: FixtureScopeTest, fixtures: []Fixture{&FixtureV1{FixtureName: "token", FixtureValue: "expired"}}},
+		{name: FixtureScopeCaptured},
+		{name: FixtureScopeSuite, fixtures: []Fixture{
+			&FixtureV1{FixtureName: "token", FixtureValue: "valid"},
+			&FixtureV1{FixtureName: "user", FixtureValue: "alice"},
+			&FixtureV1{FixtureName: "api_key", FixtureValue: "sk+live/AbC=="},
+		}},
+	})
+
+	want := `Fixtures of test me (test > captured > suite):
+  token = "expired" (test, shadows suite)
+  user = "alice" (suite)
+  api_key = "****" (suite)`
+
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -10,8 +10,14 @@ import (
 type TestsSecretary struct {
 	// completedTests holds all test events that were completed during the run.
 	completedTests []TestEvent
-	// skippedSuites holds all tests that were skipped during the run.
+	// skippedSuites holds all suites that were skipped during the run.
 	skippedSuites []SuiteSkippedEvent
+	// skippedTests holds all tests that were skipped during the run.
+	skippedTests []TestEvent
+	// suiteDurations holds the total duration of every suite that finished.
+	suiteDurations map[string]time.Duration
+	// generatedFixtures holds the fixture values generated for every suite.
+	generatedFixtures map[string][]GeneratedFixture
 
 	// Metadata about running tests
 	totalFailedTests  int
@@ -24,6 +30,9 @@ func NewTestsSecretary(eventChan <-chan Event) *TestsSecretary {
 	return &TestsSecretary{
 		skippedSuites:     make([]SuiteSkippedEvent, 0),
 		completedTests:    make([]TestEvent, 0),
+		suiteDurations:    make(map[string]time.Duration),
+		generatedFixtures: make(map[string][]GeneratedFixture),
+		skippedTests:      make([]TestEvent, 0),
 		totalFailedTests:  0,
 		totalSkippedTests: 0,
 		totalPassedTests:  0,
@@ -55,6 +64,25 @@ func (s *TestsSecretary) ConsumeEvent(event Event) error {
 		} else {
 			return fmt.Errorf("expected TestEvent, got %T", event)
 		}
+	case EventTestSkipped:
+		if testEvent, ok := event.(*TestEvent); ok {
+			s.skippedTests = append(s.skippedTests, *testEvent)
+			s.totalSkippedTests++
+		} else {
+			return fmt.Errorf("expected TestEvent, got %T", event)
+		}
+	case EventSuiteFinished:
+		if suiteEvent, ok := event.(*SuiteFinishedEvent); ok {
+			s.suiteDurations[suiteEvent.SuiteName()] = suiteEvent.TotalTime
+		} else {
+			return fmt.Errorf("expected SuiteFinishedEvent, got %T", event)
+		}
+	case EventFixturesGenerated:
+		if fixturesEvent, ok := event.(*FixturesGeneratedEvent); ok {
+			s.generatedFixtures[fixturesEvent.SuiteName()] = fixturesEvent.Fixtures
+		} else {
+			return fmt.Errorf("expected FixturesGeneratedEvent, got %T", event)
+		}
 	case EventSuiteSkipped:
 		if suiteEvent, ok := event.(*SuiteSkippedEvent); ok {
 			s.skippedSuites = append(s.skippedSuites, *suiteEvent)
@@ -95,6 +123,21 @@ func (s *TestsSecretary) SkippedTests() []SuiteSkippedEvent {
 	return s.skippedSuites
 }
 
+// SkippedTestEvents returns the tests that were skipped inside suites that ran.
+func (s *TestsSecretary) SkippedTestEvents() []TestEvent {
+	return s.skippedTests
+}
+
+// SuiteDurations returns the total duration of every suite that finished, by suite name.
+func (s *TestsSecretary) SuiteDurations() map[string]time.Duration {
+	return s.suiteDurations
+}
+
+// GeneratedFixtures returns the fixture values generated for every suite, by suite name.
+func (s *TestsSecretary) GeneratedFixtures() map[string][]GeneratedFixture {
+	return s.generatedFixtures
+}
+
 func (s *TestsSecretary) TotalFailedTests() int {
 	return s.totalFailedTests
 }
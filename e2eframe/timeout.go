@@ -0,0 +1,115 @@
+package e2eframe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TimeoutScope is the level at which a timeout was configured.
+type TimeoutScope string
+
+const (
+	// TimeoutScopeRun is the timeout of the whole run (`--timeout`).
+	TimeoutScopeRun TimeoutScope = "run"
+	// TimeoutScopeSuite is the timeout of a suite (`timeout:` at suite level).
+	TimeoutScopeSuite TimeoutScope = "suite"
+	// TimeoutScopeTest is the timeout of a single test attempt (`timeout:` at test level).
+	TimeoutScopeTest TimeoutScope = "test"
+)
+
+// TimeoutError is the result of a run, suite or test that exceeded its timeout.
+// It is set as the cause of the context deadline, so that the scope that
+// fired can be told apart from the other errors of a test.
+type TimeoutError struct {
+	Scope   TimeoutScope
+	Name    string
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Scope == TimeoutScopeRun {
+		return fmt.Sprintf("test run timed out after %s", e.Timeout)
+	}
+
+	return fmt.Sprintf("%s %s timed out after %s", e.Scope, e.Name, e.Timeout)
+}
+
+func (e *TimeoutError) UserFriendlyMessage() string {
+	hint := "  → Increase the timeout with --timeout"
+	if e.Scope != TimeoutScopeRun {
+		hint = fmt.Sprintf("  → Increase `timeout:` of the %s if it is expected to take longer", e.Scope)
+	}
+
+	return e.Error() + "\n" + hint
+}
+
+// Unwrap makes timeouts match context.DeadlineExceeded.
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// withTimeout returns a context that expires after timeout with a TimeoutError
+// as its cause. A zero timeout returns the parent context unchanged.
+func withTimeout(
+	ctx context.Context,
+	scope TimeoutScope,
+	name string,
+	timeout time.Duration,
+) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeoutCause(ctx, timeout, &TimeoutError{
+		Scope:   scope,
+		Name:    name,
+		Timeout: timeout,
+	})
+}
+
+// timeoutCause returns the TimeoutError that expired the context, if any.
+func timeoutCause(ctx context.Context) *TimeoutError {
+	if ctx.Err() == nil {
+		return nil
+	}
+
+	var timeoutErr *TimeoutError
+	if errors.As(context.Cause(ctx), &timeoutErr) {
+		return timeoutErr
+	}
+
+	return nil
+}
+
+// cleanupContext returns ctx, or a context detached from its cancellation once
+// a deadline expired it, so that after hooks still run with a time limit of their own.
+func cleanupContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx.Err() == nil {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(context.WithoutCancel(ctx), 60*time.Second)
+}
+
+// decodeTimeout decodes a `timeout:` duration such as `30s` or `5m`.
+func decodeTimeout(key, value *yaml.Node) (time.Duration, error) {
+	var raw string
+	if err := value.Decode(&raw); err != nil {
+		return 0, fmt.Errorf("could not decode timeout at line %d: %w", key.Line, err)
+	}
+
+	timeout, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q at line %d: %w", raw, key.Line, err)
+	}
+
+	if timeout <= 0 {
+		return 0, fmt.Errorf("timeout at line %d must be positive, got %s", key.Line, raw)
+	}
+
+	return timeout, nil
+}
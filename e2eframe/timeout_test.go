@@ -0,0 +1,205 @@
+package e2eframe
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// blockingTest is a TestSuiteTest that only returns once its context is done.
+type blockingTest struct {
+	namedTest
+}
+
+func (b *blockingTest) Run(ctx context.Context, _ *TestSuiteTestRunOptions) (*TestResult, error) {
+	<-ctx.Done()
+
+	return &TestResult{TestName: b.name, Passed: false, Err: ctx.Err()}, nil
+}
+
+func TestRunTestWithTimeout(t *testing.T) {
+	test := &blockingTest{namedTest{name: "slow query"}}
+	suite := &TestSuiteV1{
+		TestName:  "users",
+		TestMetas: map[string]TestMeta{"slow query": {Timeout: 20 * time.Millisecond}},
+	}
+
+	result, err := suite.runTestWithTimeout(context.Background(), test, &RunTestOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var timeoutErr *TimeoutError
+	if !errors.As(result.Err, &timeoutErr) {
+		t.Fatalf("expected a timeout result, got %v", result.Err)
+	}
+
+	if timeoutErr.Scope != TimeoutScopeTest || timeoutErr.Name != "slow query" {
+		t.Errorf("unexpected timeout %+v", timeoutErr)
+	}
+
+	if want := "test slow query timed out after 20ms"; result.Message != want {
+		t.Errorf("message = %q, want %q", result.Message, want)
+	}
+}
+
+func TestRunTestWithTimeoutReportsSuiteDeadline(t *testing.T) {
+	test := &blockingTest{namedTest{name: "slow query"}}
+	suite := &TestSuiteV1{TestName: "users"}
+
+	ctx, cancel := withTimeout(context.Background(), TimeoutScopeSuite, "users", 20*time.Millisecond)
+	defer cancel()
+
+	result, err := suite.runTestWithTimeout(ctx, test, &RunTestOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "suite users timed out after 20ms"; result.Message != want {
+		t.Errorf("message = %q, want %q", result.Message, want)
+	}
+}
+
+func TestRunTestWithTimeoutKeepsFastResults(t *testing.T) {
+	suite := &TestSuiteV1{
+		TestName:  "users",
+		TestMetas: map[string]TestMeta{"fast": {Timeout: time.Second}},
+	}
+
+	result, err := suite.runTestWithTimeout(context.Background(), &namedTest{name: "fast"}, &RunTestOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !result.Passed {
+		t.Errorf("expected the test to pass, got %v", result.Err)
+	}
+}
+
+func TestTimeoutErrorIsDeadlineExceeded(t *testing.T) {
+	err := &TimeoutError{Scope: TimeoutScopeRun, Timeout: time.Minute}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("timeouts must match context.DeadlineExceeded")
+	}
+
+	if want := "test run timed out after 1m0s"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestSendTestEventMarksTimeouts(t *testing.T) {
+	events := make(chan Event, 1)
+	suite := &TestSuiteV1{TestName: "users"}
+
+	cause := &TimeoutError{Scope: TimeoutScopeTest, Name: "slow", Timeout: time.Second}
+	suite.sendTestEvent(events, "slow", false, cause.Error(), time.Second, cause, nil)
+
+	event := (<-events).(*TestEvent)
+	if !event.TimedOut {
+		t.Error("expected the test event to be marked as timed out")
+	}
+}
+
+// expiredContext returns a context whose run timeout already fired.
+func expiredContext(t *testing.T) context.Context {
+	t.Helper()
+
+	ctx, cancel := withTimeout(context.Background(), TimeoutScopeRun, "", time.Nanosecond)
+	t.Cleanup(cancel)
+	<-ctx.Done()
+
+	return ctx
+}
+
+func TestRunPlannedTestReportsDeadline(t *testing.T) {
+	events := make(chan Event, 1)
+	suite := &TestSuiteV1{TestName: "users"}
+
+	result, err := suite.runPlannedTest(expiredContext(t), &namedTest{name: "create"}, &RunTestOptions{EventSink: events})
+	if err != nil {
+		t.Fatalf("a test that cannot start must not stop the suite: %v", err)
+	}
+
+	if result.Passed || !errors.As(result.Err, new(*TimeoutError)) {
+		t.Errorf("expected a timeout result, got %+v", result)
+	}
+
+	if event := (<-events).(*TestEvent); !event.TimedOut || event.TestName != "create" {
+		t.Errorf("expected a timed out event for create, got %+v", event)
+	}
+}
+
+func TestRunnersReportSuitesAfterDeadline(t *testing.T) {
+	runners := map[string]func(context.Context, []TestSuite, *RunOpts, EventSink){
+		"sequential": runTestsSequentially,
+		"parallel":   runTestsInParallel,
+	}
+
+	for name, run := range runners {
+		t.Run(name, func(t *testing.T) {
+			suite := &TestSuiteV1{
+				TestName:       "users",
+				TestSuiteTests: []TestSuiteTest{&namedTest{name: "create"}, &namedTest{name: "delete"}},
+				TestMetas:      map[string]TestMeta{"delete": {Skip: "flaky"}},
+			}
+
+			events := make(chan Event, 10)
+			run(expiredContext(t), []TestSuite{suite}, &RunOpts{}, events)
+			close(events)
+
+			var finished *SuiteFinishedEvent
+
+			for event := range events {
+				switch event := event.(type) {
+				case *SuiteErrorEvent:
+					t.Errorf("unexpected suite error: %v", event.Error)
+				case *TestEvent:
+					if event.TestName == "create" && (event.Type() != EventTestCompleted || !event.TimedOut) {
+						t.Errorf("expected create to time out, got %+v", event)
+					}
+
+					if event.TestName == "delete" && event.Type() != EventTestSkipped {
+						t.Errorf("expected delete to be skipped, got %+v", event)
+					}
+				case *SuiteFinishedEvent:
+					finished = event
+				}
+			}
+
+			if finished == nil {
+				t.Fatal("expected a suite finished event")
+			}
+
+			if finished.FailedCount != 1 || finished.SkippedCount != 1 {
+				t.Errorf("failed = %d, skipped = %d, want 1 and 1", finished.FailedCount, finished.SkippedCount)
+			}
+
+			if want := "test run timed out after 1ns"; finished.Message() != want {
+				t.Errorf("message = %q, want %q", finished.Message(), want)
+			}
+		})
+	}
+}
+
+func TestTimeoutYAML(t *testing.T) {
+	var meta TestMeta
+	if err := yaml.Unmarshal([]byte("name: a\ntimeout: 1m30s\n"), &meta); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if meta.Timeout != 90*time.Second {
+		t.Errorf("timeout = %v, want 1m30s", meta.Timeout)
+	}
+
+	for _, invalid := range []string{"name: a\ntimeout: soon\n", "name: a\ntimeout: 0s\n"} {
+		err := yaml.Unmarshal([]byte(invalid), &TestMeta{})
+		if err == nil || !strings.Contains(err.Error(), "timeout") {
+			t.Errorf("expected a timeout error for %q, got %v", invalid, err)
+		}
+	}
+}
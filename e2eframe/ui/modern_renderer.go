@@ -141,6 +141,18 @@ func (r *ModernRenderer) RenderSuiteFinished(suite SuiteFinishedInfo) error {
 		r.consecutivePassedTests = 0
 	}
 
+	// Skipped tests are only listed one by one in verbose mode
+	if r.mode == RenderModeNormal && suite.SkippedCount > 0 {
+		c := r.colors
+		skippedLine := fmt.Sprintf("  %s⊘%s  %d tests skipped\n",
+			c.Dim+c.Yellow, c.Reset,
+			suite.SkippedCount)
+		if err := r.write(skippedLine); err != nil {
+			return err
+		}
+		r.linesAfterHeader++
+	}
+
 	r.tracker.CompleteSuite(suite.Name)
 	c := r.colors
 
@@ -498,6 +510,35 @@ func (r *ModernRenderer) RenderTestCompleted(test TestInfo) error {
 	return nil
 }
 
+// RenderTestSkipped renders when a test is skipped
+func (r *ModernRenderer) RenderTestSkipped(test TestInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// In normal mode the skipped tests are summarized when the suite finishes
+	if r.mode != RenderModeVerbose {
+		return nil
+	}
+
+	if r.spinnerActive {
+		r.stopSpinnerLocked()
+	}
+
+	c := r.colors
+	reason := ""
+	if test.SkipReason != "" {
+		reason = fmt.Sprintf(" %s(%s)%s", c.Dim+c.Gray, test.SkipReason, c.Reset)
+	}
+
+	line := fmt.Sprintf("  %s⊘%s  %s%s%s%s\n",
+		c.Dim+c.Yellow, c.Reset,
+		c.White, test.Name, c.Reset,
+		reason)
+	r.linesAfterHeader++
+
+	return r.write(line)
+}
+
 // RenderWarning renders a warning message with proper formatting
 func (r *ModernRenderer) RenderWarning(message string) error {
 	r.mu.Lock()
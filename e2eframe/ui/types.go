@@ -37,6 +37,9 @@ type Renderer interface {
 	// RenderTestCompleted renders when a test completes
 	RenderTestCompleted(test TestInfo) error
 
+	// RenderTestSkipped renders when a test is skipped
+	RenderTestSkipped(test TestInfo) error
+
 	// RenderSuiteFinished renders when a suite finishes with timing breakdown
 	RenderSuiteFinished(suite SuiteFinishedInfo) error
 
@@ -95,6 +98,7 @@ type TestInfo struct {
 	RetryCount   int
 	MaxRetries   int
 	LogPaths     []string // Paths to saved log files (for failed tests)
+	SkipReason   string   // Why the test was skipped (for skipped tests)
 }
 
 // Summary contains the final test run summary
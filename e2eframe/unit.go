@@ -3,6 +3,7 @@ package e2eframe
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 
 	"github.com/testcontainers/testcontainers-go"
 	"gopkg.in/yaml.v3"
@@ -70,7 +71,8 @@ type UnitStartOptions struct {
 	CacheImages bool
 	// CleanupCache removes old cached images to prevent bloat
 	CleanupCache bool
-	// Fixtures is a list of fixtures to apply on interpolations.
+	// Fixtures is a list of fixtures to apply on interpolations,
+	// including the variables of the units started before this one.
 	Fixtures []Fixture
 	// EventSink is a channel to send events to.
 	EventSink EventSink
@@ -78,13 +80,57 @@ type UnitStartOptions struct {
 	WorkingDir string
 	// SuiteName is the name of the test suite (for log organization)
 	SuiteName string
+	// LogDir is the directory of the saved logs (`log_dir` of ene.yml), DefaultLogDir if empty
+	LogDir string
 	// CleanupRegistry is the central registry for tracking cleanable resources.
 	// Units should register their containers/networks here for coordinated cleanup.
 	CleanupRegistry *CleanupRegistry
 }
 
+// SuiteLogDir returns the directory of the saved logs of a suite, under logDir
+// or DefaultLogDir when it is empty.
+func SuiteLogDir(logDir, suiteName string) string {
+	if logDir == "" {
+		logDir = DefaultLogDir
+	}
+
+	return filepath.Join(logDir, suiteName)
+}
+
 type GetEnvRawOptions struct {
 	WorkingDir string
+	// Fixtures are the suite fixtures, referenced by the env_file path of units
+	Fixtures []Fixture
+}
+
+// Interpolate resolves the fixtures referenced by a configuration field read
+// before the unit starts, e.g. `env_file: .env.{{ stage }}`.
+func (o *GetEnvRawOptions) Interpolate(field string) string {
+	if o == nil {
+		return field
+	}
+
+	return InterpolateString(FixtureInterpolationRegex, field, o.Fixtures)
+}
+
+// InterpolateUnitFields replaces the references to fixtures and unit variables in
+// configuration fields of a unit, such as its image or command. Units call it in
+// Start with UnitStartOptions.Fixtures, once the units they depend on are started.
+func InterpolateUnitFields(fixtures []Fixture, fields ...*string) {
+	for _, field := range fields {
+		*field = InterpolateString(FixtureInterpolationRegex, *field, fixtures)
+	}
+}
+
+// StringFields returns pointers to the elements of a list field, e.g. a command,
+// to interpolate them with InterpolateUnitFields.
+func StringFields(values []string) []*string {
+	fields := make([]*string, len(values))
+	for i := range values {
+		fields[i] = &values[i]
+	}
+
+	return fields
 }
 
 type Unit interface {
@@ -0,0 +1,91 @@
+package e2eframe
+
+import "sync"
+
+// VariableFixture is implemented by fixtures exposing named variables rather than
+// a value, referenced as `{{ name.variable }}`.
+type VariableFixture interface {
+	Fixture
+	// Variable returns the value of a variable, false if it is unknown or empty.
+	Variable(name string) (string, bool)
+}
+
+// UnitFixture exposes the variables of a started unit, such as its host, port,
+// endpoint or dsn, e.g. `{{ postgres.dsn }}` or `{{ storage.endpoint }}`.
+// Each variable is read from the unit once, on first use, and kept: units are
+// not required to be safe for concurrent use, while tests running in parallel
+// share the same UnitFixture.
+type UnitFixture struct {
+	Unit Unit
+
+	mu        sync.Mutex
+	variables map[string]unitVariable
+}
+
+// unitVariable is a variable read from a unit, or the error reading it.
+type unitVariable struct {
+	value string
+	err   error
+}
+
+func (f *UnitFixture) Name() string {
+	return f.Unit.Name()
+}
+
+// Value is empty, units are only referenced through their variables.
+func (f *UnitFixture) Value() []byte {
+	return nil
+}
+
+// Get returns a variable of the unit, read from the unit on first use.
+func (f *UnitFixture) Get(name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if variable, ok := f.variables[name]; ok {
+		return variable.value, variable.err
+	}
+
+	value, err := f.Unit.Get(name)
+
+	if f.variables == nil {
+		f.variables = make(map[string]unitVariable)
+	}
+
+	f.variables[name] = unitVariable{value: value, err: err}
+
+	return value, err
+}
+
+func (f *UnitFixture) Variable(name string) (string, bool) {
+	value, err := f.Get(name)
+	if err != nil || value == "" {
+		return "", false
+	}
+
+	return value, true
+}
+
+// UnitFixtures returns the fixtures exposing the variables of the units.
+func UnitFixtures(units []Unit) []Fixture {
+	fixtures := make([]Fixture, 0, len(units))
+
+	for _, unit := range units {
+		if unit != nil {
+			fixtures = append(fixtures, &UnitFixture{Unit: unit})
+		}
+	}
+
+	return fixtures
+}
+
+// unitFixture returns the fixture of the unit named name, nil if there is none.
+func unitFixture(fixtures []Fixture, name string) *UnitFixture {
+	for _, fixture := range fixtures {
+		if unit, ok := fixture.(*UnitFixture); ok && unit.Name() == name {
+			return unit
+		}
+	}
+
+	return nil
+}
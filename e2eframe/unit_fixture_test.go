@@ -0,0 +1,190 @@
+package e2eframe
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestTestsSeeUnitVariables(t *testing.T) {
+	suite := &TestSuiteV1{
+		TestUnits: []Unit{
+			&graphUnit{name: "storage", vars: map[string]string{"endpoint": "http://localhost:9000"}},
+			&graphUnit{name: "config", vars: map[string]string{"endpoint": "http://config:8080"}},
+		},
+		Fixtures: []Fixture{
+			&FixtureV1{FixtureName: "config", FixtureValue: `{"db": "users"}`},
+			&FixtureV1{FixtureName: "bucket", FixtureValue: "avatars"},
+		},
+	}
+
+	fixtures := suite.testFixtures(&namedTest{name: "upload"})
+
+	tests := []struct {
+		str  string
+		want string
+	}{
+		{str: "{{ storage.endpoint }}/{{ bucket }}", want: "http://localhost:9000/avatars"},
+		{str: "{{ storage.endpoint | urlencode }}", want: "http%3A%2F%2Flocalhost%3A9000"},
+		// Unit variables take precedence, other paths fall through to the fixture with the same name
+		{str: "{{ config.endpoint }} {{ config.db }}", want: "http://config:8080 users"},
+		{str: "{{ config }}", want: `{"db": "users"}`},
+		{str: "{{ storage.missing }} {{ storage }}", want: "{{ storage.missing }} {{ storage }}"},
+	}
+
+	for _, tt := range tests {
+		if got := InterpolateString(FixtureInterpolationRegex, tt.str, fixtures); got != tt.want {
+			t.Errorf("InterpolateString(%q) = %q, want %q", tt.str, got, tt.want)
+		}
+	}
+}
+
+func TestUnitsSeeVariablesOfUnitsStartedBefore(t *testing.T) {
+	db := &graphUnit{name: "db", vars: map[string]string{"dsn": "postgres://db:5432/app"}}
+	mock := &graphUnit{name: "mock", vars: map[string]string{"endpoint": "http://localhost:8081"}}
+
+	suite := &TestSuiteV1{
+		TestUnits:     []Unit{mock, db},
+		UnitDependsOn: map[string][]string{"mock": {"db"}},
+	}
+
+	levels, err := suite.unitStartupLevels(nil)
+	if err != nil {
+		t.Fatalf("unit startup levels: %v", err)
+	}
+
+	if err := suite.interpolateVarsAndStartUnits(context.Background(), &RunTestOptions{}, levels, nil, nil); err != nil {
+		t.Fatalf("start units: %v", err)
+	}
+
+	if got := InterpolateString(FixtureInterpolationRegex, "{{ db.dsn }}", mock.fixtures); got != "postgres://db:5432/app" {
+		t.Errorf("mock sees {{ db.dsn }} = %q", got)
+	}
+
+	if got := InterpolateString(FixtureInterpolationRegex, "{{ mock.endpoint }}", db.fixtures); got != "{{ mock.endpoint }}" {
+		t.Errorf("db must not see the variables of units started after it, got %q", got)
+	}
+}
+
+// countingUnit counts the calls to Get, without synchronization as units do not need any.
+type countingUnit struct {
+	graphUnit
+	gets int
+}
+
+func (u *countingUnit) Get(key string) (string, error) {
+	u.gets++
+
+	return u.graphUnit.Get(key)
+}
+
+func TestUnitVariablesAreReadOnceForAllTests(t *testing.T) {
+	db := &countingUnit{graphUnit: graphUnit{name: "db", vars: map[string]string{"dsn": "postgres://db:5432/app", "host": "localhost"}}}
+	app := &graphUnit{name: "app", env: map[string]string{"DATABASE_URL": "{{ db.dsn }}"}}
+
+	suite := &TestSuiteV1{TestUnits: []Unit{app, db}}
+
+	deps, err := suite.calculateEnvDependencies()
+	if err != nil {
+		t.Fatalf("calculate env dependencies: %v", err)
+	}
+
+	levels, err := suite.unitStartupLevels(deps)
+	if err != nil {
+		t.Fatalf("unit startup levels: %v", err)
+	}
+
+	if err := suite.interpolateVarsAndStartUnits(context.Background(), &RunTestOptions{}, levels, deps, nil); err != nil {
+		t.Fatalf("start units: %v", err)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := range 20 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			fixtures := suite.testFixtures(&namedTest{name: fmt.Sprintf("test %d", i)})
+			if got := InterpolateString(FixtureInterpolationRegex, "{{ db.dsn }} {{ db.host }}", fixtures); got != "postgres://db:5432/app localhost" {
+				t.Errorf("test %d sees %q", i, got)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if db.gets != 2 {
+		t.Errorf("Get called %d times, want once per variable", db.gets)
+	}
+}
+
+func TestUnitConfigurationReferencesAddDependencies(t *testing.T) {
+	cfg, _, err := loadSuiteConfig(t, map[string]string{
+		"suite.yml": `
+kind: e2e_test:v1
+name: users
+fixtures:
+  - app_version: "1.2.3"
+units:
+  - name: registry
+    kind: stub
+  - name: cache
+    kind: stub
+  - name: app
+    kind: stub
+    image: "{{ registry.host }}:5000/app:{{ app_version }}"
+    depends_on: [cache]
+target: app
+tests:
+  - name: ping
+    kind: stub
+`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dependsOn := cfg.referencedUnits()
+	if got := dependsOn["app"]; !slices.Equal(got, []string{"cache", "registry"}) {
+		t.Errorf("app depends on %v, want [cache registry]", got)
+	}
+
+	if got := cfg.UnitDependsOn["app"]; !slices.Equal(got, []string{"cache"}) {
+		t.Errorf("configured depends_on changed to %v", got)
+	}
+}
+
+func TestInterpolateUnitFields(t *testing.T) {
+	fixtures := append(
+		UnitFixtures([]Unit{&graphUnit{name: "registry", vars: map[string]string{"host": "10.0.0.2"}}}),
+		&FixtureV1{FixtureName: "stage", FixtureValue: "ci"},
+	)
+
+	image := "{{ registry.host }}:5000/app:{{ stage }}"
+	cmd := []string{"./server", "--env={{ stage | upper }}", "{{ missing }}"}
+
+	InterpolateUnitFields(fixtures, &image)
+	InterpolateUnitFields(fixtures, StringFields(cmd)...)
+
+	if image != "10.0.0.2:5000/app:ci" {
+		t.Errorf("image = %q", image)
+	}
+
+	if want := []string{"./server", "--env=CI", "{{ missing }}"}; !slices.Equal(cmd, want) {
+		t.Errorf("cmd = %v, want %v", cmd, want)
+	}
+
+	var opts *GetEnvRawOptions
+	if got := opts.Interpolate(".env.{{ stage }}"); got != ".env.{{ stage }}" {
+		t.Errorf("nil options interpolated the env file to %q", got)
+	}
+
+	opts = &GetEnvRawOptions{Fixtures: fixtures}
+	if got := opts.Interpolate(".env.{{ stage }}"); got != ".env.ci" {
+		t.Errorf("env file = %q, want .env.ci", got)
+	}
+}
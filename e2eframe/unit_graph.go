@@ -0,0 +1,200 @@
+package e2eframe
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CircularDependencyError is returned when units depend on each other in a cycle.
+type CircularDependencyError struct {
+	// Path lists the units forming the cycle, starting and ending with the same unit.
+	Path []string
+}
+
+func (e *CircularDependencyError) Error() string {
+	return fmt.Sprintf("circular dependency between units: %s", strings.Join(e.Path, " -> "))
+}
+
+func (e *CircularDependencyError) UserFriendlyMessage() string {
+	return fmt.Sprintf(
+		"circular dependency between units: %s\n"+
+			"  → Units cannot depend on each other through env interpolation or depends_on\n"+
+			"  → Break the cycle by removing one of the references",
+		strings.Join(e.Path, " -> "),
+	)
+}
+
+// UnitDependencyNotFoundError is returned when a unit depends on a unit that is not defined in the suite.
+type UnitDependencyNotFoundError struct {
+	Unit       string
+	Dependency string
+}
+
+func (e *UnitDependencyNotFoundError) Error() string {
+	return fmt.Sprintf("unit %s depends on unknown unit %s", e.Unit, e.Dependency)
+}
+
+// unitGraph is the dependency graph of the units of a test suite.
+// An edge from A to B means that A must be started and ready before B starts.
+type unitGraph struct {
+	units []Unit
+	// dependencies maps a unit name to the names of the units it depends on
+	dependencies map[string][]string
+}
+
+// newUnitGraph builds the dependency graph from the env var dependencies
+// and the explicit depends_on lists of the units.
+func newUnitGraph(
+	units []Unit,
+	envDependencies []EnvDependency,
+	dependsOn map[string][]string,
+) (*unitGraph, error) {
+	g := &unitGraph{
+		dependencies: make(map[string][]string),
+	}
+
+	known := make(map[string]bool)
+
+	for _, unit := range units {
+		if unit == nil {
+			continue
+		}
+
+		g.units = append(g.units, unit)
+		known[unit.Name()] = true
+	}
+
+	addEdge := func(dependant, dependency string) error {
+		if !known[dependant] {
+			return fmt.Errorf("unit %s not found", dependant)
+		}
+
+		if !known[dependency] {
+			return &UnitDependencyNotFoundError{Unit: dependant, Dependency: dependency}
+		}
+
+		for _, existing := range g.dependencies[dependant] {
+			if existing == dependency {
+				return nil
+			}
+		}
+
+		g.dependencies[dependant] = append(g.dependencies[dependant], dependency)
+
+		return nil
+	}
+
+	for _, dep := range envDependencies {
+		// Fixtures are resolved before any unit starts, they do not order units
+		if dep.IsFixture {
+			continue
+		}
+
+		if err := addEdge(dep.DependantUnitName, dep.DependencyUnitName); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, unit := range g.units {
+		for _, dependency := range dependsOn[unit.Name()] {
+			if err := addEdge(unit.Name(), dependency); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// levels groups the units into startup levels. Every unit only depends on units
+// from earlier levels, so the units of a level can be started concurrently.
+// Units keep their declaration order within a level.
+func (g *unitGraph) levels() ([][]Unit, error) {
+	if cycle := g.findCycle(); cycle != nil {
+		return nil, &CircularDependencyError{Path: cycle}
+	}
+
+	level := make(map[string]int, len(g.units))
+
+	var depth func(name string) int
+	depth = func(name string) int {
+		if l, ok := level[name]; ok {
+			return l
+		}
+
+		l := 0
+		for _, dependency := range g.dependencies[name] {
+			if d := depth(dependency) + 1; d > l {
+				l = d
+			}
+		}
+
+		level[name] = l
+
+		return l
+	}
+
+	var levels [][]Unit
+
+	for _, unit := range g.units {
+		l := depth(unit.Name())
+		for len(levels) <= l {
+			levels = append(levels, nil)
+		}
+
+		levels[l] = append(levels[l], unit)
+	}
+
+	return levels, nil
+}
+
+// findCycle returns the path of the first dependency cycle found, or nil if the graph is acyclic.
+func (g *unitGraph) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(g.units))
+
+	var stack []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		stack = append(stack, name)
+
+		for _, dependency := range g.dependencies[name] {
+			switch state[dependency] {
+			case visiting:
+				for i, n := range stack {
+					if n == dependency {
+						cycle := append([]string{}, stack[i:]...)
+
+						return append(cycle, dependency)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+
+		return nil
+	}
+
+	for _, unit := range g.units {
+		if state[unit.Name()] == unvisited {
+			if cycle := visit(unit.Name()); cycle != nil {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
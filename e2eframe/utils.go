@@ -4,16 +4,14 @@ import (
 	"fmt"
 	"net"
 	"os"
-	"regexp"
 )
 
-// InterpolateEnvs gets the envCfg,
-// checks if any value has an interpolation request (e.g. `{{ service.var }}` ),
-// requests the variable from the corresponding service,
-// and replaces it in the original envCfg.
+// InterpolateEnvs replaces the references to service variables in the values of
+// envCfg, e.g. `amqp://{{ rabbit.user }}@{{ rabbit.host }}:{{ rabbit.port }}/vh`.
+// A value may hold any number of references mixed with text and piped into
+// template functions.
 func InterpolateEnvs(services []Unit, envCfg map[string]any) error {
-	// {{ <service_name>.<variable> }}
-	envRegex := regexp.MustCompile(`^\{\{ ([A-Za-z0-9_]+)\.([A-Za-z0-9_]+) }}$`)
+	fixtures := UnitFixtures(services)
 
 	for key, value := range envCfg {
 		valueStr, ok := value.(string)
@@ -21,24 +19,31 @@ func InterpolateEnvs(services []Unit, envCfg map[string]any) error {
 			continue
 		}
 
-		submatch := envRegex.FindStringSubmatch(valueStr)
-		serviceName := submatch[1]
-		variableName := submatch[2]
+		// {{ <service_name>.<variable> }}
+		for _, submatch := range ServiceVariableInterpolationRegex.FindAllStringSubmatch(valueStr, -1) {
+			serviceName, variableName := submatch[1], submatch[2]
 
-		for _, service := range services {
-			if service.Name() == serviceName {
-				variableValue, err := service.Get(variableName)
-				if err != nil {
+			for _, service := range services {
+				if service.Name() != serviceName {
+					continue
+				}
+
+				if _, err := service.Get(variableName); err != nil {
 					return fmt.Errorf(
 						"interpolate environment variable %s failed: %s",
 						variableName,
 						err,
 					)
 				}
-
-				envCfg[key] = variableValue
 			}
 		}
+
+		interpolated, err := InterpolateStringErr(FixtureInterpolationRegex, valueStr, fixtures)
+		if err != nil {
+			return fmt.Errorf("interpolate environment variable %s: %w", key, err)
+		}
+
+		envCfg[key] = interpolated
 	}
 
 	return nil
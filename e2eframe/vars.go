@@ -0,0 +1,209 @@
+package e2eframe
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SuiteVar is a variable of the `vars:` section, computed once every unit is ready.
+// Tests reference it like a fixture, e.g. `{{ api_base }}`.
+type SuiteVar struct {
+	Name string
+	// Expression is interpolated with the fixtures, the unit variables and the
+	// vars defined before it, e.g. `{{ app.host }}:{{ app.port }}/v2`
+	Expression string
+	// Query is a one-off test, such as an HTTP call or a SQL query, whose
+	// `capture:` becomes the value of the variable
+	Query TestSuiteTest
+}
+
+// decodeVars reads the `vars:` section, as a list of single-key mappings or as a mapping.
+func (t *TestSuiteConfigV1) decodeVars(value *yaml.Node) error {
+	entries, err := t.varEntries("vars", value)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(entries); i += 2 {
+		v, err := t.decodeVar(entries[i], entries[i+1])
+		if err != nil {
+			return err
+		}
+
+		if err := t.addVar(*v, t.location(entries[i])); err != nil {
+			return err
+		}
+
+		t.collectReferences(entries[i+1], referenceOwner{variable: v.Name})
+	}
+
+	return nil
+}
+
+// varEntries returns the name and value nodes of a section in the format of
+// `vars:`, a list of single-key mappings or a mapping.
+func (t *TestSuiteConfigV1) varEntries(section string, value *yaml.Node) ([]*yaml.Node, error) {
+	switch value.Kind {
+	case yaml.SequenceNode:
+		var entries []*yaml.Node
+
+		for _, item := range value.Content {
+			if item.Kind != yaml.MappingNode || len(item.Content) != 2 {
+				return nil, fmt.Errorf("entry of %s at %s must be a single 'name: value' mapping", section, t.location(item))
+			}
+
+			entries = append(entries, item.Content...)
+		}
+
+		return entries, nil
+	case yaml.MappingNode:
+		return value.Content, nil
+	default:
+		return nil, fmt.Errorf("%s must be either a sequence (array) or mapping (object), got: %v", section, value.Kind)
+	}
+}
+
+// decodeVar decodes a var: an expression, or a test of any kind with a `capture:`
+// naming the location of the value in its response.
+func (t *TestSuiteConfigV1) decodeVar(key, value *yaml.Node) (*SuiteVar, error) {
+	if !CaptureNameRegex.MatchString(key.Value) {
+		return nil, fmt.Errorf(
+			"invalid var name %q at %s: only letters, digits and underscores are allowed",
+			key.Value,
+			t.location(key),
+		)
+	}
+
+	switch value.Kind {
+	case yaml.ScalarNode:
+		return &SuiteVar{Name: key.Value, Expression: value.Value}, nil
+	case yaml.MappingNode:
+	default:
+		return nil, fmt.Errorf("var %s at %s must be an expression or a query", key.Value, t.location(key))
+	}
+
+	_, kindNode := mappingField(value, "kind")
+	_, captureNode := mappingField(value, "capture")
+
+	if kindNode == nil || captureNode == nil || captureNode.Kind != yaml.ScalarNode || captureNode.Value == "" {
+		return nil, &DetailedError{
+			Message: fmt.Sprintf("var %s at %s must have a kind and the capture of its value", key.Value, t.location(key)),
+			File:    t.SourceFile,
+			Line:    key.Line,
+			Examples: []string{
+				"vars:",
+				"  - admin_token:",
+				"      kind: http",
+				"      request:",
+				"        method: POST",
+				"        path: /admin/login",
+				"      capture: body.token",
+			},
+		}
+	}
+
+	// The query is a test named after the var, capturing the var
+	query := &yaml.Node{Kind: yaml.MappingNode, Line: value.Line, Column: value.Column}
+	query.Content = append(query.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: "name"},
+		&yaml.Node{Kind: yaml.ScalarNode, Value: key.Value},
+	)
+
+	for i := 0; i < len(value.Content); i += 2 {
+		switch value.Content[i].Value {
+		case "name":
+		case "capture":
+			query.Content = append(query.Content, value.Content[i], &yaml.Node{
+				Kind:    yaml.MappingNode,
+				Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: key.Value}, captureNode},
+			})
+		default:
+			query.Content = append(query.Content, value.Content[i], value.Content[i+1])
+		}
+	}
+
+	test, err := UnmarshallTestSuiteTest(TestSuiteTestKind(kindNode.Value), query)
+	if err != nil {
+		return nil, fmt.Errorf("var %s at %s: %w", key.Value, t.location(key), err)
+	}
+
+	return &SuiteVar{Name: key.Value, Query: test}, nil
+}
+
+// addVar adds a var to the suite, replacing an inherited var with the same name.
+func (t *TestSuiteConfigV1) addVar(v SuiteVar, location sourceLocation) error {
+	if err := t.define("var", v.Name, location); err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(t.Vars, func(other SuiteVar) bool { return other.Name == v.Name })
+	if index >= 0 {
+		t.Vars[index] = v
+		t.dropReferences(referenceOwner{variable: v.Name})
+	} else {
+		t.Vars = append(t.Vars, v)
+	}
+
+	return nil
+}
+
+// evaluateVars computes the suite vars in order. Each var sees the units, the
+// fixtures and the vars defined before it.
+func (t *TestSuiteV1) evaluateVars(ctx context.Context, opts *RunTestOptions) error {
+	t.vars = nil
+
+	for _, v := range t.Vars {
+		value, err := t.evaluateVar(ctx, v, t.runtimeFixtures(), opts)
+		if err != nil {
+			return fmt.Errorf("var %s: %w", v.Name, err)
+		}
+
+		t.vars = append(t.vars, &CapturedFixture{CaptureName: v.Name, CaptureValue: value})
+	}
+
+	return nil
+}
+
+// evaluateVar computes the value of a var, or of a `before:` step of a test, with the given fixtures.
+func (t *TestSuiteV1) evaluateVar(ctx context.Context, v SuiteVar, fixtures []Fixture, opts *RunTestOptions) (string, error) {
+	if v.Query == nil {
+		value, err := InterpolateStringErr(FixtureInterpolationRegex, v.Expression, fixtures)
+		if err != nil {
+			return "", err
+		}
+
+		if match := FixtureInterpolationRegex.FindString(value); match != "" {
+			return "", fmt.Errorf("unresolved reference %s", match)
+		}
+
+		return value, nil
+	}
+
+	if err := v.Query.Initialize(t); err != nil {
+		return "", fmt.Errorf("initialize %s query: %w", v.Query.Kind(), err)
+	}
+
+	result, err := v.Query.Run(ctx, &TestSuiteTestRunOptions{
+		Verbose:      opts.Verbose,
+		Debug:        t.Debug,
+		Fixtures:     fixtures,
+		RelativePath: t.RelativePath,
+	})
+	if err != nil {
+		return "", fmt.Errorf("run %s query: %w", v.Query.Kind(), err)
+	}
+
+	if !result.Passed {
+		return "", fmt.Errorf("%s query failed: %s", v.Query.Kind(), result.MessageOrErr())
+	}
+
+	value, ok := result.Captured[v.Name]
+	if !ok {
+		return "", fmt.Errorf("%s query captured no value", v.Query.Kind())
+	}
+
+	return value, nil
+}
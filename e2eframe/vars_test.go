@@ -0,0 +1,181 @@
+package e2eframe
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const queryTestKind TestSuiteTestKind = "lookup"
+
+// queryTest captures its interpolated response.
+type queryTest struct {
+	TestName string   `yaml:"name"`
+	Response string   `yaml:"response"`
+	Capture  Captures `yaml:"capture"`
+}
+
+func (q *queryTest) Name() string { return q.TestName }
+func (q *queryTest) Kind() string { return string(queryTestKind) }
+func (q *queryTest) Run(_ context.Context, opts *TestSuiteTestRunOptions) (*TestResult, error) {
+	response := InterpolateString(FixtureInterpolationRegex, q.Response, opts.Fixtures)
+	if response == "" {
+		return &TestResult{TestName: q.TestName, Message: "empty response"}, nil
+	}
+
+	captured := make(map[string]string, len(q.Capture))
+	for name := range q.Capture {
+		captured[name] = response
+	}
+
+	return &TestResult{TestName: q.TestName, Passed: true, Captured: captured}, nil
+}
+func (q *queryTest) UnmarshalYAML(node *yaml.Node) error {
+	type plain queryTest
+	return node.Decode((*plain)(q))
+}
+func (q *queryTest) Initialize(_ TestSuite) error { return nil }
+
+func init() {
+	RegisterTestSuiteTestUnmarshaler(queryTestKind, func(node *yaml.Node) (TestSuiteTest, error) {
+		test := &queryTest{}
+		if err := test.UnmarshalYAML(node); err != nil {
+			return nil, err
+		}
+
+		return test, nil
+	})
+}
+
+func TestSuiteVarsAreComputedInOrder(t *testing.T) {
+	cfg, _, err := loadSuiteConfig(t, map[string]string{
+		"suite.yml": `
+kind: e2e_test:v1
+name: users
+fixtures:
+  - password: s3cret
+vars:
+  - api_base: "{{ app.host }}:{{ app.port }}/v2"
+  - admin_token:
+      kind: lookup
+      response: "token-{{ password | upper }}"
+      capture: body.token
+  - auth: "Bearer {{ admin_token }}"
+units:
+  - name: app
+    kind: stub
+target: app
+tests:
+  - name: me
+    kind: stub
+    path: "{{ api_base }}/me?auth={{ auth }}"
+`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(cfg.Vars) != 3 || cfg.Vars[1].Query == nil || cfg.Vars[1].Query.Name() != "admin_token" {
+		t.Fatalf("unexpected vars: %+v", cfg.Vars)
+	}
+
+	suite := &TestSuiteV1{
+		TestUnits: []Unit{&graphUnit{name: "app", vars: map[string]string{"host": "localhost", "port": "8080"}}},
+		Fixtures:  cfg.Fixtures,
+		Vars:      cfg.Vars,
+	}
+
+	if err := suite.evaluateVars(context.Background(), &RunTestOptions{}); err != nil {
+		t.Fatalf("evaluate vars: %v", err)
+	}
+
+	fixtures := suite.testFixtures(&namedTest{name: "me"})
+	if got, want := InterpolateString(FixtureInterpolationRegex, "{{ api_base }} {{ auth }}", fixtures), "localhost:8080/v2 Bearer token-S3CRET"; got != want {
+		t.Errorf("vars = %q, want %q", got, want)
+	}
+}
+
+func TestSuiteVarErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		vars    string
+		wantErr string
+	}{
+		{
+			name:    "reference to an unknown value",
+			vars:    `  - api_base: "{{ app.hots }}/v2"`,
+			wantErr: "var api_base: interpolation error: {{ app.hots }}: app.hots not found in app",
+		},
+		{
+			name:    "later var",
+			vars:    "  - auth: \"{{ token }}\"\n  - token: abc",
+			wantErr: "var auth: unresolved reference {{ token }}",
+		},
+		{
+			name:    "failing query",
+			vars:    "  - token:\n      kind: lookup\n      response: \"\"\n      capture: body.token",
+			wantErr: "var token: lookup query failed: empty response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, _, err := loadSuiteConfig(t, map[string]string{
+				"suite.yml": "kind: e2e_test:v1\nname: users\nvars:\n" + tt.vars + `
+units:
+  - name: app
+    kind: stub
+target: app
+tests:
+  - name: me
+    kind: stub
+`,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			suite := &TestSuiteV1{
+				TestUnits: []Unit{&graphUnit{name: "app", vars: map[string]string{"host": "localhost"}}},
+				Vars:      cfg.Vars,
+			}
+
+			if err := suite.evaluateVars(context.Background(), &RunTestOptions{}); err == nil || err.Error() != tt.wantErr {
+				t.Errorf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSuiteVarDecodingErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		vars    string
+		wantErr string
+	}{
+		{name: "invalid name", vars: "  - api.base: x", wantErr: `invalid var name "api.base"`},
+		{name: "query without capture", vars: "  - token:\n      kind: lookup", wantErr: "var token at"},
+		{name: "unknown fixture", vars: `  - auth: "{{ tokn }}"`, wantErr: `unknown fixture "tokn" referenced by {{ tokn }} in var "auth"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := loadSuiteConfig(t, map[string]string{
+				"suite.yml": "kind: e2e_test:v1\nname: users\nfixtures:\n  - token: abc\nvars:\n" + tt.vars + `
+units:
+  - name: app
+    kind: stub
+target: app
+tests:
+  - name: me
+    kind: stub
+`,
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
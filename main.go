@@ -5,10 +5,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -50,21 +54,51 @@ var rootCmd = &cobra.Command{
 	Version: version,
 	Args:    cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		baseDir := cmd.Flag("base-dir").Value.String()
+		envFile := cmd.Flag("env-file").Value.String()
+		profile := cmd.Flag("profile").Value.String()
+		root := cmd.Flag("root").Value.String()
+		suiteFiles := suiteFilesFlag(cmd)
+
+		// Prioritize positional argument over --base-dir flag
+		if len(args) > 0 {
+			baseDir = args[0]
+		}
+
+		if envFile != "" {
+			if err := e2eframe.LoadEnvFile(envFile); err != nil {
+				fmt.Printf("%s%s✖ ERROR: %v%s\n", colorBold, colorRed, err, colorReset)
+				os.Exit(1)
+			}
+		}
+
+		// ene.yml and its selected profile give their defaults to the flags not set on the command line
+		baseDir, err := applyProjectConfig(cmd, baseDir, profile)
+		if err != nil {
+			fmt.Printf("%s%s✖ ERROR: %v%s\n", colorBold, colorRed, err, colorReset)
+			os.Exit(1)
+		}
+
 		verbose := cmd.Flag("verbose").Value.String()
 		pretty := cmd.Flag("pretty").Value.String()
 		parallel := cmd.Flag("parallel").Value.String()
+		jobsFlag := cmd.Flag("jobs").Value.String()
 		suiteFlag := cmd.Flag("suite").Value.String()
 		debug := cmd.Flag("debug").Value.String()
 		cleanupCache := cmd.Flag("cleanup-cache").Value.String()
 		suitesFilter := strings.Split(suiteFlag, ",")
 		htmlReportPath := cmd.Flag("html").Value.String()
 		jsonReportPath := cmd.Flag("json").Value.String()
-		baseDir := cmd.Flag("base-dir").Value.String()
-
-		// Prioritize positional argument over --base-dir flag
-		if len(args) > 0 {
-			baseDir = args[0]
-		}
+		junitReportPath := cmd.Flag("junit").Value.String()
+		retriesFlag := cmd.Flag("retries").Value.String()
+		retryDelay := cmd.Flag("retry-delay").Value.String()
+		retryBackoff := e2eframe.RetryBackoff(cmd.Flag("retry-backoff").Value.String())
+		retryOnFlag := cmd.Flag("retry-on").Value.String()
+		timeoutFlag := cmd.Flag("timeout").Value.String()
+		tagsFlag := cmd.Flag("tags").Value.String()
+		testFlag := cmd.Flag("test").Value.String()
+		shardFlag := cmd.Flag("shard").Value.String()
+		shardDurationsPath := cmd.Flag("shard-durations").Value.String()
 
 		isVerbose := verbose == "true"
 		isPretty := pretty == "true"
@@ -87,8 +121,80 @@ var rootCmd = &cobra.Command{
 			return false
 		}
 
+		maxRetries, err := strconv.Atoi(retriesFlag)
+		if err != nil || maxRetries < 0 {
+			fmt.Printf("%s%s✖ ERROR: invalid --retries %q: must be a non-negative number%s\n", colorBold, colorRed, retriesFlag, colorReset)
+			os.Exit(1)
+		}
+
+		retryOn, err := e2eframe.ParseRetryConditions(retryOnFlag)
+		if err != nil {
+			fmt.Printf("%s%s✖ ERROR: %v%s\n", colorBold, colorRed, err, colorReset)
+			os.Exit(1)
+		}
+
+		retryDefaults := e2eframe.RetryPolicy{Delay: retryDelay, Backoff: retryBackoff, On: retryOn}
+		if err := retryDefaults.Validate(); err != nil {
+			fmt.Printf("%s%s✖ ERROR: %v%s\n", colorBold, colorRed, err, colorReset)
+			os.Exit(1)
+		}
+
+		jobs, err := strconv.Atoi(jobsFlag)
+		if err != nil || jobs < 0 {
+			fmt.Printf("%s%s✖ ERROR: invalid --jobs %q: must be a non-negative number%s\n", colorBold, colorRed, jobsFlag, colorReset)
+			os.Exit(1)
+		}
+
+		runTimeout, err := time.ParseDuration(timeoutFlag)
+		if err != nil || runTimeout < 0 {
+			fmt.Printf("%s%s✖ ERROR: invalid --timeout %q: expected a duration such as 30m%s\n", colorBold, colorRed, timeoutFlag, colorReset)
+			os.Exit(1)
+		}
+
+		tagFilter, err := e2eframe.ParseTagFilter(tagsFlag)
+		if err != nil {
+			fmt.Printf("%s%s✖ ERROR: %v%s\n", colorBold, colorRed, err, colorReset)
+			os.Exit(1)
+		}
+
+		var testPattern *regexp.Regexp
+		if testFlag != "" {
+			testPattern, err = regexp.Compile(testFlag)
+			if err != nil {
+				fmt.Printf("%s%s✖ ERROR: invalid --test pattern: %v%s\n", colorBold, colorRed, err, colorReset)
+				os.Exit(1)
+			}
+		}
+
+		shard, err := e2eframe.ParseShard(shardFlag)
+		if err != nil {
+			fmt.Printf("%s%s✖ ERROR: %v%s\n", colorBold, colorRed, err, colorReset)
+			os.Exit(1)
+		}
+
+		if shardDurationsPath != "" {
+			if !shard.IsEnabled() {
+				fmt.Printf("%s%s✖ ERROR: --shard-durations requires --shard%s\n", colorBold, colorRed, colorReset)
+				os.Exit(1)
+			}
+
+			// The report of a previous run may not exist yet, e.g. on the first CI run
+			shard.Durations, err = e2eframe.LoadSuiteDurations(shardDurationsPath)
+			if errors.Is(err, fs.ErrNotExist) {
+				fmt.Printf("%s⚠ %s not found, balancing shards by number of suites%s\n", colorYellow, shardDurationsPath, colorReset)
+			} else if err != nil {
+				fmt.Printf("%s%s✖ ERROR: %v%s\n", colorBold, colorRed, err, colorReset)
+				os.Exit(1)
+			}
+		}
+
 		// Count total suites that will be run (for progress tracking)
-		totalSuites, err := e2eframe.CountFilteredTestSuites(baseDir, shouldIncludeTest)
+		totalSuites, err := e2eframe.CountFilteredTestSuites(
+			baseDir,
+			e2eframe.LoadOptions{Profile: profile, Root: root, SuiteFiles: suiteFiles},
+			shouldIncludeTest,
+			shard,
+		)
 		if err != nil {
 			fmt.Printf("%s%s✖ ERROR: %v%s\n", colorBold, colorRed, err, colorReset)
 			os.Exit(1)
@@ -98,8 +204,6 @@ var rootCmd = &cobra.Command{
 		flushableSink, eventChan := e2eframe.NewFlushableEventSink(100)
 		var eventSink e2eframe.EventSink = eventChan
 
-		// Use optimized defaults for better performance
-		maxRetries := 3 // Keep reliable retry behavior
 		// Default to keeping cached images for faster rebuilds
 		// Only cleanup when explicitly requested via --cleanup-cache flag
 
@@ -132,13 +236,23 @@ var rootCmd = &cobra.Command{
 			FilterFunc:      shouldIncludeTest,
 			Verbose:         isVerbose,
 			Parallel:        isParallel,
+			Jobs:            jobs,
 			Events:          eventSink,
 			FlushableEvents: flushableSink,
 			MaxRetries:      maxRetries,
-			RetryDelay:      "2s",
+			RetryDelay:      retryDelay,
+			RetryBackoff:    retryBackoff,
+			RetryOn:         retryOn,
 			Debug:           isDebug,
 			BaseDir:         baseDir,
 			CleanupCache:    isCleanupCache,
+			Tags:            tagFilter,
+			TestPattern:     testPattern,
+			Timeout:         runTimeout,
+			Shard:           shard,
+			Profile:         profile,
+			Root:            root,
+			SuiteFiles:      suiteFiles,
 		})
 		if err != nil {
 			fmt.Printf("%s%s✖ ERROR: %v%s\n", colorBold, colorRed, err, colorReset)
@@ -185,6 +299,19 @@ var rootCmd = &cobra.Command{
 			consumers = append(consumers, jsonConsumer)
 		}
 
+		if junitReportPath != "" {
+			junitConsumer, err := e2eframe.NewJUnitReportProcessor(e2eframe.JUnitReportProcessorParams{
+				OutputFile:     junitReportPath,
+				TestsSecretary: testsSecretary,
+			})
+			if err != nil {
+				fmt.Printf("%s%s✖ ERROR: %v%s\n", colorBold, colorRed, err, colorReset)
+
+				return
+			}
+			consumers = append(consumers, junitConsumer)
+		}
+
 		// Process events
 		for event := range eventChan {
 			// Handle flush tokens for event ordering
@@ -241,7 +368,7 @@ var scaffoldTestCmd = &cobra.Command{
 	Use:   "scaffold-test [name]",
 	Args:  cobra.ExactArgs(1),
 	Short: "Scaffold a new test",
-	Long:  `Create a new test suite under ./tests/<n>`,
+	Long:  `Create a new test suite under ./tests/<n>, or the tests_dir of ene.yml`,
 	Run: func(cmd *cobra.Command, args []string) {
 		tmpl := cmd.Flag("tmpl").Value.String()
 
@@ -255,8 +382,16 @@ var scaffoldTestCmd = &cobra.Command{
 			templates = []string{"mongo", "httpmock"} // Default templates if none specified
 		}
 
+		// Suites are created in the tests_dir of ene.yml, ./tests by default
+		project, err := e2eframe.LoadProjectConfig("", "")
+		if err != nil {
+			fmt.Println("Error scaffolding test:", err)
+
+			return
+		}
+
 		testName := args[0]
-		if err := ScaffoldTest(testName, templates); err != nil {
+		if err := ScaffoldTest(project.TestsPath(), testName, templates); err != nil {
 			fmt.Println("Error scaffolding test:", err)
 
 			return
@@ -274,10 +409,21 @@ var dryRunCmd = &cobra.Command{
 		verbose := cmd.Flag("verbose").Value.String()
 		debug := cmd.Flag("debug").Value.String()
 		baseDir := cmd.Flag("base-dir").Value.String()
+		envFile := cmd.Flag("env-file").Value.String()
+		profile := cmd.Flag("profile").Value.String()
+		root := cmd.Flag("root").Value.String()
+		suiteFiles := suiteFilesFlag(cmd)
 
 		isVerbose := verbose == "true"
 		isDebug := debug == "true"
 
+		if envFile != "" {
+			if err := e2eframe.LoadEnvFile(envFile); err != nil {
+				fmt.Printf("%s%s✖ DRY RUN FAILED: %v%s\n", colorBold, colorRed, err, colorReset)
+				os.Exit(1)
+			}
+		}
+
 		var testFile string
 		if len(args) > 0 {
 			// Positional arg can be either a test file or a directory
@@ -290,11 +436,20 @@ var dryRunCmd = &cobra.Command{
 			}
 		}
 
-		err := e2eframe.DryRun(context.Background(), &e2eframe.DryRunOpts{
-			TestFile: testFile,
-			Verbose:  isVerbose,
-			Debug:    isDebug,
-			BaseDir:  baseDir,
+		_, baseDir, err := projectBaseDir(baseDir, profile)
+		if err != nil {
+			fmt.Printf("%s%s✖ DRY RUN FAILED: %v%s\n", colorBold, colorRed, err, colorReset)
+			os.Exit(1)
+		}
+
+		err = e2eframe.DryRun(context.Background(), &e2eframe.DryRunOpts{
+			TestFile:   testFile,
+			Verbose:    isVerbose,
+			Debug:      isDebug,
+			BaseDir:    baseDir,
+			Profile:    profile,
+			Root:       root,
+			SuiteFiles: suiteFiles,
 		})
 		if err != nil {
 			fmt.Printf("%s%s✖ DRY RUN FAILED: %v%s\n", colorBold, colorRed, err, colorReset)
@@ -318,7 +473,13 @@ var listSuitesCmd = &cobra.Command{
 			baseDir = args[0]
 		}
 
-		suiteNames, err := e2eframe.ListTestSuiteNames(baseDir)
+		_, baseDir, err := projectBaseDir(baseDir, "")
+		if err != nil {
+			fmt.Printf("%s%s✖ ERROR: %v%s\n", colorBold, colorRed, err, colorReset)
+			os.Exit(1)
+		}
+
+		suiteNames, err := e2eframe.ListTestSuiteNames(baseDir, e2eframe.LoadOptions{SuiteFiles: suiteFilesFlag(cmd)})
 		if err != nil {
 			fmt.Printf("%s%s✖ ERROR: %v%s\n", colorBold, colorRed, err, colorReset)
 			os.Exit(1)
@@ -336,6 +497,59 @@ var listSuitesCmd = &cobra.Command{
 	},
 }
 
+var migrateConfigCmd = &cobra.Command{
+	Use:   "migrate-config [path]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Rewrite suite files to the newest configuration kind",
+	Long: `Rewrites the suite files of a path, a suite file or a directory of suites, to the
+newest configuration kind and renames their deprecated fields. Comments are kept.
+
+Examples:
+  ene migrate-config                          # Migrate the suites of the project
+  ene migrate-config tests/users/suite.yml    # Migrate a single file, e.g. a base suite
+  ene migrate-config --dry-run                # Show the changes without writing them`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dryRun := cmd.Flag("dry-run").Value.String() == "true"
+
+		var baseDir string
+		if len(args) > 0 {
+			baseDir = args[0]
+		}
+
+		_, baseDir, err := projectBaseDir(baseDir, "")
+		if err != nil {
+			fmt.Printf("%s%s✖ ERROR: %v%s\n", colorBold, colorRed, err, colorReset)
+			os.Exit(1)
+		}
+
+		migrated, err := e2eframe.MigrateConfig(baseDir, &e2eframe.MigrateOpts{
+			DryRun:     dryRun,
+			SuiteFiles: suiteFilesFlag(cmd),
+		})
+		if err != nil {
+			fmt.Printf("%s%s✖ ERROR: %v%s\n", colorBold, colorRed, err, colorReset)
+			os.Exit(1)
+		}
+
+		if len(migrated) == 0 {
+			fmt.Printf("%s%s✓ All suites use %s%s\n", colorBold, colorGreen, e2eframe.LatestConfigKind, colorReset)
+			return
+		}
+
+		for _, file := range migrated {
+			if dryRun {
+				fmt.Printf("%s%sWould migrate %s:%s\n", colorBold, colorYellow, file.Path, colorReset)
+			} else {
+				fmt.Printf("%s%s✓ Migrated %s:%s\n", colorBold, colorGreen, file.Path, colorReset)
+			}
+
+			for _, change := range file.Changes {
+				fmt.Printf("  %s\n", change)
+			}
+		}
+	},
+}
+
 var cleanupCmd = &cobra.Command{
 	Use:   "cleanup [resource-type]",
 	Short: "Clean up orphaned Docker resources created by ene",
@@ -510,13 +724,113 @@ containers are removed before networks to prevent "network has active endpoints"
 	},
 }
 
-// ScaffoldTest creates a new test suite in ./tests/<name>.
-func ScaffoldTest(testName string, templates []string) error {
+// projectBaseDir reads the ene.yml of the project of baseDir, or of the current
+// directory, and returns the directory to discover suites in: baseDir when set,
+// the tests_dir of ene.yml when run from the project root, else the current directory.
+func projectBaseDir(baseDir, profile string) (*e2eframe.ProjectConfig, string, error) {
+	project, err := e2eframe.LoadProjectConfig(baseDir, profile)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if baseDir == "" && project != nil && project.TestsDir != "" {
+		if cwd, err := os.Getwd(); err == nil && cwd == project.Dir {
+			baseDir = project.TestsPath()
+		}
+	}
+
+	return project, baseDir, nil
+}
+
+// suiteFilesFlag returns the name patterns of the suite files given with --suite-files.
+func suiteFilesFlag(cmd *cobra.Command) []string {
+	flag := cmd.Flag("suite-files").Value.String()
+	if flag == "" {
+		return nil
+	}
+
+	return strings.Split(flag, ",")
+}
+
+// applyProjectConfig sets the flags not given on the command line to the defaults
+// of ene.yml and of the selected profile, and returns the directory to discover suites in.
+func applyProjectConfig(cmd *cobra.Command, baseDir, profile string) (string, error) {
+	project, baseDir, err := projectBaseDir(baseDir, profile)
+	if err != nil || project == nil {
+		return baseDir, err
+	}
+
+	defaults := make(map[string]string)
+
+	if project.Retry.Retries != nil {
+		defaults["retries"] = strconv.Itoa(*project.Retry.Retries)
+	}
+
+	if project.Retry.Delay != "" {
+		defaults["retry-delay"] = project.Retry.Delay
+	}
+
+	if project.Retry.Backoff != "" {
+		defaults["retry-backoff"] = string(project.Retry.Backoff)
+	}
+
+	if len(project.Retry.On) > 0 {
+		conditions := make([]string, len(project.Retry.On))
+		for i, condition := range project.Retry.On {
+			conditions[i] = string(condition)
+		}
+
+		defaults["retry-on"] = strings.Join(conditions, ",")
+	}
+
+	if project.Verbose != nil {
+		defaults["verbose"] = strconv.FormatBool(*project.Verbose)
+	}
+
+	if project.Parallel != nil {
+		defaults["parallel"] = strconv.FormatBool(*project.Parallel)
+	}
+
+	if project.Jobs != nil {
+		defaults["jobs"] = strconv.Itoa(*project.Jobs)
+	}
+
+	if project.Timeout > 0 {
+		defaults["timeout"] = project.Timeout.String()
+	}
+
+	if project.HTMLReport != "" {
+		defaults["html"] = project.ReportPath(project.HTMLReport)
+	}
+
+	if project.JSONReport != "" {
+		defaults["json"] = project.ReportPath(project.JSONReport)
+	}
+
+	if project.JUnitReport != "" {
+		defaults["junit"] = project.ReportPath(project.JUnitReport)
+	}
+
+	for name, value := range defaults {
+		if cmd.Flags().Changed(name) {
+			continue
+		}
+
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return "", fmt.Errorf("%s: invalid %s %q: %w", project.Path, name, value, err)
+		}
+	}
+
+	return baseDir, nil
+}
+
+// ScaffoldTest creates a new test suite in <testsDir>/<name>.
+func ScaffoldTest(testsDir, testName string, templates []string) error {
 	if testName == "" {
 		testName = "Mytest"
 	}
 
-	suiteDir := filepath.Join(e2eframe.TestsDir, testName)
+	suiteDir := filepath.Join(testsDir, testName)
 	if err := os.MkdirAll(suiteDir, 0o755); err != nil {
 		return fmt.Errorf("could not create directory %s: %w", suiteDir, err)
 	}
@@ -624,11 +938,26 @@ func init() {
 	rootCmd.Flags().Bool("pretty", true, "pretty print output")
 	rootCmd.Flags().Bool("debug", false, "enable debug mode")
 	rootCmd.Flags().Bool("parallel", false, "run tests in parallel")
+	rootCmd.Flags().Int("jobs", 0, "maximum number of suites run at once with --parallel, and separately of parallel tests run at once in each suite, so up to jobs×jobs tests can run at once (0 means the number of CPUs)")
 	rootCmd.Flags().String("suite", "", "run specific test suites (comma-separated), e.g. 'ene --suite=suite1,suite2' or partial matches 'ene --suite=TestService_,_Function'")
+	rootCmd.Flags().Int("retries", 3, "default number of retries for failing tests, overridden by 'retries' in suites and tests")
+	rootCmd.Flags().String("retry-delay", "2s", "default delay before retrying a failing test")
+	rootCmd.Flags().String("retry-backoff", "constant", "default retry backoff: 'constant' or 'exponential' (with jitter)")
+	rootCmd.Flags().String("retry-on", "any", "default failures to retry (comma-separated): 'any', 'transport' or 'assertion'")
+	rootCmd.Flags().Duration("timeout", 0, "maximum duration of the whole test run, e.g. '30m' (0 means no timeout)")
+	rootCmd.Flags().String("tags", "", "run only tests with these tags (comma-separated, prefix with ! to exclude), e.g. 'ene --tags=smoke,!slow'")
+	rootCmd.Flags().String("test", "", "run only tests whose name matches this regular expression, e.g. 'ene --test=\"^create_\"'")
+	rootCmd.Flags().String("shard", "", "run only a shard of the suites, e.g. 'ene --shard=2/4' runs the second of four shards")
+	rootCmd.Flags().String("shard-durations", "", "JSON report of a previous run, used to balance --shard by suite durations instead of suite count")
 	rootCmd.Flags().String("html", "", "generate HTML report to this path") // new
 	rootCmd.Flags().String("json", "", "generate JSON report to this path")
+	rootCmd.Flags().String("junit", "", "generate JUnit XML report to this path")
+	rootCmd.Flags().String("profile", "", "profile of ene.yml and the suites to run with, e.g. 'ene --profile=ci'")
+	rootCmd.Flags().String("root", "", "project root that relative paths of the suites resolve from, defaults to the directory of ene.yml, go.mod or .git above the suites")
+	rootCmd.Flags().String("suite-files", "", "name patterns of the suite files (comma-separated), defaults to suite_files of ene.yml or suite.yml, e.g. 'ene --suite-files=suite.yml,*.ene.yml'")
 	rootCmd.Flags().String("base-dir", "", "(deprecated: use positional arg instead) base directory for tests, defaults to current directory")
 	rootCmd.Flags().Bool("cleanup-cache", false, "cleanup old cached Docker images to prevent bloat")
+	rootCmd.Flags().String("env-file", "", "env file whose variables are substituted for ${VAR} in suite files, before the .env files of the project and suites")
 
 	scaffoldTestCmd.Flags().
 		String("tmpl", "", "templates to use for scaffolding, e.g. 'e2e scaffold-test my_test --tmpl=mongo,httpmock'")
@@ -636,8 +965,16 @@ func init() {
 	dryRunCmd.Flags().BoolP("verbose", "v", false, "enable detailed logs")
 	dryRunCmd.Flags().Bool("debug", false, "enable debug mode")
 	dryRunCmd.Flags().String("base-dir", "", "(deprecated: use positional arg instead) base directory for tests, defaults to current directory")
+	dryRunCmd.Flags().String("profile", "", "profile of ene.yml and the suites to validate, e.g. 'ene dry-run --profile=ci'")
+	dryRunCmd.Flags().String("suite-files", "", "name patterns of the suite files (comma-separated), defaults to suite_files of ene.yml or suite.yml")
+	dryRunCmd.Flags().String("root", "", "project root that relative paths of the suites resolve from, defaults to the directory of ene.yml, go.mod or .git above the suites")
+	dryRunCmd.Flags().String("env-file", "", "env file whose variables are substituted for ${VAR} in suite files, before the .env files of the project and suites")
 
 	listSuitesCmd.Flags().String("base-dir", "", "(deprecated: use positional arg instead) base directory for tests, defaults to current directory")
+	listSuitesCmd.Flags().String("suite-files", "", "name patterns of the suite files (comma-separated), defaults to suite_files of ene.yml or suite.yml")
+
+	migrateConfigCmd.Flags().Bool("dry-run", false, "show the changes without writing the files")
+	migrateConfigCmd.Flags().String("suite-files", "", "name patterns of the suite files (comma-separated), defaults to suite_files of ene.yml or suite.yml")
 
 	cleanupCmd.Flags().Bool("dry-run", false, "show what would be removed without actually removing")
 	cleanupCmd.Flags().Bool("force", false, "skip confirmation prompt")
@@ -648,13 +985,14 @@ func init() {
 	rootCmd.AddCommand(scaffoldTestCmd)
 	rootCmd.AddCommand(dryRunCmd)
 	rootCmd.AddCommand(listSuitesCmd)
+	rootCmd.AddCommand(migrateConfigCmd)
 	rootCmd.AddCommand(cleanupCmd)
 	rootCmd.AddCommand(versionCmd)
 
 	// Add custom completion for --suite flag
 	rootCmd.RegisterFlagCompletionFunc("suite", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		baseDir := cmd.Flag("base-dir").Value.String()
-		suiteNames, err := e2eframe.ListTestSuiteNames(baseDir)
+		suiteNames, err := e2eframe.ListTestSuiteNames(baseDir, e2eframe.LoadOptions{})
 		if err != nil {
 			return nil, cobra.ShellCompDirectiveError
 		}
@@ -150,7 +150,7 @@ func (u *Unit) Start(ctx context.Context, opts *e2eframe.UnitStartOptions) error
 			// Write body
 			switch b := body.(type) {
 			case string:
-				interpolatedBody := u.interpolateFixtures(b, opts.Fixtures)
+				interpolatedBody := e2eframe.InterpolateJSON(e2eframe.FixtureInterpolationRegex, b, opts.Fixtures)
 				w.Write([]byte(interpolatedBody))
 			case map[string]interface{}:
 				// Interpolate fixtures in JSON object
@@ -234,8 +234,17 @@ func (u *Unit) LocalEndpoint() string {
 	return fmt.Sprintf("http://localhost:%d", u.Port)
 }
 
-func (u *Unit) Get(_ string) (string, error) {
-	return "", nil
+func (u *Unit) Get(variable string) (string, error) {
+	switch variable {
+	case "host":
+		return "localhost", nil
+	case "port":
+		return fmt.Sprintf("%d", u.Port), nil
+	case "endpoint", "local_endpoint":
+		return u.ExternalEndpoint(), nil
+	default:
+		return "", fmt.Errorf("unknown variable %s", variable)
+	}
 }
 
 func (u *Unit) GetEnvRaw(_ *e2eframe.GetEnvRawOptions) map[string]string {
@@ -275,7 +284,8 @@ func (u *Unit) interpolateFixturesInMap(data interface{}, fixtures []e2eframe.Fi
 		}
 		return result
 	case string:
-		return u.interpolateFixtures(v, fixtures)
+		// A single reference to a structured fixture keeps its JSON type
+		return e2eframe.InterpolateValue(e2eframe.FixtureInterpolationRegex, v, fixtures)
 	default:
 		return v
 	}
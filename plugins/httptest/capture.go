@@ -0,0 +1,169 @@
+package httptest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/exapsy/ene/e2eframe"
+)
+
+const (
+	captureSourceBody   = "body"
+	captureSourceHeader = "header"
+	captureSourceStatus = "status"
+)
+
+// captureValues extracts the runtime variables declared in the test's `capture:` block
+// from the response. Supported sources are:
+//
+//	body            the raw response body
+//	body.<path>     a gjson path into the JSON response body
+//	header.<name>   a response header
+//	status          the response status code
+func (t *TestSuiteTest) captureValues(
+	statusCode int,
+	headers http.Header,
+	body []byte,
+) (map[string]string, error) {
+	if len(t.Capture) == 0 {
+		return nil, nil
+	}
+
+	captured := make(map[string]string, len(t.Capture))
+
+	for name, source := range t.Capture {
+		value, err := captureValue(name, source, statusCode, headers, body)
+		if err != nil {
+			return nil, err
+		}
+
+		captured[name] = value
+	}
+
+	return captured, nil
+}
+
+func captureValue(
+	name string,
+	source string,
+	statusCode int,
+	headers http.Header,
+	body []byte,
+) (string, error) {
+	kind, path, _ := strings.Cut(source, ".")
+
+	switch kind {
+	case captureSourceStatus:
+		if path != "" {
+			return "", &e2eframe.CaptureMissingError{Name: name, Source: source, Reason: "status does not take a path"}
+		}
+
+		return strconv.Itoa(statusCode), nil
+	case captureSourceHeader:
+		if path == "" {
+			return "", &e2eframe.CaptureMissingError{Name: name, Source: source, Reason: "missing header name"}
+		}
+
+		if _, ok := headers[http.CanonicalHeaderKey(path)]; !ok {
+			return "", &e2eframe.CaptureMissingError{Name: name, Source: source}
+		}
+
+		return headers.Get(path), nil
+	case captureSourceBody:
+		if path == "" {
+			return string(body), nil
+		}
+
+		if !gjson.ValidBytes(body) {
+			return "", &e2eframe.CaptureMissingError{Name: name, Source: source, Reason: "response body is not valid JSON"}
+		}
+
+		result := gjson.GetBytes(body, path)
+		if !result.Exists() {
+			return "", &e2eframe.CaptureMissingError{Name: name, Source: source}
+		}
+
+		return result.String(), nil
+	default:
+		return "", fmt.Errorf(
+			"capture %s: unknown source %q (expected body, body.<path>, header.<name> or status)",
+			name,
+			source,
+		)
+	}
+}
+
+// validateCaptures checks that every capture source is well formed: `status`,
+// `header.<name>`, `body` or `body.<path>` with a valid gjson path.
+func validateCaptures(captures e2eframe.Captures) error {
+	for name, source := range captures {
+		kind, path, hasPath := strings.Cut(source, ".")
+
+		var reason string
+
+		switch {
+		case kind == captureSourceStatus && hasPath:
+			reason = "status does not take a path"
+		case kind == captureSourceHeader && path == "":
+			reason = "missing header name"
+		case kind == captureSourceBody && hasPath && !validBodyPath(path):
+			reason = "invalid gjson path"
+		case kind == captureSourceStatus, kind == captureSourceHeader, kind == captureSourceBody:
+			continue
+		default:
+			return fmt.Errorf(
+				"capture %s: unknown source %q (expected body, body.<path>, header.<name> or status)",
+				name,
+				source,
+			)
+		}
+
+		return fmt.Errorf("capture %s: invalid source %q: %s", name, source, reason)
+	}
+
+	return nil
+}
+
+// validBodyPath reports whether path is a well formed gjson path: no empty
+// components and balanced queries, multipaths and string literals.
+func validBodyPath(path string) bool {
+	depth, componentLen := 0, 0
+	inString := false
+
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+
+		switch {
+		case c == '\\':
+			if i++; i == len(path) {
+				return false
+			}
+		case inString:
+			inString = c != '"'
+		case depth > 0 && c == '"':
+			inString = true
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			if depth--; depth < 0 {
+				return false
+			}
+		case depth == 0 && (c == '.' || c == '|'):
+			if componentLen == 0 {
+				return false
+			}
+
+			componentLen = 0
+
+			continue
+		}
+
+		componentLen++
+	}
+
+	return depth == 0 && !inString && componentLen > 0
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 
@@ -54,6 +55,7 @@ type TestSuiteTest struct {
 	Debug          bool   // Optional: enable debug output for this test
 	Request        TestSuiteTestRequest
 	Expect         TestSuiteTestExpect
+	Capture        e2eframe.Captures // Optional: runtime variables to capture from the response
 	TargetEndpoint string
 }
 
@@ -148,7 +150,8 @@ func (t *TestSuiteTest) GetBodyWithFixtures(fixtures []e2eframe.Fixture) io.Read
 
 	interpolationRegex := e2eframe.FixtureInterpolationRegex
 	if interpolationRegex.MatchString(t.Request.Body) {
-		str := e2eframe.InterpolateString(interpolationRegex, t.Request.Body, fixtures)
+		// JSON bodies keep the types of structured fixtures, e.g. "{{ user.id }}" becomes 42
+		str := e2eframe.InterpolateJSON(interpolationRegex, t.Request.Body, fixtures)
 
 		return io.NopCloser(strings.NewReader(str))
 	}
@@ -193,18 +196,22 @@ func (t *TestSuiteTest) Run(
 
 	// Log request details in verbose or debug mode
 	if opts.Verbose || opts.Debug || t.Debug {
-		fmt.Printf("\n=== HTTP Request ===\n")
-		fmt.Printf("%s %s\n", t.Request.Method, fullURL)
+		// Values of secret fixtures are masked in the dump
+		dump := e2eframe.NewMaskingWriter(os.Stdout)
+
+		fmt.Fprintf(dump, "\n=== HTTP Request ===\n")
+		fmt.Fprintf(dump, "%s %s\n", t.Request.Method, fullURL)
 		if len(headers) > 0 {
-			fmt.Printf("Headers:\n")
+			fmt.Fprintf(dump, "Headers:\n")
 			for key, value := range headers {
-				fmt.Printf("  %s: %s\n", key, value)
+				fmt.Fprintf(dump, "  %s: %s\n", key, value)
 			}
 		}
 		if len(bodyBytes) > 0 {
-			fmt.Printf("Body:\n%s\n", string(bodyBytes))
+			fmt.Fprintf(dump, "Body:\n%s\n", string(bodyBytes))
 		}
-		fmt.Printf("====================\n\n")
+		fmt.Fprintf(dump, "====================\n\n")
+		dump.Flush()
 	}
 
 	// Create new body reader from bytes
@@ -232,7 +239,7 @@ func (t *TestSuiteTest) Run(
 		return &e2eframe.TestResult{
 			TestName: t.TestName,
 			Message:  errMsg,
-			Err:      err,
+			Err:      &e2eframe.TransportError{Err: err},
 			Passed:   false,
 		}, nil
 	}
@@ -247,20 +254,23 @@ func (t *TestSuiteTest) Run(
 
 	// Log response details in verbose or debug mode
 	if opts.Verbose || opts.Debug || t.Debug {
-		fmt.Printf("=== HTTP Response ===\n")
-		fmt.Printf("Status: %d %s\n", r.StatusCode, http.StatusText(r.StatusCode))
+		dump := e2eframe.NewMaskingWriter(os.Stdout)
+
+		fmt.Fprintf(dump, "=== HTTP Response ===\n")
+		fmt.Fprintf(dump, "Status: %d %s\n", r.StatusCode, http.StatusText(r.StatusCode))
 		if len(r.Header) > 0 {
-			fmt.Printf("Headers:\n")
+			fmt.Fprintf(dump, "Headers:\n")
 			for key, values := range r.Header {
 				for _, value := range values {
-					fmt.Printf("  %s: %s\n", key, value)
+					fmt.Fprintf(dump, "  %s: %s\n", key, value)
 				}
 			}
 		}
 		if len(responseBodyBytes) > 0 {
-			fmt.Printf("Body:\n%s\n", string(responseBodyBytes))
+			fmt.Fprintf(dump, "Body:\n%s\n", string(responseBodyBytes))
 		}
-		fmt.Printf("=====================\n\n")
+		fmt.Fprintf(dump, "=====================\n\n")
+		dump.Flush()
 	}
 
 	// Replace response body with buffered version for assertions
@@ -276,10 +286,22 @@ func (t *TestSuiteTest) Run(
 		}, nil
 	}
 
+	captured, err := t.captureValues(r.StatusCode, r.Header, responseBodyBytes)
+	if err != nil {
+		errMsg := t.formatTestFailureError(fullURL, headers, bodyBytes, r.StatusCode, r.Header, responseBodyBytes, err)
+		return &e2eframe.TestResult{
+			TestName: t.TestName,
+			Message:  errMsg,
+			Err:      err,
+			Passed:   false,
+		}, nil
+	}
+
 	return &e2eframe.TestResult{
 		TestName: t.TestName,
 		Message:  "Test passed successfully",
 		Passed:   true,
+		Captured: captured,
 	}, nil
 }
 
@@ -331,7 +353,20 @@ func (t *TestSuiteTest) UnmarshalYAML(node *yaml.Node) error {
 			}
 
 			t.Expect = *expect
+		case "capture":
+			if err := value.Decode(&t.Capture); err != nil {
+				return err
+			}
+
+			if err := validateCaptures(t.Capture); err != nil {
+				return err
+			}
 		default:
+			// Common test fields (tags, skip/only, retries) are handled by the framework
+			if e2eframe.IsTestMetaField(key.Value) {
+				continue
+			}
+
 			return &yaml.TypeError{Errors: []string{"unknown field: " + key.Value}}
 		}
 	}
@@ -453,7 +488,7 @@ func (t *TestSuiteTest) formatRequestError(
 		sb.WriteString(fmt.Sprintf("Body:\n%s\n", string(body)))
 	}
 	sb.WriteString("=======================")
-	return sb.String()
+	return e2eframe.MaskSecrets(sb.String())
 }
 
 // formatTestFailureError formats an error message with full request and response details
@@ -495,7 +530,7 @@ func (t *TestSuiteTest) formatTestFailureError(
 		sb.WriteString(fmt.Sprintf("Body:\n%s\n", string(responseBody)))
 	}
 	sb.WriteString("========================")
-	return sb.String()
+	return e2eframe.MaskSecrets(sb.String())
 }
 
 func init() {
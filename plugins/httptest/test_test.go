@@ -293,3 +293,300 @@ func TestRunRequestErrorIncludesDetails(t *testing.T) {
 	assert.Contains(t, res.Message, `{"foo":"bar"}`)
 	assert.Contains(t, res.Message, "=== Request Details ===")
 }
+
+func TestRunCapturesValues(t *testing.T) {
+	srv := stdhttptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Location", "/users/42")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"id":42,"profile":{"email":"jane@example.com"},"tags":["a","b"]}`)
+		}),
+	)
+	defer srv.Close()
+
+	ts := &httptestplugin.TestSuiteTest{
+		TestName:       "create-user",
+		TargetEndpoint: srv.URL,
+		Request: httptestplugin.TestSuiteTestRequest{
+			Path:    "/users",
+			Method:  http.MethodPost,
+			Timeout: "1s",
+		},
+		Expect: httptestplugin.TestSuiteTestExpect{
+			StatusCode: http.StatusCreated,
+		},
+		Capture: e2eframe.Captures{
+			"user_id":  "body.id",
+			"email":    "body.profile.email",
+			"tags":     "body.tags",
+			"location": "header.location",
+			"status":   "status",
+		},
+	}
+
+	res, err := ts.Run(context.Background(), nil)
+	require.NoError(t, err)
+	require.True(t, res.Passed, res.Message)
+	assert.Equal(t, map[string]string{
+		"user_id":  "42",
+		"email":    "jane@example.com",
+		"tags":     `["a","b"]`,
+		"location": "/users/42",
+		"status":   "201",
+	}, res.Captured)
+}
+
+func TestRunCaptureMissingFailsTest(t *testing.T) {
+	srv := stdhttptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `{"id":42}`)
+		}),
+	)
+	defer srv.Close()
+
+	ts := &httptestplugin.TestSuiteTest{
+		TestName:       "missing-capture",
+		TargetEndpoint: srv.URL,
+		Request: httptestplugin.TestSuiteTestRequest{
+			Path:    "/",
+			Method:  http.MethodGet,
+			Timeout: "1s",
+		},
+		Expect: httptestplugin.TestSuiteTestExpect{
+			StatusCode: http.StatusOK,
+		},
+		Capture: e2eframe.Captures{"token": "body.token"},
+	}
+
+	res, err := ts.Run(context.Background(), nil)
+	require.NoError(t, err)
+	assert.False(t, res.Passed)
+	assert.Contains(t, res.Message, "capture token: no value found at body.token")
+	assert.Nil(t, res.Captured)
+}
+
+func TestRunUsesCapturedFixtures(t *testing.T) {
+	var gotPath string
+
+	srv := stdhttptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+	defer srv.Close()
+
+	ts := &httptestplugin.TestSuiteTest{
+		TestName:       "get-user",
+		TargetEndpoint: srv.URL,
+		Request: httptestplugin.TestSuiteTestRequest{
+			Path:    "/users/{{ user_id }}",
+			Method:  http.MethodGet,
+			Timeout: "1s",
+		},
+		Expect: httptestplugin.TestSuiteTestExpect{
+			StatusCode: http.StatusOK,
+		},
+	}
+
+	res, err := ts.Run(context.Background(), &e2eframe.TestSuiteTestRunOptions{
+		Fixtures: []e2eframe.Fixture{
+			&e2eframe.CapturedFixture{CaptureName: "user_id", CaptureValue: "42"},
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, res.Passed)
+	assert.Equal(t, "/users/42", gotPath)
+}
+
+func TestRunKeepsTypesOfStructuredFixturesInJSONBody(t *testing.T) {
+	var gotBody string
+
+	srv := stdhttptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			gotBody = string(data)
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+	defer srv.Close()
+
+	ts := &httptestplugin.TestSuiteTest{
+		TestName:       "create-order",
+		TargetEndpoint: srv.URL,
+		Request: httptestplugin.TestSuiteTestRequest{
+			Path:    "/orders",
+			Method:  http.MethodPost,
+			Body:    `{"user_id": "{{ created.id }}", "items": "{{ created.items }}", "note": "for {{ created.name }}"}`,
+			Timeout: "1s",
+		},
+		Expect: httptestplugin.TestSuiteTestExpect{
+			StatusCode: http.StatusOK,
+		},
+	}
+
+	res, err := ts.Run(context.Background(), &e2eframe.TestSuiteTestRunOptions{
+		Fixtures: []e2eframe.Fixture{
+			&e2eframe.CapturedFixture{
+				CaptureName:  "created",
+				CaptureValue: `{"id": 42, "name": "alice", "items": [{"sku": "A-1"}]}`,
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, res.Passed)
+	assert.Equal(t, `{"user_id": 42, "items": [{"sku":"A-1"}], "note": "for alice"}`, gotBody)
+}
+
+func TestUnmarshalYAMLCapture(t *testing.T) {
+	tests := []struct {
+		name    string
+		yamlStr string
+		want    e2eframe.Captures
+		wantErr string
+	}{
+		{
+			name: "valid capture",
+			yamlStr: `
+name: foo
+kind: http
+capture:
+  user_id: body.data.id
+  token: header.X-Token
+`,
+			want: e2eframe.Captures{"user_id": "body.data.id", "token": "header.X-Token"},
+		},
+		{
+			name: "unknown source",
+			yamlStr: `
+name: foo
+kind: http
+capture:
+  user_id: response.id
+`,
+			wantErr: `unknown source "response.id"`,
+		},
+		{
+			name: "gjson paths",
+			yamlStr: `
+name: foo
+kind: http
+capture:
+  body: body
+  status: status
+  admin: body.users.#(name=="a.b").id
+  first: body.items.0|@this
+`,
+			want: e2eframe.Captures{
+				"body":   "body",
+				"status": "status",
+				"admin":  `body.users.#(name=="a.b").id`,
+				"first":  "body.items.0|@this",
+			},
+		},
+		{
+			name: "unknown source with a known prefix",
+			yamlStr: `
+name: foo
+kind: http
+capture:
+  user_id: bodyx.id
+`,
+			wantErr: `unknown source "bodyx.id"`,
+		},
+		{
+			name: "status with a path",
+			yamlStr: `
+name: foo
+kind: http
+capture:
+  code: status.code
+`,
+			wantErr: `invalid source "status.code": status does not take a path`,
+		},
+		{
+			name: "empty header name",
+			yamlStr: `
+name: foo
+kind: http
+capture:
+  token: header.
+`,
+			wantErr: `invalid source "header.": missing header name`,
+		},
+		{
+			name: "empty body path",
+			yamlStr: `
+name: foo
+kind: http
+capture:
+  user_id: body.
+`,
+			wantErr: `invalid source "body.": invalid gjson path`,
+		},
+		{
+			name: "empty path component",
+			yamlStr: `
+name: foo
+kind: http
+capture:
+  user_id: body.data..id
+`,
+			wantErr: `invalid source "body.data..id": invalid gjson path`,
+		},
+		{
+			name: "unbalanced query",
+			yamlStr: `
+name: foo
+kind: http
+capture:
+  user_id: body.users.#(name=="alice".id
+`,
+			wantErr: `invalid gjson path`,
+		},
+		{
+			name: "invalid name",
+			yamlStr: `
+name: foo
+kind: http
+capture:
+  user-id: body.id
+`,
+			wantErr: `invalid capture name "user-id"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got httptestplugin.TestSuiteTest
+
+			err := yaml.Unmarshal([]byte(tt.yamlStr), &got)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Capture)
+		})
+	}
+}
+
+func TestUnmarshalYAMLAcceptsTestMetaFields(t *testing.T) {
+	yamlStr := `
+name: foo
+kind: http
+tags: [smoke]
+skip: flaky upstream
+only: true
+request:
+  path: /health
+`
+
+	var got httptestplugin.TestSuiteTest
+
+	require.NoError(t, yaml.Unmarshal([]byte(yamlStr), &got))
+	assert.Equal(t, "/health", got.Request.Path)
+}
@@ -6,10 +6,12 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -216,16 +218,17 @@ const (
 )
 
 type HTTPUnitConfig struct {
-	Name            string        `yaml:"name"`
-	Command         []string      `yaml:"command"`
-	Dockerfile      string        `yaml:"dockerfile"`
-	Image           string        `yaml:"image"`
-	AppPort         int           `yaml:"app_port"`
-	HealthcheckPath string        `yaml:"healthcheck"`
-	EnvFile         string        `yaml:"env_file"`
-	Env             any           `yaml:"env"`
-	BuildTimeout    time.Duration `yaml:"build_timeout"`
-	StartupTimeout  time.Duration `yaml:"startup_timeout"`
+	Name            string            `yaml:"name"`
+	Command         []string          `yaml:"command"`
+	Dockerfile      string            `yaml:"dockerfile"`
+	Image           string            `yaml:"image"`
+	AppPort         int               `yaml:"app_port"`
+	HealthcheckPath string            `yaml:"healthcheck"`
+	EnvFile         string            `yaml:"env_file"`
+	Env             any               `yaml:"env"`
+	BuildArgs       map[string]string `yaml:"build_args"`
+	BuildTimeout    time.Duration     `yaml:"build_timeout"`
+	StartupTimeout  time.Duration     `yaml:"startup_timeout"`
 }
 
 type HTTPUnit struct {
@@ -239,6 +242,7 @@ type HTTPUnit struct {
 	HealthcheckPath string
 	EnvFile         string
 	EnvVars         map[string]any
+	BuildArgs       map[string]string
 	cont            testcontainers.Container
 	BuildTimeout    time.Duration
 	StartupTimeout  time.Duration
@@ -256,6 +260,8 @@ type HTTPUnit struct {
 
 	// File path for error logs
 	errorLogFile string
+	// logDir is the directory of the saved logs, e.g. `log_dir` of ene.yml
+	logDir string
 }
 
 func init() {
@@ -329,6 +335,7 @@ func New(cfg map[string]any) (e2eframe.Unit, error) {
 
 	envFile, _ := cfg["env_file"].(string)
 	healthcheck, _ := cfg["healthcheck"].(string)
+	buildArgs, _ := cfg["build_args"].(map[string]string)
 
 	buildTimeout, ok := cfg["build_timeout"].(time.Duration)
 	if !ok {
@@ -394,6 +401,7 @@ func New(cfg map[string]any) (e2eframe.Unit, error) {
 		Command:         cmd,
 		EnvFile:         envFile,
 		EnvVars:         envVars,
+		BuildArgs:       buildArgs,
 		AppPort:         appPort,
 		HealthcheckPath: healthcheck,
 		Dockerfile:      dockerfile,
@@ -412,6 +420,17 @@ func (s *HTTPUnit) Name() string {
 // runs the command specified in the config,
 // and exposes the port to the host.
 func (s *HTTPUnit) Start(ctx context.Context, opts *e2eframe.UnitStartOptions) error {
+	s.logDir = opts.LogDir
+
+	// Resolve the fixtures and unit variables referenced by the configuration
+	e2eframe.InterpolateUnitFields(opts.Fixtures, &s.Image, &s.Dockerfile, &s.HealthcheckPath, &s.EnvFile)
+	e2eframe.InterpolateUnitFields(opts.Fixtures, e2eframe.StringFields(s.Command)...)
+
+	for key, value := range s.BuildArgs {
+		e2eframe.InterpolateUnitFields(opts.Fixtures, &value)
+		s.BuildArgs[key] = value
+	}
+
 	// load env file into map
 	envs := make(map[string]string)
 
@@ -477,8 +496,8 @@ func (s *HTTPUnit) Start(ctx context.Context, opts *e2eframe.UnitStartOptions) e
 	logCapture := &httpLogConsumer{unit: s}
 
 	// Create log file for capturing build output
-	// Use project root .ene/<suite-name>/ directory
-	logDir := filepath.Join(".ene", opts.SuiteName)
+	// Use the project log directory, .ene/<suite-name>/ by default
+	logDir := e2eframe.SuiteLogDir(opts.LogDir, opts.SuiteName)
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		fmt.Printf("Warning: failed to create log directory: %v\n", err)
 	}
@@ -491,20 +510,33 @@ func (s *HTTPUnit) Start(ctx context.Context, opts *e2eframe.UnitStartOptions) e
 		logFile = nil
 	}
 
+	// Values of secret fixtures are masked in the saved and printed build log
+	var logFileWriter *e2eframe.MaskingWriter
+	if logFile != nil {
+		logFileWriter = e2eframe.NewMaskingWriter(logFile)
+	}
+
 	// Capture build logs for error reporting in verbose mode
 	if opts.Verbose || opts.Debug {
+		stdout := e2eframe.NewMaskingWriter(os.Stdout)
+		defer stdout.Flush()
+
 		if logFile != nil {
-			buildLogWriter = io.MultiWriter(os.Stdout, &s.buildLogs, logFile)
+			buildLogWriter = io.MultiWriter(stdout, &s.buildLogs, logFileWriter)
+			defer logFileWriter.Flush()
 		} else {
-			buildLogWriter = io.MultiWriter(os.Stdout, &s.buildLogs)
+			buildLogWriter = io.MultiWriter(stdout, &s.buildLogs)
 		}
 		logConsumers = []testcontainers.LogConsumer{
 			logCapture,
 		}
 	} else {
 		if logFile != nil {
-			buildLogWriter = io.MultiWriter(&s.buildLogs, logFile)
-			defer logFile.Close()
+			buildLogWriter = io.MultiWriter(&s.buildLogs, logFileWriter)
+			defer func() {
+				logFileWriter.Flush()
+				logFile.Close()
+			}()
 		} else {
 			buildLogWriter = &s.buildLogs
 		}
@@ -570,6 +602,10 @@ func (s *HTTPUnit) Start(ctx context.Context, opts *e2eframe.UnitStartOptions) e
 					buildOptions.Version = types.BuilderV1
 					buildOptions.BuildArgs = map[string]*string{}
 
+					for key, value := range s.BuildArgs {
+						buildOptions.BuildArgs[key] = &value
+					}
+
 					// Add no-cache option for debugging only
 					if opts.Debug {
 						buildOptions.NoCache = true
@@ -754,7 +790,7 @@ func (s *HTTPUnit) Start(ctx context.Context, opts *e2eframe.UnitStartOptions) e
 			errorLogContent += fmt.Sprintf("\n=== Error ===\n%s\n", errStr)
 			errorLogContent += fmt.Sprintf("\n=== Build Output ===\n%s\n", buildLogStr)
 
-			if writeErr := os.WriteFile(s.errorLogFile, []byte(errorLogContent), 0644); writeErr != nil {
+			if writeErr := os.WriteFile(s.errorLogFile, []byte(e2eframe.MaskSecrets(errorLogContent)), 0644); writeErr != nil {
 				fmt.Printf("Warning: failed to write error log: %v\n", writeErr)
 			}
 		}
@@ -862,7 +898,7 @@ func (s *HTTPUnit) SaveRuntimeLogs(suiteName, reason string) (string, error) {
 	}
 
 	// Create log directory at project root .ene/<suite-name>/
-	logDir := filepath.Join(".ene", suiteName)
+	logDir := e2eframe.SuiteLogDir(s.logDir, suiteName)
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create log directory: %w", err)
 	}
@@ -895,7 +931,7 @@ func (s *HTTPUnit) SaveRuntimeLogs(suiteName, reason string) (string, error) {
 	logContent += string(logBytes)
 
 	// Write to file
-	if err := os.WriteFile(logFilePath, []byte(logContent), 0644); err != nil {
+	if err := os.WriteFile(logFilePath, []byte(e2eframe.MaskSecrets(logContent)), 0644); err != nil {
 		return "", fmt.Errorf("failed to write log file: %w", err)
 	}
 
@@ -916,6 +952,11 @@ func (s *HTTPUnit) Get(variable string) (string, error) {
 		return "localhost", nil
 	case "port":
 		return fmt.Sprintf("%d", s.Port), nil
+	case "endpoint":
+		return s.ExternalEndpoint(), nil
+	case "local_endpoint":
+		// Container URL, e.g. for callbacks sent by other units
+		return s.LocalEndpoint(), nil
 	default:
 		return "", fmt.Errorf("unknown variable %s", variable)
 	}
@@ -925,7 +966,7 @@ func (s *HTTPUnit) GetEnvRaw(opts *e2eframe.GetEnvRawOptions) map[string]string
 	envs := make(map[string]string)
 
 	if s.EnvFile != "" {
-		envFilePath := filepath.Join(opts.WorkingDir, s.EnvFile)
+		envFilePath := filepath.Join(opts.WorkingDir, opts.Interpolate(s.EnvFile))
 		if err := godotenv.Load(envFilePath); err != nil {
 			return nil
 		}
@@ -950,6 +991,10 @@ func (s *HTTPUnit) GetEnvRaw(opts *e2eframe.GetEnvRawOptions) map[string]string
 }
 
 func (s *HTTPUnit) SetEnvs(env map[string]string) {
+	if s.EnvVars == nil {
+		s.EnvVars = make(map[string]any)
+	}
+
 	for k, v := range env {
 		s.EnvVars[k] = v
 	}
@@ -974,6 +1019,7 @@ func UnmarshallUnit(node *yaml.Node) (e2eframe.Unit, error) {
 		"healthcheck":     config.HealthcheckPath,
 		"env_file":        config.EnvFile,
 		"env":             config.Env,
+		"build_args":      config.BuildArgs,
 		"build_timeout":   config.BuildTimeout,
 		"startup_timeout": config.StartupTimeout,
 	})
@@ -1057,6 +1103,11 @@ func (s *HTTPUnit) generateSmartContentHash(contextPath string) (string, error)
 		return "", fmt.Errorf("failed to generate smart hash for %s: %w", contextPath, err)
 	}
 
+	// Build args change the image without changing the build context
+	for _, key := range slices.Sorted(maps.Keys(s.BuildArgs)) {
+		hash.Write([]byte(key + "=" + s.BuildArgs[key]))
+	}
+
 	result := hex.EncodeToString(hash.Sum(nil))[:12]
 	return result, nil
 }
@@ -466,6 +466,11 @@ func (t *TestSuiteTest) UnmarshalYAML(node *yaml.Node) error {
 				return fmt.Errorf("failed to decode verify_state: %w", err)
 			}
 		default:
+			// Common test fields (tags, skip/only, retries) are handled by the framework
+			if e2eframe.IsTestMetaField(key.Value) {
+				continue
+			}
+
 			return fmt.Errorf("unknown field: %s", key.Value)
 		}
 	}
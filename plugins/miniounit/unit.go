@@ -53,6 +53,7 @@ type MinioUnit struct {
 	secretKey      string
 	buckets        []string
 	cmd            []string
+	logDir         string
 	EnvVars        map[string]any
 }
 
@@ -190,6 +191,8 @@ func (m *MinioUnit) Name() string {
 }
 
 func (m *MinioUnit) Start(ctx context.Context, opts *e2eframe.UnitStartOptions) error {
+	m.logDir = opts.LogDir
+
 	freePort, err := e2eframe.GetFreePort()
 	if err != nil {
 		return fmt.Errorf("get free port: %w", err)
@@ -203,6 +206,11 @@ func (m *MinioUnit) Start(ctx context.Context, opts *e2eframe.UnitStartOptions)
 	m.exposedPort = freePort
 	m.consolePort = consolePort
 
+	// Resolve the fixtures and unit variables referenced by the configuration
+	e2eframe.InterpolateUnitFields(opts.Fixtures, &m.Image)
+	e2eframe.InterpolateUnitFields(opts.Fixtures, e2eframe.StringFields(m.buckets)...)
+	e2eframe.InterpolateUnitFields(opts.Fixtures, e2eframe.StringFields(m.cmd)...)
+
 	// Emit starting event
 	m.sendEvent(opts.EventSink, e2eframe.EventContainerStarting,
 		fmt.Sprintf("starting MinIO container %s", m.serviceName))
@@ -330,7 +338,7 @@ func (m *MinioUnit) SaveRuntimeLogs(suiteName, reason string) (string, error) {
 	}
 
 	// Create log directory at project root .ene/<suite-name>/
-	logDir := filepath.Join(".ene", suiteName)
+	logDir := e2eframe.SuiteLogDir(m.logDir, suiteName)
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create log directory: %w", err)
 	}
@@ -363,7 +371,7 @@ func (m *MinioUnit) SaveRuntimeLogs(suiteName, reason string) (string, error) {
 	logContent += string(logBytes)
 
 	// Write to file
-	if err := os.WriteFile(logFilePath, []byte(logContent), 0644); err != nil {
+	if err := os.WriteFile(logFilePath, []byte(e2eframe.MaskSecrets(logContent)), 0644); err != nil {
 		return "", fmt.Errorf("failed to write log file: %w", err)
 	}
 
@@ -490,11 +498,16 @@ func (m *MinioUnit) createBuckets(ctx context.Context) error {
 	return nil
 }
 
-func (m *MinioUnit) GetEnvRaw(_ *e2eframe.GetEnvRawOptions) map[string]string {
+func (m *MinioUnit) GetEnvRaw(opts *e2eframe.GetEnvRawOptions) map[string]string {
 	envs := make(map[string]string)
 
 	if m.envFile != "" {
-		file, err := os.ReadFile(m.envFile)
+		envFilePath := opts.Interpolate(m.envFile)
+		if opts != nil && opts.WorkingDir != "" {
+			envFilePath = filepath.Join(opts.WorkingDir, envFilePath)
+		}
+
+		file, err := os.ReadFile(envFilePath)
 		if err != nil {
 			return nil
 		}
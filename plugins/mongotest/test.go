@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/exapsy/ene/e2eframe"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/yaml.v3"
@@ -27,6 +30,7 @@ type TestSuiteTest struct {
 	Filter        interface{}        `yaml:"filter"`   // Can be string (JSON) or map (YAML)
 	Pipeline      interface{}        `yaml:"pipeline"` // Can be string (JSON) or array (YAML)
 	Expect        *MongoExpectations `yaml:"expect"`
+	Capture       e2eframe.Captures  `yaml:"capture"` // Optional: runtime variables to capture from the result
 	MongoEndpoint string
 	testSuite     e2eframe.TestSuite
 }
@@ -124,7 +128,7 @@ func (t *TestSuiteTest) Run(ctx context.Context, opts *e2eframe.TestSuiteTestRun
 			TestName: t.TestName,
 			Passed:   false,
 			Message:  fmt.Sprintf("Failed to connect to MongoDB: %v", err),
-			Err:      err,
+			Err:      &e2eframe.TransportError{Err: err},
 			Duration: time.Since(startTime),
 		}, nil
 	}
@@ -144,13 +148,14 @@ func (t *TestSuiteTest) Run(ctx context.Context, opts *e2eframe.TestSuiteTestRun
 			TestName: t.TestName,
 			Passed:   false,
 			Message:  fmt.Sprintf("Failed to ping MongoDB: %v", err),
-			Err:      err,
+			Err:      &e2eframe.TransportError{Err: err},
 			Duration: time.Since(startTime),
 		}, nil
 	}
 
 	// Run expectations
-	if err := t.runExpectations(ctx, client, opts); err != nil {
+	results, err := t.runExpectations(ctx, client, opts)
+	if err != nil {
 		return &e2eframe.TestResult{
 			TestName: t.TestName,
 			Passed:   false,
@@ -160,17 +165,29 @@ func (t *TestSuiteTest) Run(ctx context.Context, opts *e2eframe.TestSuiteTestRun
 		}, nil
 	}
 
+	captured, err := t.captureValues(results)
+	if err != nil {
+		return &e2eframe.TestResult{
+			TestName: t.TestName,
+			Passed:   false,
+			Message:  fmt.Sprintf("Capture failed: %v", err),
+			Err:      err,
+			Duration: time.Since(startTime),
+		}, nil
+	}
+
 	return &e2eframe.TestResult{
 		TestName: t.TestName,
 		Passed:   true,
 		Message:  "All expectations passed",
 		Duration: time.Since(startTime),
+		Captured: captured,
 	}, nil
 }
 
-func (t *TestSuiteTest) runExpectations(ctx context.Context, client *mongo.Client, opts *e2eframe.TestSuiteTestRunOptions) error {
+func (t *TestSuiteTest) runExpectations(ctx context.Context, client *mongo.Client, opts *e2eframe.TestSuiteTestRunOptions) ([]map[string]interface{}, error) {
 	if t.Expect == nil {
-		return fmt.Errorf("no expectations provided")
+		return nil, fmt.Errorf("no expectations provided")
 	}
 
 	// Interpolate fixtures in collection_exists if needed
@@ -185,17 +202,17 @@ func (t *TestSuiteTest) runExpectations(ctx context.Context, client *mongo.Clien
 	// Check collection existence
 	if collectionExists != "" {
 		if err := t.verifyCollectionExists(ctx, client, collectionExists); err != nil {
-			return err
+			return nil, err
 		}
 		// If only checking collection existence, return early
 		if t.Collection == "" && t.Filter == nil && t.Pipeline == nil {
-			return nil
+			return nil, nil
 		}
 	}
 
 	// If no collection provided but we have other expectations, error
 	if t.Collection == "" {
-		return fmt.Errorf("no collection provided for expectations")
+		return nil, fmt.Errorf("no collection provided for expectations")
 	}
 
 	// Interpolate fixtures in collection name if needed
@@ -215,14 +232,14 @@ func (t *TestSuiteTest) runExpectations(ctx context.Context, client *mongo.Clien
 	if t.Filter != nil {
 		filter, err = t.parseAndInterpolateQuery(t.Filter, opts)
 		if err != nil {
-			return fmt.Errorf("failed to parse filter: %w", err)
+			return nil, fmt.Errorf("failed to parse filter: %w", err)
 		}
 	}
 
 	if t.Pipeline != nil {
 		pipeline, err = t.parseAndInterpolateQuery(t.Pipeline, opts)
 		if err != nil {
-			return fmt.Errorf("failed to parse pipeline: %w", err)
+			return nil, fmt.Errorf("failed to parse pipeline: %w", err)
 		}
 	}
 
@@ -271,16 +288,16 @@ func (t *TestSuiteTest) runExpectations(ctx context.Context, client *mongo.Clien
 		// Use aggregation
 		pipelineArray, ok := pipeline.([]interface{})
 		if !ok {
-			return fmt.Errorf("pipeline must be an array")
+			return nil, fmt.Errorf("pipeline must be an array")
 		}
 		cursor, err := coll.Aggregate(ctx, pipelineArray)
 		if err != nil {
-			return fmt.Errorf("aggregation execution failed: %w", err)
+			return nil, fmt.Errorf("aggregation execution failed: %w", err)
 		}
 		defer cursor.Close(ctx)
 
 		if err := cursor.All(ctx, &results); err != nil {
-			return fmt.Errorf("failed to decode aggregation results: %w", err)
+			return nil, fmt.Errorf("failed to decode aggregation results: %w", err)
 		}
 	} else {
 		// Use find
@@ -291,12 +308,12 @@ func (t *TestSuiteTest) runExpectations(ctx context.Context, client *mongo.Clien
 
 		cursor, err := coll.Find(ctx, findFilter)
 		if err != nil {
-			return fmt.Errorf("find execution failed: %w", err)
+			return nil, fmt.Errorf("find execution failed: %w", err)
 		}
 		defer cursor.Close(ctx)
 
 		if err := cursor.All(ctx, &results); err != nil {
-			return fmt.Errorf("failed to decode find results: %w", err)
+			return nil, fmt.Errorf("failed to decode find results: %w", err)
 		}
 	}
 
@@ -316,7 +333,91 @@ func (t *TestSuiteTest) runExpectations(ctx context.Context, client *mongo.Clien
 	}
 
 	// Verify expectations
-	return t.verifyExpectations(results)
+	if err := t.verifyExpectations(results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// captureValues extracts the runtime variables declared in the test's `capture:` block
+// from the query results. A source is a dot-separated field path read from the first
+// document, optionally prefixed with a zero-based document index (e.g. `1.profile.email`).
+func (t *TestSuiteTest) captureValues(results []map[string]interface{}) (map[string]string, error) {
+	if len(t.Capture) == 0 {
+		return nil, nil
+	}
+
+	captured := make(map[string]string, len(t.Capture))
+
+	for name, source := range t.Capture {
+		docIndex, fields := 0, strings.Split(source, ".")
+		if n, err := strconv.Atoi(fields[0]); err == nil && len(fields) > 1 {
+			docIndex, fields = n, fields[1:]
+		}
+
+		if docIndex < 0 || docIndex >= len(results) {
+			return nil, &e2eframe.CaptureMissingError{
+				Name:   name,
+				Source: source,
+				Reason: fmt.Sprintf("query returned %d documents", len(results)),
+			}
+		}
+
+		value, ok := lookupField(results[docIndex], fields)
+		if !ok {
+			return nil, &e2eframe.CaptureMissingError{Name: name, Source: source}
+		}
+
+		captured[name] = e2eframe.FormatCapturedValue(value)
+	}
+
+	return captured, nil
+}
+
+// validateCaptures checks that every capture source is a field path without
+// empty fields, optionally prefixed with a non-negative document index.
+func validateCaptures(captures e2eframe.Captures) error {
+	for name, source := range captures {
+		fields := strings.Split(source, ".")
+
+		if n, err := strconv.Atoi(fields[0]); err == nil && n < 0 && len(fields) > 1 {
+			return fmt.Errorf("capture %s: invalid source %q: document index must be non-negative", name, source)
+		}
+
+		for _, field := range fields {
+			if field == "" {
+				return fmt.Errorf("capture %s: invalid source %q: empty field name", name, source)
+			}
+		}
+	}
+
+	return nil
+}
+
+// lookupField walks a normalized document following the given field path.
+// Numeric path segments index into arrays.
+func lookupField(value interface{}, fields []string) (interface{}, bool) {
+	for _, field := range fields {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[field]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			idx, err := strconv.Atoi(field)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			value = v[idx]
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
 }
 
 func (t *TestSuiteTest) parseAndInterpolateQuery(query interface{}, opts *e2eframe.TestSuiteTestRunOptions) (interface{}, error) {
@@ -326,7 +427,7 @@ func (t *TestSuiteTest) parseAndInterpolateQuery(query interface{}, opts *e2efra
 		if opts != nil && len(opts.Fixtures) > 0 {
 			interpolationRegex := e2eframe.FixtureInterpolationRegex
 			if interpolationRegex.MatchString(strQuery) {
-				strQuery = e2eframe.InterpolateString(interpolationRegex, strQuery, opts.Fixtures)
+				strQuery = e2eframe.InterpolateJSON(interpolationRegex, strQuery, opts.Fixtures)
 			}
 		}
 
@@ -451,6 +552,14 @@ func (t *TestSuiteTest) normalizeValue(value interface{}) interface{} {
 		return nil
 	}
 
+	switch bsonValue := value.(type) {
+	case primitive.ObjectID:
+		// Compare and capture object ids by their hex representation
+		return bsonValue.Hex()
+	case primitive.D:
+		return t.normalizeValue(map[string]interface{}(bsonValue.Map()))
+	}
+
 	v := reflect.ValueOf(value)
 	switch v.Kind() {
 	case reflect.Map:
@@ -488,7 +597,8 @@ func (t *TestSuiteTest) interpolateExpectationValue(value interface{}, fixtures
 	if strVal, ok := value.(string); ok {
 		interpolationRegex := e2eframe.FixtureInterpolationRegex
 		if interpolationRegex.MatchString(strVal) {
-			return e2eframe.InterpolateString(interpolationRegex, strVal, fixtures)
+			// A single reference to a structured fixture keeps its JSON type
+			return e2eframe.InterpolateValue(interpolationRegex, strVal, fixtures)
 		}
 		return strVal
 	}
@@ -746,6 +856,14 @@ func (t *TestSuiteTest) UnmarshalYAML(node *yaml.Node) error {
 			if err := value.Decode(&t.Expect); err != nil {
 				return fmt.Errorf("failed to decode expect: %w", err)
 			}
+		case "capture":
+			if err := value.Decode(&t.Capture); err != nil {
+				return fmt.Errorf("failed to decode capture: %w", err)
+			}
+
+			if err := validateCaptures(t.Capture); err != nil {
+				return err
+			}
 		default:
 			// Ignore unknown fields for forward compatibility
 			continue
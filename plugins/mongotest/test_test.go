@@ -1,9 +1,12 @@
 package mongotest
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/exapsy/ene/e2eframe"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"gopkg.in/yaml.v3"
 )
 
@@ -859,3 +862,133 @@ func TestNormalizeValue(t *testing.T) {
 		})
 	}
 }
+
+func TestCaptureValues(t *testing.T) {
+	results := []map[string]interface{}{
+		{
+			"_id":     "6523f1c2a1b2c3d4e5f60718",
+			"name":    "Jane",
+			"profile": map[string]interface{}{"email": "jane@example.com"},
+			"tags":    []interface{}{"admin", "staff"},
+		},
+		{"_id": "6523f1c2a1b2c3d4e5f60719", "name": "John"},
+	}
+
+	tests := []struct {
+		name        string
+		capture     e2eframe.Captures
+		want        map[string]string
+		errContains string
+	}{
+		{
+			name:    "top level fields",
+			capture: e2eframe.Captures{"user_id": "_id", "user_name": "name"},
+			want:    map[string]string{"user_id": "6523f1c2a1b2c3d4e5f60718", "user_name": "Jane"},
+		},
+		{
+			name:    "nested field and array element",
+			capture: e2eframe.Captures{"email": "profile.email", "first_tag": "tags.0"},
+			want:    map[string]string{"email": "jane@example.com", "first_tag": "admin"},
+		},
+		{
+			name:    "indexed document",
+			capture: e2eframe.Captures{"second_id": "1._id"},
+			want:    map[string]string{"second_id": "6523f1c2a1b2c3d4e5f60719"},
+		},
+		{
+			name:    "structured value encoded as json",
+			capture: e2eframe.Captures{"tags": "tags"},
+			want:    map[string]string{"tags": `["admin","staff"]`},
+		},
+		{
+			name:        "missing field",
+			capture:     e2eframe.Captures{"age": "age"},
+			errContains: "capture age: no value found at age",
+		},
+		{
+			name:        "document out of range",
+			capture:     e2eframe.Captures{"third_id": "5._id"},
+			errContains: "query returned 2 documents",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := &TestSuiteTest{Capture: tt.capture}
+
+			got, err := test.captureValues(results)
+			if tt.errContains != "" {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error containing '%s', got '%s'", tt.errContains, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("captured %s = %q, want %q", name, got[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeObjectID(t *testing.T) {
+	test := &TestSuiteTest{}
+
+	oid, err := primitive.ObjectIDFromHex("6523f1c2a1b2c3d4e5f60718")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	doc := test.normalizeDocument(map[string]interface{}{
+		"_id":     oid,
+		"profile": primitive.D{{Key: "email", Value: "jane@example.com"}},
+	})
+
+	if doc["_id"] != "6523f1c2a1b2c3d4e5f60718" {
+		t.Errorf("expected object id hex, got %v (%T)", doc["_id"], doc["_id"])
+	}
+
+	profile, ok := doc["profile"].(map[string]interface{})
+	if !ok || profile["email"] != "jane@example.com" {
+		t.Errorf("expected nested document to be normalized to a map, got %v (%T)", doc["profile"], doc["profile"])
+	}
+}
+
+func TestUnmarshalYAMLRejectsInvalidCaptures(t *testing.T) {
+	tests := map[string]string{
+		"-1._id":         "document index must be non-negative",
+		"profile..email": "empty field name",
+		"profile.":       "empty field name",
+	}
+
+	for source, wantErr := range tests {
+		t.Run(source, func(t *testing.T) {
+			yamlContent := fmt.Sprintf(`
+name: "find user"
+kind: mongo
+collection: users
+capture:
+  user_id: %q
+`, source)
+
+			var node yaml.Node
+			if err := yaml.Unmarshal([]byte(yamlContent), &node); err != nil {
+				t.Fatalf("failed to unmarshal yaml: %v", err)
+			}
+
+			err := (&TestSuiteTest{}).UnmarshalYAML(node.Content[0])
+			if err == nil || !strings.Contains(err.Error(), wantErr) {
+				t.Errorf("expected error containing %q, got %v", wantErr, err)
+			}
+		})
+	}
+}
@@ -43,6 +43,7 @@ type MongoUnit struct {
 	database          string
 	startupTimeout    time.Duration
 	cmd               []string
+	logDir            string
 	EnvVars           map[string]any
 }
 
@@ -120,6 +121,8 @@ func (m *MongoUnit) Name() string {
 }
 
 func (m *MongoUnit) Start(ctx context.Context, opts *e2eframe.UnitStartOptions) error {
+	m.logDir = opts.LogDir
+
 	freePort, err := e2eframe.GetFreePort()
 	if err != nil {
 		return fmt.Errorf("get free port: %w", err)
@@ -127,6 +130,10 @@ func (m *MongoUnit) Start(ctx context.Context, opts *e2eframe.UnitStartOptions)
 
 	m.exposedPort = freePort
 
+	// Resolve the fixtures and unit variables referenced by the configuration
+	e2eframe.InterpolateUnitFields(opts.Fixtures, &m.Image, &m.MigrationFilePath)
+	e2eframe.InterpolateUnitFields(opts.Fixtures, e2eframe.StringFields(m.cmd)...)
+
 	// Use custom cmd if provided, otherwise use default MongoDB command
 	cmd := m.cmd
 	if len(cmd) == 0 {
@@ -285,7 +292,7 @@ func (m *MongoUnit) SaveRuntimeLogs(suiteName, reason string) (string, error) {
 	}
 
 	// Create log directory at project root .ene/<suite-name>/
-	logDir := filepath.Join(".ene", suiteName)
+	logDir := e2eframe.SuiteLogDir(m.logDir, suiteName)
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create log directory: %w", err)
 	}
@@ -318,7 +325,7 @@ func (m *MongoUnit) SaveRuntimeLogs(suiteName, reason string) (string, error) {
 	logContent += string(logBytes)
 
 	// Write to file
-	if err := os.WriteFile(logFilePath, []byte(logContent), 0644); err != nil {
+	if err := os.WriteFile(logFilePath, []byte(e2eframe.MaskSecrets(logContent)), 0644); err != nil {
 		return "", fmt.Errorf("failed to write log file: %w", err)
 	}
 
@@ -414,11 +421,16 @@ func (m *MongoUnit) UnmarshalYAML(node *yaml.Node) error {
 	return nil
 }
 
-func (m *MongoUnit) GetEnvRaw(_ *e2eframe.GetEnvRawOptions) map[string]string {
+func (m *MongoUnit) GetEnvRaw(opts *e2eframe.GetEnvRawOptions) map[string]string {
 	envs := make(map[string]string)
 
 	if m.envFile != "" {
-		file, err := os.ReadFile(m.envFile)
+		envFilePath := opts.Interpolate(m.envFile)
+		if opts != nil && opts.WorkingDir != "" {
+			envFilePath = filepath.Join(opts.WorkingDir, envFilePath)
+		}
+
+		file, err := os.ReadFile(envFilePath)
 		if err != nil {
 			return nil
 		}
@@ -451,6 +463,10 @@ func (m *MongoUnit) GetEnvRaw(_ *e2eframe.GetEnvRawOptions) map[string]string {
 }
 
 func (s *MongoUnit) SetEnvs(env map[string]string) {
+	if s.EnvVars == nil {
+		s.EnvVars = make(map[string]any)
+	}
+
 	for key, val := range env {
 		s.EnvVars[key] = val
 	}
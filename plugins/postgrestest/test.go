@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/exapsy/ene/e2eframe"
@@ -23,6 +25,7 @@ type TestSuiteTest struct {
 	Debug            bool                  `yaml:"debug"`  // Optional: enable debug output for this test
 	Query            string                `yaml:"query"`
 	Expect           *PostgresExpectations `yaml:"expect"`
+	Capture          e2eframe.Captures     `yaml:"capture"` // Optional: runtime variables to capture from the result
 	PostgresEndpoint string
 	testSuite        e2eframe.TestSuite
 }
@@ -109,7 +112,7 @@ func (t *TestSuiteTest) Run(ctx context.Context, opts *e2eframe.TestSuiteTestRun
 			TestName: t.TestName,
 			Passed:   false,
 			Message:  fmt.Sprintf("Failed to connect to Postgres: %v", err),
-			Err:      err,
+			Err:      &e2eframe.TransportError{Err: err},
 			Duration: time.Since(startTime),
 		}, nil
 	}
@@ -125,13 +128,14 @@ func (t *TestSuiteTest) Run(ctx context.Context, opts *e2eframe.TestSuiteTestRun
 			TestName: t.TestName,
 			Passed:   false,
 			Message:  fmt.Sprintf("Failed to ping Postgres: %v", err),
-			Err:      err,
+			Err:      &e2eframe.TransportError{Err: err},
 			Duration: time.Since(startTime),
 		}, nil
 	}
 
 	// Run expectations
-	if err := t.runExpectations(ctx, db, opts); err != nil {
+	results, err := t.runExpectations(ctx, db, opts)
+	if err != nil {
 		return &e2eframe.TestResult{
 			TestName: t.TestName,
 			Passed:   false,
@@ -141,17 +145,29 @@ func (t *TestSuiteTest) Run(ctx context.Context, opts *e2eframe.TestSuiteTestRun
 		}, nil
 	}
 
+	captured, err := t.captureValues(results)
+	if err != nil {
+		return &e2eframe.TestResult{
+			TestName: t.TestName,
+			Passed:   false,
+			Message:  fmt.Sprintf("Capture failed: %v", err),
+			Err:      err,
+			Duration: time.Since(startTime),
+		}, nil
+	}
+
 	return &e2eframe.TestResult{
 		TestName: t.TestName,
 		Passed:   true,
 		Message:  "All expectations passed",
 		Duration: time.Since(startTime),
+		Captured: captured,
 	}, nil
 }
 
-func (t *TestSuiteTest) runExpectations(ctx context.Context, db *sql.DB, opts *e2eframe.TestSuiteTestRunOptions) error {
+func (t *TestSuiteTest) runExpectations(ctx context.Context, db *sql.DB, opts *e2eframe.TestSuiteTestRunOptions) ([]map[string]interface{}, error) {
 	if t.Expect == nil {
-		return fmt.Errorf("no expectations provided")
+		return nil, fmt.Errorf("no expectations provided")
 	}
 
 	// Interpolate fixtures in table_exists if needed
@@ -166,17 +182,17 @@ func (t *TestSuiteTest) runExpectations(ctx context.Context, db *sql.DB, opts *e
 	// Check table existence
 	if tableExists != "" {
 		if err := t.verifyTableExists(ctx, db, tableExists); err != nil {
-			return err
+			return nil, err
 		}
 		// If only checking table existence, return early
 		if t.Query == "" && t.Expect.RowCount == nil && len(t.Expect.Rows) == 0 {
-			return nil
+			return nil, nil
 		}
 	}
 
 	// If no query provided but we have other expectations, error
 	if t.Query == "" {
-		return fmt.Errorf("no query provided for expectations")
+		return nil, fmt.Errorf("no query provided for expectations")
 	}
 
 	// Interpolate fixtures in query if needed
@@ -218,14 +234,14 @@ func (t *TestSuiteTest) runExpectations(ctx context.Context, db *sql.DB, opts *e
 	// Execute query
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
-		return fmt.Errorf("query execution failed: %w", err)
+		return nil, fmt.Errorf("query execution failed: %w", err)
 	}
 	defer rows.Close()
 
 	// Get column names
 	columns, err := rows.Columns()
 	if err != nil {
-		return fmt.Errorf("failed to get columns: %w", err)
+		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
 
 	// Collect all rows
@@ -239,7 +255,7 @@ func (t *TestSuiteTest) runExpectations(ctx context.Context, db *sql.DB, opts *e
 		}
 
 		if err := rows.Scan(valuePtrs...); err != nil {
-			return fmt.Errorf("failed to scan row: %w", err)
+			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
 		// Create a map for this row
@@ -256,7 +272,7 @@ func (t *TestSuiteTest) runExpectations(ctx context.Context, db *sql.DB, opts *e
 	}
 
 	if err := rows.Err(); err != nil {
-		return fmt.Errorf("error iterating rows: %w", err)
+		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
 	// Log results in verbose or debug mode
@@ -273,7 +289,72 @@ func (t *TestSuiteTest) runExpectations(ctx context.Context, db *sql.DB, opts *e
 	}
 
 	// Verify expectations
-	return t.verifyExpectations(results, columns)
+	if err := t.verifyExpectations(results, columns); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// captureValues extracts the runtime variables declared in the test's `capture:` block
+// from the query results. A source is either a column name, read from the first row,
+// or `<row>.<column>` with a zero-based row index.
+func (t *TestSuiteTest) captureValues(results []map[string]interface{}) (map[string]string, error) {
+	if len(t.Capture) == 0 {
+		return nil, nil
+	}
+
+	captured := make(map[string]string, len(t.Capture))
+
+	for name, source := range t.Capture {
+		rowIndex, column := 0, source
+		if idx, col, ok := strings.Cut(source, "."); ok {
+			n, err := strconv.Atoi(idx)
+			if err != nil {
+				return nil, &e2eframe.CaptureMissingError{Name: name, Source: source, Reason: "row index must be a number"}
+			}
+
+			rowIndex, column = n, col
+		}
+
+		if rowIndex < 0 || rowIndex >= len(results) {
+			return nil, &e2eframe.CaptureMissingError{
+				Name:   name,
+				Source: source,
+				Reason: fmt.Sprintf("query returned %d rows", len(results)),
+			}
+		}
+
+		value, exists := results[rowIndex][column]
+		if !exists {
+			return nil, &e2eframe.CaptureMissingError{Name: name, Source: source}
+		}
+
+		captured[name] = e2eframe.FormatCapturedValue(value)
+	}
+
+	return captured, nil
+}
+
+// validateCaptures checks that every capture source is a column name or
+// `<row>.<column>` with a non-negative row index.
+func validateCaptures(captures e2eframe.Captures) error {
+	for name, source := range captures {
+		column := source
+		if idx, col, ok := strings.Cut(source, "."); ok {
+			if n, err := strconv.Atoi(idx); err != nil || n < 0 {
+				return fmt.Errorf("capture %s: invalid source %q: row index must be a non-negative number", name, source)
+			}
+
+			column = col
+		}
+
+		if column == "" {
+			return fmt.Errorf("capture %s: invalid source %q: missing column name", name, source)
+		}
+	}
+
+	return nil
 }
 
 func (t *TestSuiteTest) verifyTableExists(ctx context.Context, db *sql.DB, tableName string) error {
@@ -376,7 +457,8 @@ func (t *TestSuiteTest) interpolateExpectationValue(value interface{}, fixtures
 	if strVal, ok := value.(string); ok {
 		interpolationRegex := e2eframe.FixtureInterpolationRegex
 		if interpolationRegex.MatchString(strVal) {
-			return e2eframe.InterpolateString(interpolationRegex, strVal, fixtures)
+			// A single reference to a structured fixture keeps its JSON type
+			return e2eframe.InterpolateValue(interpolationRegex, strVal, fixtures)
 		}
 		return strVal
 	}
@@ -626,6 +708,14 @@ func (t *TestSuiteTest) UnmarshalYAML(node *yaml.Node) error {
 			if err := value.Decode(&t.Expect); err != nil {
 				return fmt.Errorf("failed to decode expect: %w", err)
 			}
+		case "capture":
+			if err := value.Decode(&t.Capture); err != nil {
+				return fmt.Errorf("failed to decode capture: %w", err)
+			}
+
+			if err := validateCaptures(t.Capture); err != nil {
+				return err
+			}
 		default:
 			// Ignore unknown fields for forward compatibility
 			continue
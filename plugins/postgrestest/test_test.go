@@ -1,6 +1,8 @@
 package postgrestest
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/exapsy/ene/e2eframe"
@@ -808,3 +810,139 @@ func TestInterpolateExpectationRowsEmptyFixtures(t *testing.T) {
 		t.Errorf("email should be unchanged, got %v", result[0]["email"])
 	}
 }
+
+func TestCaptureValues(t *testing.T) {
+	results := []map[string]interface{}{
+		{"id": int64(7), "email": "jane@example.com", "deleted_at": nil},
+		{"id": int64(8), "email": "john@example.com", "deleted_at": nil},
+	}
+
+	tests := []struct {
+		name        string
+		capture     e2eframe.Captures
+		results     []map[string]interface{}
+		want        map[string]string
+		errContains string
+	}{
+		{
+			name:    "no capture block",
+			results: results,
+			want:    nil,
+		},
+		{
+			name:    "column from first row",
+			capture: e2eframe.Captures{"user_id": "id", "user_email": "email"},
+			results: results,
+			want:    map[string]string{"user_id": "7", "user_email": "jane@example.com"},
+		},
+		{
+			name:    "column from indexed row",
+			capture: e2eframe.Captures{"second_id": "1.id"},
+			results: results,
+			want:    map[string]string{"second_id": "8"},
+		},
+		{
+			name:        "unknown column",
+			capture:     e2eframe.Captures{"name": "name"},
+			results:     results,
+			errContains: "capture name: no value found at name",
+		},
+		{
+			name:        "row out of range",
+			capture:     e2eframe.Captures{"third_id": "2.id"},
+			results:     results,
+			errContains: "query returned 2 rows",
+		},
+		{
+			name:        "no rows",
+			capture:     e2eframe.Captures{"user_id": "id"},
+			results:     nil,
+			errContains: "query returned 0 rows",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := &TestSuiteTest{Capture: tt.capture}
+
+			got, err := test.captureValues(tt.results)
+			if tt.errContains != "" {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				if !contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error containing '%s', got '%s'", tt.errContains, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d captured values, got %d (%v)", len(tt.want), len(got), got)
+			}
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("captured %s = %q, want %q", name, got[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshalYAMLCapture(t *testing.T) {
+	yamlContent := `
+name: "find user"
+kind: postgres
+query: "SELECT id FROM users LIMIT 1"
+expect:
+  row_count: 1
+capture:
+  user_id: id
+`
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(yamlContent), &node); err != nil {
+		t.Fatalf("failed to unmarshal yaml: %v", err)
+	}
+
+	test := &TestSuiteTest{}
+	if err := test.UnmarshalYAML(node.Content[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if test.Capture["user_id"] != "id" {
+		t.Errorf("expected capture user_id -> id, got %v", test.Capture)
+	}
+}
+
+func TestUnmarshalYAMLRejectsInvalidCaptures(t *testing.T) {
+	tests := map[string]string{
+		"-1.id": "row index must be a non-negative number",
+		"x.id":  "row index must be a non-negative number",
+		"0.":    "missing column name",
+	}
+
+	for source, wantErr := range tests {
+		t.Run(source, func(t *testing.T) {
+			yamlContent := fmt.Sprintf(`
+name: "find user"
+kind: postgres
+query: "SELECT id FROM users LIMIT 1"
+capture:
+  user_id: %q
+`, source)
+
+			var node yaml.Node
+			if err := yaml.Unmarshal([]byte(yamlContent), &node); err != nil {
+				t.Fatalf("failed to unmarshal yaml: %v", err)
+			}
+
+			err := (&TestSuiteTest{}).UnmarshalYAML(node.Content[0])
+			if err == nil || !strings.Contains(err.Error(), wantErr) {
+				t.Errorf("expected error containing %q, got %v", wantErr, err)
+			}
+		})
+	}
+}
@@ -53,6 +53,7 @@ type PostgresUnit struct {
 	user           string
 	password       string
 	cmd            []string
+	logDir         string
 	EnvVars        map[string]any
 }
 
@@ -148,6 +149,8 @@ func (p *PostgresUnit) Name() string {
 }
 
 func (p *PostgresUnit) Start(ctx context.Context, opts *e2eframe.UnitStartOptions) error {
+	p.logDir = opts.LogDir
+
 	freePort, err := e2eframe.GetFreePort()
 	if err != nil {
 		return fmt.Errorf("get free port: %w", err)
@@ -155,6 +158,10 @@ func (p *PostgresUnit) Start(ctx context.Context, opts *e2eframe.UnitStartOption
 
 	p.exposedPort = freePort
 
+	// Resolve the fixtures and unit variables referenced by the configuration
+	e2eframe.InterpolateUnitFields(opts.Fixtures, &p.Image, &p.MigrationsPath)
+	e2eframe.InterpolateUnitFields(opts.Fixtures, e2eframe.StringFields(p.cmd)...)
+
 	// Emit starting event
 	p.sendEvent(opts.EventSink, e2eframe.EventContainerStarting,
 		fmt.Sprintf("starting PostgreSQL container %s", p.serviceName))
@@ -257,7 +264,7 @@ func (p *PostgresUnit) SaveRuntimeLogs(suiteName, reason string) (string, error)
 	}
 
 	// Create log directory at project root .ene/<suite-name>/
-	logDir := filepath.Join(".ene", suiteName)
+	logDir := e2eframe.SuiteLogDir(p.logDir, suiteName)
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create log directory: %w", err)
 	}
@@ -290,7 +297,7 @@ func (p *PostgresUnit) SaveRuntimeLogs(suiteName, reason string) (string, error)
 	logContent += string(logBytes)
 
 	// Write to file
-	if err := os.WriteFile(logFilePath, []byte(logContent), 0644); err != nil {
+	if err := os.WriteFile(logFilePath, []byte(e2eframe.MaskSecrets(logContent)), 0644); err != nil {
 		return "", fmt.Errorf("failed to write log file: %w", err)
 	}
 
@@ -445,9 +452,9 @@ func (p *PostgresUnit) GetEnvRaw(opts *e2eframe.GetEnvRawOptions) map[string]str
 	envs := make(map[string]string)
 
 	if p.envFile != "" {
-		envFilePath := p.envFile
-		if opts.WorkingDir != "" {
-			envFilePath = filepath.Join(opts.WorkingDir, p.envFile)
+		envFilePath := opts.Interpolate(p.envFile)
+		if opts != nil && opts.WorkingDir != "" {
+			envFilePath = filepath.Join(opts.WorkingDir, envFilePath)
 		}
 
 		file, err := os.ReadFile(envFilePath)